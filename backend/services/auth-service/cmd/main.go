@@ -3,12 +3,19 @@ package main
 import (
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"auth-service/internal/audit"
 	"auth-service/internal/auth"
 	"auth-service/internal/config"
 	"auth-service/internal/database"
+	"auth-service/internal/federation"
 	"auth-service/internal/handlers"
+	"auth-service/internal/mail"
 	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/orders"
 	"auth-service/internal/repositories"
 	"auth-service/internal/services"
 
@@ -34,6 +41,9 @@ import (
 
 // @host localhost:8081
 // @BasePath /api/v1
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
 func main() {
 	cfg, err := config.LoadConfig("config/config.yaml")
 	if err != nil {
@@ -50,15 +60,186 @@ func main() {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	if err := db.SeedRoles(cfg.RBAC.BootstrapAdmins); err != nil {
+		log.Fatalf("Failed to seed roles: %v", err)
+	}
+
 	userRepo := repositories.NewUserRepository(db.GetDB())
-	jwtService := auth.NewJWTService(cfg.JWT.Secret, cfg.JWT.ExpirationTime)
+	tokenRepo := repositories.NewTokenRepository(db.GetDB())
+	roleRepo := repositories.NewRoleRepository(db.GetDB())
+
+	var keyManager *auth.KeyManager
+	if cfg.JWT.Algorithm != "" && cfg.JWT.Algorithm != auth.AlgorithmHS256 {
+		if cfg.JWT.KeyGracePeriod < cfg.JWT.ExpirationTime {
+			log.Fatalf("JWT key grace period (%dh) must not be shorter than the token lifetime (%dh)",
+				cfg.JWT.KeyGracePeriod, cfg.JWT.ExpirationTime)
+		}
+
+		keyBox, err := auth.NewSecretBox(cfg.JWT.KeyEncryptionKey)
+		if err != nil {
+			log.Fatalf("Failed to initialize signing key encryption: %v", err)
+		}
+
+		keyManager, err = auth.NewKeyManager(
+			repositories.NewSigningKeyRepository(db.GetDB()),
+			keyBox,
+			cfg.JWT.Algorithm,
+			time.Duration(cfg.JWT.KeyRotationInterval)*time.Hour,
+			time.Duration(cfg.JWT.KeyGracePeriod)*time.Hour,
+		)
+		if err != nil {
+			log.Fatalf("Failed to initialize signing keys: %v", err)
+		}
+		go keyManager.Run(5 * time.Minute)
+	}
+
+	jwtService := auth.NewJWTService(cfg.JWT.Secret, keyManager, cfg.JWT.Issuer, cfg.JWT.ExpirationTime, cfg.JWT.RefreshExpirationTime)
+	if keyManager == nil {
+		if cfg.JWT.Secret == "" {
+			log.Fatalf("JWT secret must be set for %s signing", auth.AlgorithmHS256)
+		}
+	} else if cfg.JWT.AcceptHS256Until != "" {
+		deadline, err := time.Parse(time.RFC3339, cfg.JWT.AcceptHS256Until)
+		if err != nil {
+			log.Fatalf("Invalid jwt.accept_hs256_until: %v", err)
+		}
+		if cfg.JWT.Secret == "" {
+			log.Fatalf("jwt.accept_hs256_until requires jwt.secret")
+		}
+		if time.Now().Before(deadline) {
+			log.Printf("Accepting HS256 tokens signed with the shared secret until %s", deadline.Format(time.RFC3339))
+		}
+		jwtService.AcceptHMACUntil(deadline)
+	}
+
+	mailer, err := mail.NewMailer(cfg.Mail.Driver, cfg.Mail.From, cfg.Mail.SMTP.Host, cfg.Mail.SMTP.Port,
+		cfg.Mail.SMTP.Username, cfg.Mail.SMTP.Password, cfg.Mail.Dir)
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
+	passwordPolicy := &auth.PasswordPolicy{
+		MinLength:            cfg.PasswordPolicy.MinLength,
+		MaxLength:            cfg.PasswordPolicy.MaxLength,
+		RequireUpper:         cfg.PasswordPolicy.RequireUppercase,
+		RequireLower:         cfg.PasswordPolicy.RequireLowercase,
+		RequireDigit:         cfg.PasswordPolicy.RequireDigit,
+		RequireSymbol:        cfg.PasswordPolicy.RequireSymbol,
+		DisallowPersonalInfo: cfg.PasswordPolicy.DisallowPersonalInfo,
+	}
+	if cfg.PasswordPolicy.BreachedList != "" {
+		passwordPolicy.Breached, err = auth.LoadBreachedPasswords(cfg.PasswordPolicy.BreachedList)
+		if err != nil {
+			log.Fatalf("Failed to load breached password list: %v", err)
+		}
+		log.Printf("Loaded %d breached password hashes", passwordPolicy.Breached.Len())
+	}
+
+	passwordHasher, err := auth.NewPasswordHasher(cfg.PasswordHashing.Algorithm, auth.Argon2Params{
+		Memory:      cfg.PasswordHashing.Argon2.Memory,
+		Iterations:  cfg.PasswordHashing.Argon2.Iterations,
+		Parallelism: cfg.PasswordHashing.Argon2.Parallelism,
+		SaltLength:  cfg.PasswordHashing.Argon2.SaltLength,
+		KeyLength:   cfg.PasswordHashing.Argon2.KeyLength,
+	}, cfg.PasswordHashing.BcryptCost)
+	if err != nil {
+		log.Fatalf("Failed to initialize password hashing: %v", err)
+	}
+
+	auditor := audit.NewLogger(repositories.NewAuditEventRepository(db.GetDB()), cfg.Audit.QueueSize, cfg.Audit.BatchSize,
+		time.Duration(cfg.Audit.FlushInterval)*time.Millisecond)
 
-	authService := services.NewAuthService(userRepo, jwtService)
+	var attemptStore auth.AttemptStore
+	switch cfg.LoginProtection.Store {
+	case auth.ThrottleStoreDatabase:
+		attemptStore = repositories.NewLoginAttemptRepository(db.GetDB())
+	case auth.ThrottleStoreMemory, "":
+		attemptStore = auth.NewMemoryAttemptStore()
+	default:
+		log.Fatalf("Unsupported login protection store: %s", cfg.LoginProtection.Store)
+	}
+
+	loginThrottle := auth.NewLoginThrottle(attemptStore, auth.ThrottlePolicy{
+		MaxAttempts:        cfg.LoginProtection.MaxAttempts,
+		IPMaxAttempts:      cfg.LoginProtection.IPMaxAttempts,
+		Window:             time.Duration(cfg.LoginProtection.Window) * time.Second,
+		LockoutDuration:    time.Duration(cfg.LoginProtection.LockoutDuration) * time.Second,
+		MaxLockoutDuration: time.Duration(cfg.LoginProtection.MaxLockoutDuration) * time.Second,
+	})
+
+	accountService := services.NewAccountService(userRepo, tokenRepo, mailer, cfg.Account.PublicURL,
+		time.Duration(cfg.Account.PasswordResetTTL)*time.Minute,
+		time.Duration(cfg.Account.EmailVerificationTTL)*time.Hour,
+		cfg.Account.RequireEmailVerification, passwordPolicy, passwordHasher, loginThrottle, auditor)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	secretBox, err := auth.NewSecretBox(cfg.MFA.EncryptionKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize MFA secret encryption: %v", err)
+	}
+
+	mfaService := services.NewMFAService(repositories.NewMFARepository(db.GetDB()), userRepo, tokenRepo, secretBox,
+		loginThrottle, cfg.MFA.Issuer, time.Duration(cfg.MFA.ChallengeTTL)*time.Minute)
+	mfaHandler := handlers.NewMFAHandler(mfaService)
+	oauthService := services.NewOAuthService(repositories.NewOAuthClientRepository(db.GetDB()), roleRepo, jwtService,
+		time.Duration(cfg.OAuth.ClientTokenTTL)*time.Minute)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db.GetDB())
+	orgRepo := repositories.NewOrganizationRepository(db.GetDB())
+	authService := services.NewAuthService(userRepo, tokenRepo, roleRepo, jwtService, accountService, mfaService, loginThrottle, oauthService, apiKeyRepo, orgRepo, auditor)
+	oidcService := services.NewOIDCService(authService, oauthService, mfaService, userRepo, tokenRepo, jwtService,
+		time.Duration(cfg.OAuth.AuthorizationCodeTTL)*time.Second)
+	oauthHandler := handlers.NewOAuthHandler(oauthService, authService, oidcService)
+
+	var identityProviders []*federation.Provider
+	for _, provider := range cfg.Federation.Providers {
+		identityProviders = append(identityProviders, federation.NewProvider(federation.ProviderConfig{
+			Name:           provider.Name,
+			DisplayName:    provider.DisplayName,
+			Issuer:         provider.Issuer,
+			ClientID:       provider.ClientID,
+			ClientSecret:   provider.ClientSecret,
+			Scopes:         provider.Scopes,
+			RedirectURL:    strings.TrimSuffix(cfg.OAuth.PublicURL, "/") + "/api/v1/auth/federated/" + provider.Name + "/callback",
+			AllowedDomains: provider.AllowedDomains,
+			AutoProvision:  provider.AutoProvision,
+			LinkByEmail:    provider.LinkByEmail,
+			TrustEmail:     provider.TrustEmail,
+			DefaultRoles:   provider.DefaultRoles,
+		}, nil))
+	}
+	federationService := services.NewFederationService(identityProviders, authService, oidcService, mfaService, userRepo,
+		repositories.NewIdentityRepository(db.GetDB()), tokenRepo, roleRepo, time.Duration(cfg.Federation.StateTTL)*time.Second)
+	federationHandler := handlers.NewFederationHandler(federationService, cfg.Federation.StateTTL)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, federationService.Providers())
 	authHandler := handlers.NewAuthHandler(authService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(services.NewAPIKeyService(apiKeyRepo, userRepo, authService))
+	roleService := services.NewRoleService(roleRepo, userRepo)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	userAdminService := services.NewUserAdminService(userRepo, tokenRepo, auditor)
+	userAdminHandler := handlers.NewUserAdminHandler(userAdminService)
+	auditHandler := handlers.NewAuditHandler(services.NewAuditService(repositories.NewAuditEventRepository(db.GetDB())))
+	var orderClient *orders.Client
+	if cfg.Privacy.OrderServiceURL != "" {
+		orderClient = orders.NewClient(cfg.Privacy.OrderServiceURL, services.ServiceTokenSource(jwtService),
+			time.Duration(cfg.Privacy.RequestTimeout)*time.Second)
+	} else {
+		log.Println("No order service configured, data exports and erasures leave orders out")
+	}
+	privacyHandler := handlers.NewPrivacyHandler(services.NewPrivacyService(userRepo, tokenRepo,
+		repositories.NewIdentityRepository(db.GetDB()), apiKeyRepo, repositories.NewAuditEventRepository(db.GetDB()),
+		repositories.NewErasureRepository(db.GetDB()), loginThrottle, jwtService, orderClient, auditor))
+	organizationHandler := handlers.NewOrganizationHandler(services.NewOrganizationService(orgRepo, userRepo, tokenRepo, authService))
+	sessionHandler := handlers.NewSessionHandler(services.NewSessionService(tokenRepo, userRepo))
+	wellKnownHandler := handlers.NewWellKnownHandler(jwtService,
+		handlers.NewDiscoveryDocument(jwtService.Issuer(), cfg.OAuth.PublicURL, cfg.JWT.Algorithm))
 
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(gin.Logger())
+	// Gin trusts every proxy by default, which would let clients choose the IP
+	// the login lockout counts against
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
 	router.Use(middleware.PrometheusMiddleware())
 
 	// Swagger endpoint
@@ -71,10 +252,129 @@ func main() {
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
 			auth.GET("/validate", authHandler.ValidateToken)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/password/forgot", accountHandler.ForgotPassword)
+			auth.POST("/password/reset", accountHandler.ResetPassword)
+			auth.POST("/email/verify", accountHandler.VerifyEmail)
+			auth.POST("/email/resend", accountHandler.ResendVerification)
+			auth.POST("/email/change/confirm", accountHandler.ConfirmEmailChange)
+			auth.POST("/mfa/verify", authHandler.VerifyMFA)
+			auth.GET("/providers", federationHandler.ListProviders)
+			auth.GET("/federated/:provider/login", federationHandler.Login)
+			auth.GET("/federated/:provider/callback", federationHandler.Callback)
+			auth.GET("/identities", middleware.AuthMiddleware(authService), federationHandler.ListIdentities)
+			auth.GET("/me", middleware.AuthMiddleware(authService), accountHandler.GetMe)
+			auth.PATCH("/me", middleware.AuthMiddleware(authService), middleware.RejectAPIKeys(), accountHandler.UpdateMe)
+			auth.POST("/password/change", middleware.AuthMiddleware(authService), middleware.RejectAPIKeys(), accountHandler.ChangePassword)
+			auth.POST("/email/change", middleware.AuthMiddleware(authService), middleware.RejectAPIKeys(), accountHandler.ChangeEmail)
+			auth.GET("/me/export", middleware.AuthMiddleware(authService), middleware.RejectAPIKeys(), privacyHandler.ExportData)
+			auth.POST("/me/erase", middleware.AuthMiddleware(authService), middleware.RejectAPIKeys(), privacyHandler.EraseAccount)
+			auth.POST("/erasure-receipts/verify", privacyHandler.VerifyErasureReceipt)
+			auth.GET("/orgs", middleware.AuthMiddleware(authService), organizationHandler.ListMyOrganizations)
+			auth.POST("/orgs/switch", organizationHandler.SwitchOrganization)
+
+			mfa := auth.Group("/mfa")
+			mfa.Use(middleware.AuthMiddleware(authService), middleware.RejectAPIKeys())
+			{
+				mfa.GET("", mfaHandler.Status)
+				mfa.POST("/setup", mfaHandler.Setup)
+				mfa.POST("/enable", mfaHandler.Enable)
+				mfa.POST("/disable", mfaHandler.Disable)
+				mfa.POST("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+			}
+
+			apiKeys := auth.Group("/api-keys")
+			apiKeys.Use(middleware.AuthMiddleware(authService), middleware.RejectAPIKeys())
+			{
+				apiKeys.GET("", apiKeyHandler.ListKeys)
+				apiKeys.POST("", apiKeyHandler.CreateKey)
+				apiKeys.DELETE("/:id", apiKeyHandler.RevokeKey)
+			}
+
+			sessions := auth.Group("/sessions")
+			sessions.Use(middleware.AuthMiddleware(authService), middleware.RejectAPIKeys())
+			{
+				sessions.GET("", sessionHandler.ListSessions)
+				sessions.DELETE("", sessionHandler.RevokeOtherSessions)
+				sessions.DELETE("/:id", sessionHandler.RevokeSession)
+			}
 			//auth.GET("/users/:id", authHandler.GetUser)
 		}
+
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(authService))
+		{
+			rbac := admin.Group("")
+			rbac.Use(middleware.RequirePermission(models.PermissionRolesManage))
+			{
+				rbac.GET("/roles", roleHandler.ListRoles)
+				rbac.POST("/roles", roleHandler.CreateRole)
+				rbac.PUT("/roles/:id/permissions", roleHandler.SetRolePermissions)
+				rbac.GET("/permissions", roleHandler.ListPermissions)
+				rbac.GET("/users/:id/roles", roleHandler.GetUserRoles)
+				rbac.POST("/users/:id/roles", roleHandler.AssignRole)
+				rbac.DELETE("/users/:id/roles/:role", roleHandler.RemoveRole)
+			}
+
+			usersRead := admin.Group("/users")
+			usersRead.Use(middleware.RequirePermission(models.PermissionUsersRead))
+			{
+				usersRead.GET("", userAdminHandler.ListUsers)
+				usersRead.GET("/:id", userAdminHandler.GetUser)
+				usersRead.GET("/:id/sessions", sessionHandler.ListUserSessions)
+			}
+
+			usersWrite := admin.Group("/users")
+			usersWrite.Use(middleware.RequirePermission(models.PermissionUsersWrite))
+			{
+				usersWrite.PUT("/:id/status", userAdminHandler.SetUserStatus)
+				usersWrite.DELETE("/:id", userAdminHandler.DeleteUser)
+				usersWrite.POST("/:id/restore", userAdminHandler.RestoreUser)
+				usersWrite.POST("/:id/erase", privacyHandler.EraseUser)
+				usersWrite.DELETE("/:id/sessions", sessionHandler.RevokeUserSessions)
+				usersWrite.DELETE("/:id/sessions/:session", sessionHandler.RevokeUserSession)
+			}
+
+			auditEvents := admin.Group("/audit-events")
+			auditEvents.Use(middleware.RequirePermission(models.PermissionAuditRead))
+			{
+				auditEvents.GET("", auditHandler.ListEvents)
+				auditEvents.GET("/export", auditHandler.ExportEvents)
+			}
+
+			orgs := admin.Group("/orgs")
+			orgs.Use(middleware.RequirePermission(models.PermissionOrgsManage))
+			{
+				orgs.GET("", organizationHandler.ListOrganizations)
+				orgs.POST("", organizationHandler.CreateOrganization)
+				orgs.GET("/:id/members", organizationHandler.ListMembers)
+				orgs.PUT("/:id/members/:user_id", organizationHandler.SetMember)
+				orgs.DELETE("/:id/members/:user_id", organizationHandler.RemoveMember)
+			}
+
+			clients := admin.Group("/oauth-clients")
+			clients.Use(middleware.RequirePermission(models.PermissionClientsManage))
+			{
+				clients.GET("", oauthHandler.ListClients)
+				clients.POST("", oauthHandler.CreateClient)
+				clients.GET("/:id", oauthHandler.GetClient)
+				clients.PUT("/:id", oauthHandler.UpdateClient)
+				clients.DELETE("/:id", oauthHandler.DeleteClient)
+				clients.POST("/:id/secret", oauthHandler.RotateSecret)
+			}
+		}
 	}
 
+	router.POST("/oauth/token", oauthHandler.Token)
+	router.POST("/oauth/introspect", oauthHandler.Introspect)
+	router.GET("/oauth/authorize", oidcHandler.Authorize)
+	router.POST("/oauth/authorize", oidcHandler.AuthorizeLogin)
+	router.GET("/oauth/userinfo", oidcHandler.UserInfo)
+	router.POST("/oauth/userinfo", oidcHandler.UserInfo)
+	router.GET("/.well-known/jwks.json", wellKnownHandler.JWKS)
+	router.GET("/.well-known/openid-configuration", wellKnownHandler.OpenIDConfiguration)
+
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "healthy",
@@ -85,6 +385,20 @@ func main() {
 	// Prometheus metrics endpoint
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	// Periodically drop expired tokens and stale login attempt counters
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := tokenRepo.DeleteExpired(); err != nil {
+				log.Printf("Failed to clean up expired tokens: %v", err)
+			}
+			if err := loginThrottle.Prune(); err != nil {
+				log.Printf("Failed to clean up login attempts: %v", err)
+			}
+		}
+	}()
+
 	// var wg sync.WaitGroup
 	// wg.Add(1)
 	// go func() {
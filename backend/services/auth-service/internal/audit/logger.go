@@ -0,0 +1,127 @@
+// Package audit records the security audit trail without slowing down the
+// requests that produce it.
+package audit
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+)
+
+// maxUserAgentLength and maxReasonLength match the audit_events columns
+const (
+	maxUserAgentLength = 255
+	maxReasonLength    = 255
+)
+
+// Store persists audit events.
+type Store interface {
+	CreateBatch(events []*models.AuditEvent) error
+}
+
+// Logger queues audit events and writes them in batches from a background
+// goroutine. Record never blocks: when the queue is full the event is dropped,
+// logged and counted in auth_audit_events_dropped_total, so a slow database
+// can not stall logins.
+type Logger struct {
+	store     Store
+	events    chan *models.AuditEvent
+	batchSize int
+	interval  time.Duration
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewLogger starts a logger that holds up to queueSize pending events and
+// writes them at least every interval, or as soon as batchSize are pending.
+func NewLogger(store Store, queueSize, batchSize int, interval time.Duration) *Logger {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	l := &Logger{
+		store:     store,
+		events:    make(chan *models.AuditEvent, queueSize),
+		batchSize: batchSize,
+		interval:  interval,
+		done:      make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Record queues an event. CreatedAt is set to now if it is zero.
+func (l *Logger) Record(event *models.AuditEvent) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	event.UserAgent = truncate(event.UserAgent, maxUserAgentLength)
+	event.Reason = truncate(event.Reason, maxReasonLength)
+
+	select {
+	case l.events <- event:
+	default:
+		middleware.RecordAuditEventDropped()
+		log.Printf("Audit queue full, dropped %s event (%s) of %q from %s",
+			event.Type, event.Outcome, event.ActorEmail, event.IPAddress)
+	}
+}
+
+// Close writes the pending events and stops the logger. Record must not be
+// called afterwards.
+func (l *Logger) Close() {
+	l.closeOnce.Do(func() {
+		close(l.events)
+		<-l.done
+	})
+}
+
+func (l *Logger) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	batch := make([]*models.AuditEvent, 0, l.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.store.CreateBatch(batch); err != nil {
+			log.Printf("Failed to write %d audit events: %v", len(batch), err)
+		}
+		batch = make([]*models.AuditEvent, 0, l.batchSize)
+	}
+
+	for {
+		select {
+		case event, ok := <-l.events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= l.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	// Do not cut a multi-byte character in half
+	for max > 0 && s[max]&0xC0 == 0x80 {
+		max--
+	}
+	return s[:max]
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore records the batches written to it. block, when set, holds
+// every write until it is closed.
+type memoryStore struct {
+	mu      sync.Mutex
+	batches [][]*models.AuditEvent
+	block   chan struct{}
+	err     error
+}
+
+func (s *memoryStore) CreateBatch(events []*models.AuditEvent) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, events)
+	return s.err
+}
+
+func (s *memoryStore) events() []*models.AuditEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []*models.AuditEvent
+	for _, batch := range s.batches {
+		events = append(events, batch...)
+	}
+	return events
+}
+
+func TestLogger_WritesBatches(t *testing.T) {
+	store := &memoryStore{}
+	logger := NewLogger(store, 100, 2, time.Hour)
+
+	for _, eventType := range []string{models.AuditEventRegister, models.AuditEventLogin, models.AuditEventLogin} {
+		logger.Record(&models.AuditEvent{Type: eventType, Outcome: models.AuditOutcomeSuccess})
+	}
+	logger.Close()
+
+	events := store.events()
+	require.Len(t, events, 3)
+	assert.Equal(t, models.AuditEventRegister, events[0].Type)
+	assert.False(t, events[0].CreatedAt.IsZero())
+	// Two full batches: one of two events, and the remainder flushed on close
+	assert.Len(t, store.batches, 2)
+}
+
+func TestLogger_FlushesOnInterval(t *testing.T) {
+	store := &memoryStore{}
+	logger := NewLogger(store, 100, 100, 10*time.Millisecond)
+	defer logger.Close()
+
+	logger.Record(&models.AuditEvent{Type: models.AuditEventLogin, Outcome: models.AuditOutcomeFailure})
+
+	assert.Eventually(t, func() bool { return len(store.events()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestLogger_DropsWhenQueueIsFull(t *testing.T) {
+	store := &memoryStore{block: make(chan struct{})}
+	logger := NewLogger(store, 1, 1, time.Hour)
+
+	done := make(chan struct{})
+	go func() {
+		// The first event is taken by the writer and blocks in the store,
+		// the second fills the queue and the rest are dropped, without
+		// blocking the caller.
+		for i := 0; i < 5; i++ {
+			logger.Record(&models.AuditEvent{Type: models.AuditEventLogin, Outcome: models.AuditOutcomeSuccess})
+			time.Sleep(5 * time.Millisecond)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Record blocked on a full queue")
+	}
+
+	close(store.block)
+	logger.Close()
+	assert.Len(t, store.events(), 2)
+}
+
+func TestLogger_TruncatesLongFields(t *testing.T) {
+	store := &memoryStore{err: errors.New("write failed")}
+	logger := NewLogger(store, 10, 10, time.Hour)
+
+	logger.Record(&models.AuditEvent{
+		Type:      models.AuditEventLogin,
+		UserAgent: strings.Repeat("a", 254) + "é",
+		Reason:    strings.Repeat("r", 300),
+	})
+	logger.Close()
+
+	events := store.events()
+	require.Len(t, events, 1)
+	assert.Equal(t, strings.Repeat("a", 254), events[0].UserAgent)
+	assert.Len(t, events[0].Reason, maxReasonLength)
+}
@@ -3,80 +3,301 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+)
+
+// Subject types. User tokens are issued by login; client tokens by the OAuth2
+// client_credentials grant and carry no user.
+const (
+	SubjectTypeUser   = "user"
+	SubjectTypeClient = "client"
 )
 
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Email    string `json:"email"`
-	IsActive bool   `json:"is_active"`
+	UserID   uint     `json:"user_id"`
+	Email    string   `json:"email"`
+	IsActive bool     `json:"is_active"`
+	Roles    []string `json:"roles,omitempty"`
+	// Scope is the space separated list of permissions granted to the token
+	Scope       string `json:"scope,omitempty"`
+	SubjectType string `json:"sub_type,omitempty"`
+	ClientID    string `json:"client_id,omitempty"`
+	// SessionID identifies the login session of a user token
+	SessionID string `json:"sid,omitempty"`
+	// OrgID is the active organization of the user and OrgRole their role
+	// in it; both are empty for users without an organization
+	OrgID   uint   `json:"org_id,omitempty"`
+	OrgRole string `json:"org_role,omitempty"`
+	// APIKeyID is set on the claims of a request authenticated with an API
+	// key instead of an access token; such claims are never signed
+	APIKeyID uint `json:"api_key_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IsClient reports whether the token was issued to an OAuth2 client rather
+// than a user.
+func (c *Claims) IsClient() bool {
+	return c.SubjectType == SubjectTypeClient
+}
+
+// IsAPIKey reports whether the caller authenticated with an API key.
+func (c *Claims) IsAPIKey() bool {
+	return c.APIKeyID != 0
+}
+
+// Scopes returns the permissions granted to the token.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether the token grants the given permission.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultIssuer is the iss claim of issued tokens unless configured otherwise.
+const DefaultIssuer = "auth-service"
+
+// AccessTokenType is the typ header of access tokens (RFC 9068). ID tokens
+// and erasure receipts are signed with the same keys but have the default
+// typ JWT, so only tokens with this header are accepted as access tokens.
+const AccessTokenType = "at+jwt"
+
+// IsAccessTokenType reports whether typ, the typ header of a token, marks an
+// access token. The media type may be given in full.
+func IsAccessTokenType(typ string) bool {
+	return strings.TrimPrefix(strings.ToLower(typ), "application/") == AccessTokenType
+}
+
+// IDTokenClaims are the claims of an OpenID Connect ID token. ID tokens
+// identify the user to the client and are never accepted as access tokens.
+type IDTokenClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	AuthTime      int64  `json:"auth_time,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ErasureReceiptAudience is the aud claim of erasure receipts.
+const ErasureReceiptAudience = "erasure-receipt"
+
+// ErasureReceiptClaims are the claims of the signed record of a completed
+// account erasure: jti is the erasure ID and sub the former user ID. Like ID
+// tokens they are never accepted as access tokens.
+type ErasureReceiptClaims struct {
+	// Steps lists what was erased, e.g. "orders" and "account"
+	Steps               []string `json:"steps"`
+	OrdersPseudonymized int64    `json:"orders_pseudonymized"`
+	jwt.RegisteredClaims
+}
+
+// JWTService signs and verifies access tokens. With a KeyManager tokens are
+// signed asymmetrically and carry a kid header; without one the shared HMAC
+// secret is used. Once asymmetric keys are in use, HMAC tokens are refused
+// unless AcceptHMACUntil opened a migration window.
 type JWTService struct {
-	secretKey        []byte
-	expirationTime   time.Duration
+	secretKey             []byte
+	keys                  *KeyManager
+	hmacUntil             time.Time
+	issuer                string
+	expirationTime        time.Duration
+	refreshExpirationTime time.Duration
 }
 
-func NewJWTService(secret string, expirationHours int) *JWTService {
+// NewJWTService creates the token service. An empty issuer means
+// DefaultIssuer.
+func NewJWTService(secret string, keys *KeyManager, issuer string, expirationHours, refreshExpirationHours int) *JWTService {
+	if issuer == "" {
+		issuer = DefaultIssuer
+	}
 	return &JWTService{
-		secretKey:      []byte(secret),
-		expirationTime: time.Duration(expirationHours) * time.Hour,
+		secretKey:             []byte(secret),
+		keys:                  keys,
+		issuer:                issuer,
+		expirationTime:        time.Duration(expirationHours) * time.Hour,
+		refreshExpirationTime: time.Duration(refreshExpirationHours) * time.Hour,
 	}
 }
 
-func (j *JWTService) GenerateToken(userID uint, email string, isActive bool) (string, error) {
-	claims := &Claims{
-		UserID:   userID,
-		Email:    email,
-		IsActive: isActive,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expirationTime)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "auth-service",
-			Subject:   fmt.Sprintf("%d", userID),
-		},
+// AcceptHMACUntil keeps HMAC tokens signed with the shared secret valid until
+// deadline after switching to asymmetric keys, so that sessions issued before
+// the switch survive it. Anyone holding the secret can mint tokens during
+// that window; it should not outlast the refresh token lifetime.
+func (j *JWTService) AcceptHMACUntil(deadline time.Time) {
+	j.hmacUntil = deadline
+}
+
+// Issuer is the iss claim of issued tokens.
+func (j *JWTService) Issuer() string {
+	return j.issuer
+}
+
+// ExpirationTime is the lifetime of access and ID tokens.
+func (j *JWTService) ExpirationTime() time.Duration {
+	return j.expirationTime
+}
+
+// RefreshExpirationTime is the lifetime of refresh tokens issued alongside
+// access tokens.
+func (j *JWTService) RefreshExpirationTime() time.Duration {
+	return j.refreshExpirationTime
+}
+
+// GenerateToken fills in the registered claims (expiry, issuer, subject and
+// a fresh jti) and signs the access token. Callers read the token ID and
+// expiry back from claims.
+func (j *JWTService) GenerateToken(claims *Claims) (string, error) {
+	return j.GenerateTokenWithTTL(claims, j.expirationTime)
+}
+
+// GenerateTokenWithTTL is GenerateToken with a custom lifetime. The subject
+// is the user ID for user tokens and the client ID for client tokens.
+func (j *JWTService) GenerateTokenWithTTL(claims *Claims, ttl time.Duration) (string, error) {
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	subject := fmt.Sprintf("%d", claims.UserID)
+	if claims.IsClient() {
+		subject = claims.ClientID
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		Issuer:    j.issuer,
+		Subject:   subject,
+		ID:        jti,
+	}
+
+	return j.sign(claims, AccessTokenType)
 }
 
-func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.secretKey, nil
-	})
+// GenerateIDToken signs an ID token for the user subject and the client in
+// audience, valid as long as an access token.
+func (j *JWTService) GenerateIDToken(subject, audience string, claims *IDTokenClaims) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expirationTime)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		Issuer:    j.issuer,
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{audience},
+	}
+
+	return j.sign(claims, "")
+}
 
+// GenerateErasureReceipt signs the receipt of the erasure erasureID of a
+// user, completed at erasedAt. Receipts do not expire.
+func (j *JWTService) GenerateErasureReceipt(erasureID string, userID uint, erasedAt time.Time, claims *ErasureReceiptClaims) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		IssuedAt: jwt.NewNumericDate(erasedAt),
+		Issuer:   j.issuer,
+		Subject:  fmt.Sprintf("%d", userID),
+		Audience: jwt.ClaimStrings{ErasureReceiptAudience},
+		ID:       erasureID,
+	}
+
+	return j.sign(claims, "")
+}
+
+// ValidateToken verifies an access token. Other tokens signed by this
+// service, such as ID tokens, are refused by their typ header.
+func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := j.parse(tokenString, claims)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	if typ, _ := token.Header["typ"].(string); !IsAccessTokenType(typ) {
+		return nil, errors.New("not an access token")
 	}
+	return claims, nil
+}
 
-	return nil, errors.New("invalid token")
+// JWKS returns the public keys that verify tokens issued by this service.
+// The set is empty when only HMAC signing is configured.
+func (j *JWTService) JWKS() JWKS {
+	if j.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return j.keys.JWKS()
 }
 
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
+// sign signs claims with the current key. typ replaces the default typ
+// header JWT when set.
+func (j *JWTService) sign(claims jwt.Claims, typ string) (string, error) {
+	var token *jwt.Token
+	var key interface{}
+	if j.keys == nil {
+		token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		key = j.secretKey
+	} else {
+		signingKey, err := j.keys.signingKey()
+		if err != nil {
+			return "", err
+		}
+		token = jwt.NewWithClaims(signingKey.method, claims)
+		token.Header["kid"] = signingKey.kid
+		key = signingKey.private
 	}
-	return string(hashedPassword), nil
+
+	if typ != "" {
+		token.Header["typ"] = typ
+	}
+	return token.SignedString(key)
 }
 
-func CheckPassword(hashedPassword, password string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+func (j *JWTService) parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	token, err := jwt.ParseWithClaims(tokenString, claims, j.keyFunc)
 	if err != nil {
-		return fmt.Errorf("password mismatch: %w", err)
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return token, nil
+}
+
+func (j *JWTService) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(j.secretKey) == 0 || (j.keys != nil && !time.Now().Before(j.hmacUntil)) {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return j.secretKey, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+		if j.keys == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, err := j.keys.verificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if key.method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.public, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 	}
-	return nil
 }
@@ -0,0 +1,369 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	rsaKeyBits = 2048
+
+	// keyReloadInterval bounds how often an unknown kid triggers a reload from
+	// the store, so garbage tokens can not hammer the database.
+	keyReloadInterval = 30 * time.Second
+)
+
+// KeyStore persists signing keys so that every replica signs and verifies
+// with the same key set.
+type KeyStore interface {
+	ListSigningKeys() ([]*models.SigningKey, error)
+	CreateSigningKey(key *models.SigningKey) error
+	RetireSigningKeys(exceptKID string, retiredAt, expiresAt time.Time) error
+	DeleteExpiredSigningKeys(now time.Time) error
+}
+
+type signingKey struct {
+	kid       string
+	algorithm string
+	method    jwt.SigningMethod
+	private   crypto.PrivateKey
+	public    crypto.PublicKey
+	createdAt time.Time
+	retired   bool
+	// plaintext marks a key stored before keys were encrypted at rest
+	plaintext bool
+}
+
+// KeyManager owns the asymmetric keys used to sign access tokens. Keys are
+// rotated on a schedule; retired keys remain valid for verification for a
+// grace period that must cover the access token lifetime. Private keys are
+// encrypted with box before they are stored.
+type KeyManager struct {
+	store            KeyStore
+	box              *SecretBox
+	algorithm        string
+	rotationInterval time.Duration
+	gracePeriod      time.Duration
+
+	mu         sync.RWMutex
+	keys       map[string]*signingKey
+	current    *signingKey
+	lastReload time.Time
+}
+
+func NewKeyManager(store KeyStore, box *SecretBox, algorithm string, rotationInterval, gracePeriod time.Duration) (*KeyManager, error) {
+	if algorithm != AlgorithmRS256 && algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+	if box == nil {
+		return nil, errors.New("signing keys need an encryption key")
+	}
+
+	m := &KeyManager{
+		store:            store,
+		box:              box,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		gracePeriod:      gracePeriod,
+		keys:             make(map[string]*signingKey),
+	}
+
+	if err := m.RotateIfDue(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Algorithm returns the JWS algorithm used for new tokens.
+func (m *KeyManager) Algorithm() string {
+	return m.algorithm
+}
+
+// Load replaces the in-memory key set with the keys from the store.
+func (m *KeyManager) Load() error {
+	stored, err := m.store.ListSigningKeys()
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	now := time.Now()
+	keys := make(map[string]*signingKey, len(stored))
+	var current *signingKey
+	for _, record := range stored {
+		if record.ExpiresAt != nil && record.ExpiresAt.Before(now) {
+			continue
+		}
+
+		key, err := m.parseSigningKey(record)
+		if err != nil {
+			log.Printf("Skipping signing key %s: %v", record.KID, err)
+			continue
+		}
+		keys[key.kid] = key
+
+		if !key.retired && key.algorithm == m.algorithm &&
+			(current == nil || key.createdAt.After(current.createdAt)) {
+			current = key
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.current = current
+	m.lastReload = now
+	m.mu.Unlock()
+	return nil
+}
+
+// Rotate generates a new signing key, retires the previous ones and reloads
+// the key set.
+func (m *KeyManager) Rotate() error {
+	record, err := m.generateSigningKey()
+	if err != nil {
+		return err
+	}
+
+	if err := m.store.CreateSigningKey(record); err != nil {
+		return fmt.Errorf("failed to store signing key: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if err := m.store.RetireSigningKeys(record.KID, now, now.Add(m.gracePeriod)); err != nil {
+		return fmt.Errorf("failed to retire signing keys: %w", err)
+	}
+
+	log.Printf("Rotated JWT signing key, new kid %s", record.KID)
+	return m.Load()
+}
+
+// RotateIfDue reloads the key set, rotates when the current key is older than
+// the rotation interval or was stored unencrypted, and prunes keys past their
+// grace period.
+func (m *KeyManager) RotateIfDue() error {
+	if err := m.store.DeleteExpiredSigningKeys(time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to delete expired signing keys: %w", err)
+	}
+
+	if err := m.Load(); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	current := m.current
+	m.mu.RUnlock()
+
+	if current == nil || current.plaintext || time.Since(current.createdAt) >= m.rotationInterval {
+		return m.Rotate()
+	}
+	return nil
+}
+
+// Run checks for due rotations every checkInterval. It also picks up keys
+// rotated by other replicas. Run blocks and is meant to be started in its own
+// goroutine.
+func (m *KeyManager) Run(checkInterval time.Duration) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := m.RotateIfDue(); err != nil {
+			log.Printf("Failed to rotate signing keys: %v", err)
+		}
+	}
+}
+
+func (m *KeyManager) signingKey() (*signingKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.current == nil {
+		return nil, errors.New("no active signing key")
+	}
+	return m.current, nil
+}
+
+func (m *KeyManager) verificationKey(kid string) (*signingKey, error) {
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	stale := time.Since(m.lastReload) > keyReloadInterval
+	m.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	// The key may have been created by another replica since the last load.
+	if stale {
+		if err := m.Load(); err != nil {
+			return nil, err
+		}
+		m.mu.RLock()
+		key, ok = m.keys[kid]
+		m.mu.RUnlock()
+		if ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signing key: %s", kid)
+}
+
+// JWK is a single public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public halves of every key that is still valid for
+// verification.
+func (m *KeyManager) JWKS() JWKS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(m.keys))}
+	for _, key := range m.keys {
+		jwk := JWK{
+			KeyID:     key.kid,
+			Use:       "sig",
+			Algorithm: key.algorithm,
+		}
+
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func (m *KeyManager) generateSigningKey() (*models.SigningKey, error) {
+	var private crypto.PrivateKey
+	switch m.algorithm {
+	case AlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		private = key
+	case AlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", m.algorithm)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	sealed, err := m.box.Seal(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+
+	kid, err := GenerateRandomToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SigningKey{
+		KID:        kid,
+		Algorithm:  m.algorithm,
+		PrivateKey: sealed,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+func (m *KeyManager) parseSigningKey(record *models.SigningKey) (*signingKey, error) {
+	data := record.PrivateKey
+	plaintext := strings.HasPrefix(data, "-----BEGIN")
+	if !plaintext {
+		var err error
+		if data, err = m.box.Open(data); err != nil {
+			return nil, err
+		}
+	}
+
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	key := &signingKey{
+		kid:       record.KID,
+		algorithm: record.Algorithm,
+		private:   private,
+		createdAt: record.CreatedAt,
+		retired:   record.RetiredAt != nil,
+		plaintext: plaintext,
+	}
+
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		if record.Algorithm != AlgorithmRS256 {
+			return nil, fmt.Errorf("RSA key stored with algorithm %s", record.Algorithm)
+		}
+		key.method = jwt.SigningMethodRS256
+		key.public = &k.PublicKey
+	case ed25519.PrivateKey:
+		if record.Algorithm != AlgorithmEdDSA {
+			return nil, fmt.Errorf("Ed25519 key stored with algorithm %s", record.Algorithm)
+		}
+		key.method = jwt.SigningMethodEdDSA
+		key.public = k.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+
+	return key, nil
+}
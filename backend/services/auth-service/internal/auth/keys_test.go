@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKeyStore is an in-memory KeyStore for tests
+type memoryKeyStore struct {
+	mu   sync.Mutex
+	keys []*models.SigningKey
+}
+
+func (s *memoryKeyStore) ListSigningKeys() ([]*models.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]*models.SigningKey, len(s.keys))
+	copy(keys, s.keys)
+	return keys, nil
+}
+
+func (s *memoryKeyStore) CreateSigningKey(key *models.SigningKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func (s *memoryKeyStore) RetireSigningKeys(exceptKID string, retiredAt, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.keys {
+		if key.KID != exceptKID && key.RetiredAt == nil {
+			key.RetiredAt = &retiredAt
+			key.ExpiresAt = &expiresAt
+		}
+	}
+	return nil
+}
+
+func (s *memoryKeyStore) DeleteExpiredSigningKeys(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.keys[:0]
+	for _, key := range s.keys {
+		if key.ExpiresAt == nil || !key.ExpiresAt.Before(now) {
+			kept = append(kept, key)
+		}
+	}
+	s.keys = kept
+	return nil
+}
+
+func testKeyBox(t *testing.T) *SecretBox {
+	box, err := NewSecretBox("test-key-encryption-key")
+	require.NoError(t, err)
+	return box
+}
+
+func TestKeyManager_EncryptsStoredKeys(t *testing.T) {
+	store := &memoryKeyStore{}
+	keys, err := NewKeyManager(store, testKeyBox(t), AlgorithmEdDSA, time.Hour, time.Hour)
+	require.NoError(t, err)
+
+	require.Len(t, store.keys, 1)
+	assert.NotContains(t, store.keys[0].PrivateKey, "PRIVATE KEY")
+
+	// Another replica with the same encryption key can use the stored key
+	other, err := NewKeyManager(store, testKeyBox(t), AlgorithmEdDSA, time.Hour, time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, store.keys, 1)
+	token, err := NewJWTService("", keys, "", 1, 1).GenerateToken(&Claims{UserID: 1, IsActive: true})
+	require.NoError(t, err)
+	_, err = NewJWTService("", other, "", 1, 1).ValidateToken(token)
+	assert.NoError(t, err)
+
+	// but not one with another encryption key
+	wrongBox, err := NewSecretBox("another-key")
+	require.NoError(t, err)
+	stored := *store.keys[0]
+	wrong, err := NewKeyManager(&memoryKeyStore{keys: []*models.SigningKey{&stored}}, wrongBox, AlgorithmEdDSA, time.Hour, time.Hour)
+	require.NoError(t, err)
+	_, err = NewJWTService("", wrong, "", 1, 1).ValidateToken(token)
+	assert.Error(t, err)
+}
+
+func TestKeyManager_RotatesPlaintextKeys(t *testing.T) {
+	store := &memoryKeyStore{}
+	der, err := x509.MarshalPKCS8PrivateKey(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	require.NoError(t, err)
+	require.NoError(t, store.CreateSigningKey(&models.SigningKey{
+		KID:        "legacy",
+		Algorithm:  AlgorithmEdDSA,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedAt:  time.Now().UTC(),
+	}))
+
+	keys, err := NewKeyManager(store, testKeyBox(t), AlgorithmEdDSA, time.Hour, time.Hour)
+	require.NoError(t, err)
+
+	current, err := keys.signingKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, "legacy", current.kid)
+	// The legacy key still verifies tokens until its grace period ends
+	_, err = keys.verificationKey("legacy")
+	assert.NoError(t, err)
+}
+
+func TestJWTService_AsymmetricSigning(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			keys, err := NewKeyManager(&memoryKeyStore{}, testKeyBox(t), algorithm, time.Hour, time.Hour)
+			require.NoError(t, err)
+			jwtSvc := NewJWTService("", keys, "", 1, 1)
+
+			token, err := jwtSvc.GenerateToken(&Claims{UserID: 1, Email: "test@example.com", IsActive: true})
+			require.NoError(t, err)
+
+			claims, err := jwtSvc.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, uint(1), claims.UserID)
+
+			jwks := jwtSvc.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, algorithm, jwks.Keys[0].Algorithm)
+		})
+	}
+}
+
+func TestKeyManager_Rotate(t *testing.T) {
+	keys, err := NewKeyManager(&memoryKeyStore{}, testKeyBox(t), AlgorithmRS256, time.Hour, time.Hour)
+	require.NoError(t, err)
+	jwtSvc := NewJWTService("", keys, "", 1, 1)
+
+	oldToken, err := jwtSvc.GenerateToken(&Claims{UserID: 1, Email: "test@example.com", IsActive: true})
+	require.NoError(t, err)
+
+	require.NoError(t, keys.Rotate())
+
+	newToken, err := jwtSvc.GenerateToken(&Claims{UserID: 1, Email: "test@example.com", IsActive: true})
+	require.NoError(t, err)
+
+	_, err = jwtSvc.ValidateToken(oldToken)
+	assert.NoError(t, err, "tokens signed with a retired key stay valid during the grace period")
+	_, err = jwtSvc.ValidateToken(newToken)
+	assert.NoError(t, err)
+	assert.Len(t, jwtSvc.JWKS().Keys, 2)
+}
+
+func TestJWTService_RejectsHMACWithAsymmetricKeys(t *testing.T) {
+	hmacToken, err := NewJWTService("secret", nil, "", 1, 1).GenerateToken(&Claims{UserID: 1, Email: "test@example.com", IsActive: true})
+	require.NoError(t, err)
+
+	keys, err := NewKeyManager(&memoryKeyStore{}, testKeyBox(t), AlgorithmEdDSA, time.Hour, time.Hour)
+	require.NoError(t, err)
+
+	_, err = NewJWTService("", keys, "", 1, 1).ValidateToken(hmacToken)
+	assert.Error(t, err)
+
+	// A configured secret alone does not make HMAC tokens acceptable
+	jwtSvc := NewJWTService("secret", keys, "", 1, 1)
+	_, err = jwtSvc.ValidateToken(hmacToken)
+	assert.Error(t, err)
+
+	jwtSvc.AcceptHMACUntil(time.Now().Add(time.Hour))
+	_, err = jwtSvc.ValidateToken(hmacToken)
+	assert.NoError(t, err)
+
+	jwtSvc.AcceptHMACUntil(time.Now().Add(-time.Minute))
+	_, err = jwtSvc.ValidateToken(hmacToken)
+	assert.Error(t, err)
+}
+
+func TestJWTService_ClientToken(t *testing.T) {
+	jwtSvc := NewJWTService("secret", nil, "", 1, 1)
+
+	token, err := jwtSvc.GenerateTokenWithTTL(&Claims{
+		IsActive:    true,
+		Scope:       "orders:read:any",
+		SubjectType: SubjectTypeClient,
+		ClientID:    "reporting",
+	}, 5*time.Minute)
+	require.NoError(t, err)
+
+	claims, err := jwtSvc.ValidateToken(token)
+	require.NoError(t, err)
+	assert.True(t, claims.IsClient())
+	assert.Equal(t, "reporting", claims.Subject)
+	assert.Equal(t, DefaultIssuer, claims.Issuer)
+	assert.Equal(t, uint(0), claims.UserID)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+}
+
+func TestJWTService_ErasureReceipt(t *testing.T) {
+	keys, err := NewKeyManager(&memoryKeyStore{}, testKeyBox(t), AlgorithmEdDSA, time.Hour, time.Hour)
+	require.NoError(t, err)
+	jwtSvc := NewJWTService("", keys, "", 1, 1)
+
+	erasedAt := time.Now()
+	receipt, err := jwtSvc.GenerateErasureReceipt("era_1", 7, erasedAt, &ErasureReceiptClaims{
+		Steps:               []string{"orders", "account"},
+		OrdersPseudonymized: 2,
+	})
+	require.NoError(t, err)
+
+	claims := &ErasureReceiptClaims{}
+	_, err = jwtSvc.parse(receipt, claims)
+	require.NoError(t, err)
+	assert.Equal(t, "era_1", claims.ID)
+	assert.Equal(t, "7", claims.Subject)
+	assert.Equal(t, []string{ErasureReceiptAudience}, []string(claims.Audience))
+	assert.Nil(t, claims.ExpiresAt)
+
+	// A receipt is not an access token of the user
+	_, err = jwtSvc.ValidateToken(receipt)
+	assert.Error(t, err)
+}
+
+func TestJWTService_AccessTokenType(t *testing.T) {
+	keys, err := NewKeyManager(&memoryKeyStore{}, testKeyBox(t), AlgorithmEdDSA, time.Hour, time.Hour)
+	require.NoError(t, err)
+	jwtSvc := NewJWTService("", keys, "", 1, 1)
+
+	token, err := jwtSvc.GenerateToken(&Claims{UserID: 1, Email: "test@example.com", IsActive: true})
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, AccessTokenType, parsed.Header["typ"])
+
+	// An ID token is signed with the same key but is not an access token,
+	// even if its claims looked like one
+	idToken, err := jwtSvc.GenerateIDToken("1", "web", &IDTokenClaims{Email: "test@example.com"})
+	require.NoError(t, err)
+	_, err = jwtSvc.ValidateToken(idToken)
+	assert.Error(t, err)
+
+	assert.True(t, IsAccessTokenType("application/AT+JWT"))
+	assert.False(t, IsAccessTokenType("JWT"))
+	assert.False(t, IsAccessTokenType(""))
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms
+const (
+	HashAlgorithmArgon2id = "argon2id"
+	HashAlgorithmBcrypt   = "bcrypt"
+)
+
+// ErrPasswordMismatch is returned when a password does not match its hash.
+var ErrPasswordMismatch = errors.New("password mismatch")
+
+// Argon2Params are the argon2id cost parameters. They are stored with every
+// hash, so they can be raised without invalidating existing passwords.
+type Argon2Params struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow the OWASP recommendation for argon2id.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHasher hashes new passwords with the configured algorithm and
+// verifies hashes of any supported algorithm. Hashes are self-describing:
+// argon2id hashes use the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>) and bcrypt hashes the usual
+// $2a$ format.
+type PasswordHasher struct {
+	algorithm  string
+	argon2     Argon2Params
+	bcryptCost int
+	// dummyHash is a hash of a random password with the current parameters,
+	// checked by CheckDummy
+	dummyHash string
+}
+
+func NewPasswordHasher(algorithm string, argon2Params Argon2Params, bcryptCost int) (*PasswordHasher, error) {
+	switch algorithm {
+	case HashAlgorithmArgon2id, "":
+		if argon2Params.Memory == 0 || argon2Params.Iterations == 0 || argon2Params.Parallelism == 0 ||
+			argon2Params.SaltLength < 8 || argon2Params.KeyLength < 16 {
+			return nil, errors.New("invalid argon2id parameters")
+		}
+		algorithm = HashAlgorithmArgon2id
+	case HashAlgorithmBcrypt:
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hashing algorithm: %s", algorithm)
+	}
+
+	h := &PasswordHasher{algorithm: algorithm, argon2: argon2Params, bcryptCost: bcryptCost}
+
+	dummyPassword, err := GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	if h.dummyHash, err = h.Hash(dummyPassword); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Hash hashes a password with a new random salt.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h.algorithm == HashAlgorithmBcrypt {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		return string(hashedPassword), nil
+	}
+
+	salt := make([]byte, h.argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.argon2.Iterations, h.argon2.Memory, h.argon2.Parallelism, h.argon2.KeyLength)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", HashAlgorithmArgon2id, argon2.Version,
+		h.argon2.Memory, h.argon2.Iterations, h.argon2.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// NeedsRehash reports whether a hash uses another algorithm or other
+// parameters than new hashes would. Such hashes should be replaced the next
+// time the plaintext password is known, i.e. on login.
+func (h *PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	if h.algorithm == HashAlgorithmBcrypt {
+		cost, err := bcrypt.Cost([]byte(hashedPassword))
+		return err != nil || cost != h.bcryptCost
+	}
+
+	hash, err := parseArgon2Hash(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return hash.version != argon2.Version || hash.params.Memory != h.argon2.Memory ||
+		hash.params.Iterations != h.argon2.Iterations || hash.params.Parallelism != h.argon2.Parallelism ||
+		uint32(len(hash.salt)) != h.argon2.SaltLength || uint32(len(hash.key)) != h.argon2.KeyLength
+}
+
+// CheckDummy checks password against a hash nobody knows the password of,
+// taking as long as checking it against a new hash. A login for an unknown
+// email calls it, so that the response time does not tell whether the email
+// is registered.
+func (h *PasswordHasher) CheckDummy(password string) {
+	_ = CheckPassword(h.dummyHash, password)
+}
+
+// CheckPassword compares a password with an argon2id or bcrypt hash.
+func CheckPassword(hashedPassword, password string) error {
+	if !strings.HasPrefix(hashedPassword, "$"+HashAlgorithmArgon2id+"$") {
+		if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
+			return fmt.Errorf("%w: %w", ErrPasswordMismatch, err)
+		}
+		return nil
+	}
+
+	hash, err := parseArgon2Hash(hashedPassword)
+	if err != nil {
+		return err
+	}
+	key := argon2.IDKey([]byte(password), hash.salt, hash.params.Iterations, hash.params.Memory,
+		hash.params.Parallelism, uint32(len(hash.key)))
+	if subtle.ConstantTimeCompare(key, hash.key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+type argon2Hash struct {
+	version int
+	params  Argon2Params
+	salt    []byte
+	key     []byte
+}
+
+func parseArgon2Hash(hashedPassword string) (*argon2Hash, error) {
+	invalid := errors.New("invalid argon2id hash")
+
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != HashAlgorithmArgon2id {
+		return nil, invalid
+	}
+
+	var hash argon2Hash
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &hash.version); err != nil {
+		return nil, invalid
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hash.params.Memory, &hash.params.Iterations, &hash.params.Parallelism); err != nil {
+		return nil, invalid
+	}
+	if hash.params.Iterations == 0 || hash.params.Parallelism == 0 {
+		return nil, invalid
+	}
+
+	var err error
+	if hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, invalid
+	}
+	if hash.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(hash.key) == 0 {
+		return nil, invalid
+	}
+	hash.params.SaltLength = uint32(len(hash.salt))
+	hash.params.KeyLength = uint32(len(hash.key))
+
+	return &hash, nil
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy rules, reported in PasswordViolation.Rule
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBreached     = "breached"
+)
+
+// minPersonalInfoLength is the shortest name or email part that is looked
+// for in a password; shorter parts match too many passwords by chance.
+const minPersonalInfoLength = 3
+
+// PasswordPolicy configures the rules new passwords must satisfy.
+type PasswordPolicy struct {
+	// MinLength and MaxLength count characters, not bytes
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// DisallowPersonalInfo rejects passwords containing the user's email
+	// address, the local part of it, or their first or last name
+	DisallowPersonalInfo bool
+	// Breached rejects known breached passwords; nil disables the check
+	Breached *BreachedPasswords
+}
+
+// PasswordViolation is a rule a password does not satisfy.
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password does not satisfy.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return "password does not meet the policy: " + strings.Join(messages, "; ")
+}
+
+// Check returns a *PasswordPolicyError if the password breaks any rule.
+// personalInfo are the email address and names of the user the password is
+// for.
+func (p *PasswordPolicy) Check(password string, personalInfo ...string) error {
+	var violations []PasswordViolation
+	violate := func(rule, format string, args ...interface{}) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violate(PasswordRuleMinLength, "must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violate(PasswordRuleMaxLength, "must be at most %d characters long", p.MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violate(PasswordRuleUppercase, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		violate(PasswordRuleLowercase, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violate(PasswordRuleDigit, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violate(PasswordRuleSymbol, "must contain a symbol")
+	}
+
+	if p.DisallowPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		violate(PasswordRulePersonalInfo, "must not contain your name or email address")
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violate(PasswordRuleBreached, "has appeared in a data breach and can not be used")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	password = strings.ToLower(password)
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		parts := []string{info}
+		if at := strings.LastIndex(info, "@"); at > 0 {
+			parts = append(parts, info[:at])
+		}
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) >= minPersonalInfoLength && strings.Contains(password, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BreachedPasswords is a set of SHA-1 password hashes, indexed by the first
+// five hex characters like the k-anonymity range API of Have I Been Pwned.
+type BreachedPasswords struct {
+	// ranges maps a hash prefix to the sorted suffixes sharing it
+	ranges map[string][]string
+	count  int
+}
+
+// LoadBreachedPasswords reads a file with one uppercase or lowercase SHA-1
+// hex digest per line, optionally followed by ":<count>" as in the Have I Been
+// Pwned downloads. Blank lines and lines starting with # are skipped.
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	breached := &BreachedPasswords{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if i := strings.IndexByte(text, ':'); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.ToUpper(text)
+		if _, err := hex.DecodeString(text); err != nil || len(text) != sha1.Size*2 {
+			return nil, fmt.Errorf("%s:%d: not a SHA-1 hex digest", path, line)
+		}
+		breached.ranges[text[:5]] = append(breached.ranges[text[:5]], text[5:])
+		breached.count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, suffixes := range breached.ranges {
+		sort.Strings(suffixes)
+	}
+	return breached, nil
+}
+
+// Len returns the number of hashes in the list.
+func (b *BreachedPasswords) Len() int {
+	return b.count
+}
+
+// Contains reports whether the password is in the list.
+func (b *BreachedPasswords) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes := b.ranges[digest[:5]]
+	i := sort.SearchStrings(suffixes, digest[5:])
+	return i < len(suffixes) && suffixes[i] == digest[5:]
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:            8,
+		MaxLength:            72,
+		RequireUpper:         true,
+		RequireLower:         true,
+		RequireDigit:         true,
+		DisallowPersonalInfo: true,
+	}
+}
+
+func violatedRules(t *testing.T, err error) []string {
+	var policyErr *PasswordPolicyError
+	require.True(t, errors.As(err, &policyErr))
+	var rules []string
+	for _, violation := range policyErr.Violations {
+		rules = append(rules, violation.Rule)
+	}
+	return rules
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := testPasswordPolicy()
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, policy.Check("Correct-Horse-9", "john.doe@example.com", "John", "Doe"))
+	})
+
+	t.Run("reports every broken rule", func(t *testing.T) {
+		err := policy.Check("abc", "john.doe@example.com")
+		assert.Equal(t, []string{PasswordRuleMinLength, PasswordRuleUppercase, PasswordRuleDigit}, violatedRules(t, err))
+		assert.Contains(t, err.Error(), "at least 8 characters")
+	})
+
+	t.Run("length counts characters", func(t *testing.T) {
+		assert.NoError(t, policy.Check("Pässwörd1"))
+		err := (&PasswordPolicy{MaxLength: 4}).Check("ääääa")
+		assert.Equal(t, []string{PasswordRuleMaxLength}, violatedRules(t, err))
+	})
+
+	t.Run("symbol", func(t *testing.T) {
+		policy := &PasswordPolicy{RequireSymbol: true}
+		assert.Equal(t, []string{PasswordRuleSymbol}, violatedRules(t, policy.Check("Password1")))
+		assert.NoError(t, policy.Check("Password 1"))
+		assert.NoError(t, policy.Check("Password$1"))
+	})
+
+	t.Run("personal info", func(t *testing.T) {
+		for _, password := range []string{"John.Doe@Example.com1A", "xJOHN.DOE99a", "Johnathan99", "Doe-Family-1"} {
+			err := policy.Check(password, "john.doe@example.com", "John", "Doe")
+			assert.Equal(t, []string{PasswordRulePersonalInfo}, violatedRules(t, err), password)
+		}
+		// Parts shorter than three characters are not looked for
+		assert.NoError(t, policy.Check("Jo-Li-Password1", "jo@example.com", "Jo", "Li"))
+		assert.NoError(t, (&PasswordPolicy{}).Check("JohnDoe", "john.doe@example.com", "John", "Doe"))
+	})
+}
+
+func writeBreachedList(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestBreachedPasswords(t *testing.T) {
+	t.Run("matches listed hashes", func(t *testing.T) {
+		// SHA-1 of "password" and "Password1"
+		path := writeBreachedList(t, "# breached\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n\n70ccd9007338d6d81dd3b6271621b9cf9a97ea00\n")
+
+		breached, err := LoadBreachedPasswords(path)
+		require.NoError(t, err)
+		assert.Equal(t, 2, breached.Len())
+		assert.True(t, breached.Contains("password"))
+		assert.True(t, breached.Contains("Password1"))
+		assert.False(t, breached.Contains("Correct-Horse-9"))
+
+		policy := testPasswordPolicy()
+		policy.Breached = breached
+		assert.Equal(t, []string{PasswordRuleBreached}, violatedRules(t, policy.Check("Password1")))
+	})
+
+	t.Run("rejects malformed lines", func(t *testing.T) {
+		path := writeBreachedList(t, "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8\npassword\n")
+
+		_, err := LoadBreachedPasswords(path)
+		assert.ErrorContains(t, err, ":2:")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadBreachedPasswords(filepath.Join(t.TempDir(), "missing.txt"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params keep the tests fast; they are far too weak for production.
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHasher_Argon2id(t *testing.T) {
+	hasher, err := NewPasswordHasher(HashAlgorithmArgon2id, testArgon2Params, 0)
+	require.NoError(t, err)
+
+	hash, err := hasher.Hash("Sunny-Harbor-42")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	assert.NoError(t, CheckPassword(hash, "Sunny-Harbor-42"))
+	assert.ErrorIs(t, CheckPassword(hash, "sunny-harbor-42"), ErrPasswordMismatch)
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	other, err := hasher.Hash("Sunny-Harbor-42")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "every hash gets its own salt")
+}
+
+func TestPasswordHasher_NeedsRehash(t *testing.T) {
+	hasher, err := NewPasswordHasher(HashAlgorithmArgon2id, testArgon2Params, 0)
+	require.NoError(t, err)
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	assert.True(t, hasher.NeedsRehash(string(bcryptHash)))
+
+	weaker := testArgon2Params
+	weaker.Iterations = 1
+	weaker.Memory = 512
+	weakHasher, err := NewPasswordHasher(HashAlgorithmArgon2id, weaker, 0)
+	require.NoError(t, err)
+	weakHash, err := weakHasher.Hash("password")
+	require.NoError(t, err)
+	assert.True(t, hasher.NeedsRehash(weakHash))
+	// Old hashes still verify after the parameters are raised
+	assert.NoError(t, CheckPassword(weakHash, "password"))
+
+	bcryptHasher, err := NewPasswordHasher(HashAlgorithmBcrypt, testArgon2Params, bcrypt.MinCost+1)
+	require.NoError(t, err)
+	assert.True(t, bcryptHasher.NeedsRehash(string(bcryptHash)))
+	assert.True(t, bcryptHasher.NeedsRehash(weakHash))
+	upgraded, err := bcryptHasher.Hash("password")
+	require.NoError(t, err)
+	assert.False(t, bcryptHasher.NeedsRehash(upgraded))
+}
+
+func TestPasswordHasher_CheckDummy(t *testing.T) {
+	hasher, err := NewPasswordHasher(HashAlgorithmArgon2id, testArgon2Params, 0)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hasher.dummyHash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.False(t, hasher.NeedsRehash(hasher.dummyHash), "the dummy hash costs as much as a real one")
+	hasher.CheckDummy("Sunny-Harbor-42")
+}
+
+func TestCheckPassword_Bcrypt(t *testing.T) {
+	// Hash of "password" from the seed data in 001_init.sql
+	seeded := "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi"
+
+	assert.NoError(t, CheckPassword(seeded, "password"))
+	assert.ErrorIs(t, CheckPassword(seeded, "Password"), ErrPasswordMismatch)
+}
+
+func TestCheckPassword_InvalidHash(t *testing.T) {
+	for _, hash := range []string{
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$garbage$c2FsdHNhbHQ$a2V5",
+	} {
+		err := CheckPassword(hash, "password")
+		assert.Error(t, err, hash)
+		assert.NotErrorIs(t, err, ErrPasswordMismatch, hash)
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	_, err := NewPasswordHasher("md5", testArgon2Params, 0)
+	assert.Error(t, err)
+
+	_, err = NewPasswordHasher(HashAlgorithmArgon2id, Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}, 0)
+	assert.Error(t, err)
+
+	_, err = NewPasswordHasher(HashAlgorithmBcrypt, testArgon2Params, 2)
+	assert.Error(t, err)
+}
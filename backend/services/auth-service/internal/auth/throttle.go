@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/models"
+)
+
+const (
+	ThrottleStoreMemory   = "memory"
+	ThrottleStoreDatabase = "database"
+
+	ThrottleScopeEmail = "email"
+	ThrottleScopeIP    = "ip"
+)
+
+// AttemptStore persists failed login counters. Update must apply fn
+// atomically, so concurrent failures across replicas are all counted.
+type AttemptStore interface {
+	Get(key string) (*models.LoginAttempt, error)
+	Update(key string, fn func(attempt *models.LoginAttempt)) (*models.LoginAttempt, error)
+	Delete(key string) error
+	DeleteStale(before time.Time) error
+}
+
+// ThrottlePolicy configures when and for how long logins are locked.
+type ThrottlePolicy struct {
+	// MaxAttempts is the number of consecutive failures per email before it
+	// is locked; IPMaxAttempts the same per client IP.
+	MaxAttempts   int
+	IPMaxAttempts int
+	// Window is how long a failure is remembered. A failure after a quiet
+	// window starts counting from one again.
+	Window time.Duration
+	// LockoutDuration is the first lockout. Every further failure while over
+	// the threshold doubles it, up to MaxLockoutDuration.
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+}
+
+// ThrottledError is returned while a login is locked out.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginThrottle counts failed logins per email and per client IP and locks a
+// key out with exponential backoff once it crosses its threshold.
+type LoginThrottle struct {
+	store  AttemptStore
+	policy ThrottlePolicy
+}
+
+func NewLoginThrottle(store AttemptStore, policy ThrottlePolicy) *LoginThrottle {
+	return &LoginThrottle{store: store, policy: policy}
+}
+
+// Check returns a *ThrottledError if the email or the IP is locked.
+func (t *LoginThrottle) Check(email, ip string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, key := range t.keys(email, ip) {
+		attempt, err := t.store.Get(key)
+		if err != nil {
+			return err
+		}
+		if attempt != nil && attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			if wait := attempt.LockedUntil.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return &ThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login and returns the scopes ("email", "ip")
+// that were locked by it.
+func (t *LoginThrottle) RecordFailure(email, ip string) ([]string, error) {
+	var locked []string
+	for scope, key := range t.scopedKeys(email, ip) {
+		threshold := t.policy.MaxAttempts
+		if scope == ThrottleScopeIP {
+			threshold = t.policy.IPMaxAttempts
+		}
+		if threshold <= 0 {
+			continue
+		}
+
+		now := time.Now().UTC()
+		attempt, err := t.store.Update(key, func(a *models.LoginAttempt) {
+			if now.Sub(a.LastFailureAt) > t.policy.Window {
+				a.Failures = 0
+			}
+			a.Failures++
+			a.LastFailureAt = now
+
+			if a.Failures >= threshold {
+				lockedUntil := now.Add(t.lockout(a.Failures - threshold))
+				a.LockedUntil = &lockedUntil
+			}
+		})
+		if err != nil {
+			return locked, err
+		}
+
+		if attempt.Failures >= threshold {
+			locked = append(locked, scope)
+		}
+	}
+	return locked, nil
+}
+
+// RecordSuccess clears the failures of the email. The IP counter is kept, so
+// a valid account can not be used to reset it while guessing others.
+func (t *LoginThrottle) RecordSuccess(email string) error {
+	return t.store.Delete(emailKey(email))
+}
+
+// Prune drops counters that are neither locked nor within the window.
+func (t *LoginThrottle) Prune() error {
+	return t.store.DeleteStale(time.Now().UTC().Add(-t.policy.Window - t.policy.MaxLockoutDuration))
+}
+
+func (t *LoginThrottle) lockout(over int) time.Duration {
+	d := t.policy.LockoutDuration
+	for i := 0; i < over && d < t.policy.MaxLockoutDuration; i++ {
+		d *= 2
+	}
+	if d > t.policy.MaxLockoutDuration {
+		d = t.policy.MaxLockoutDuration
+	}
+	return d
+}
+
+func (t *LoginThrottle) keys(email, ip string) []string {
+	keys := []string{emailKey(email)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	return keys
+}
+
+func (t *LoginThrottle) scopedKeys(email, ip string) map[string]string {
+	keys := map[string]string{ThrottleScopeEmail: emailKey(email)}
+	if ip != "" {
+		keys[ThrottleScopeIP] = ipKey(ip)
+	}
+	return keys
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// MemoryAttemptStore keeps counters in process. It is only suitable for a
+// single instance; replicas need a shared store.
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: make(map[string]models.LoginAttempt)}
+}
+
+func (s *MemoryAttemptStore) Get(key string) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (s *MemoryAttemptStore) Update(key string, fn func(attempt *models.LoginAttempt)) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := s.attempts[key]
+	attempt.Key = key
+	fn(&attempt)
+	attempt.UpdatedAt = time.Now().UTC()
+	s.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (s *MemoryAttemptStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *MemoryAttemptStore) DeleteStale(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, attempt := range s.attempts {
+		if attempt.UpdatedAt.Before(before) {
+			delete(s.attempts, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testThrottle() *LoginThrottle {
+	return NewLoginThrottle(NewMemoryAttemptStore(), ThrottlePolicy{
+		MaxAttempts:        3,
+		IPMaxAttempts:      5,
+		Window:             15 * time.Minute,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: 5 * time.Minute,
+	})
+}
+
+func TestLoginThrottle_LocksEmailAfterThreshold(t *testing.T) {
+	throttle := testThrottle()
+
+	for i := 0; i < 2; i++ {
+		locked, err := throttle.RecordFailure("John.Doe@example.com", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Empty(t, locked)
+	}
+	assert.NoError(t, throttle.Check("john.doe@example.com", "10.0.0.2"))
+
+	locked, err := throttle.RecordFailure("john.doe@example.com", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{ThrottleScopeEmail}, locked)
+
+	err = throttle.Check("john.doe@example.com", "10.0.0.2")
+	var throttled *ThrottledError
+	require.True(t, errors.As(err, &throttled))
+	assert.InDelta(t, time.Minute.Seconds(), throttled.RetryAfter.Seconds(), 1)
+
+	assert.NoError(t, throttle.Check("jane.smith@example.com", "10.0.0.2"))
+}
+
+func TestLoginThrottle_LocksIPAcrossEmails(t *testing.T) {
+	throttle := testThrottle()
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"} {
+		_, err := throttle.RecordFailure(email, "10.0.0.1")
+		require.NoError(t, err)
+	}
+
+	assert.Error(t, throttle.Check("f@example.com", "10.0.0.1"))
+	assert.NoError(t, throttle.Check("f@example.com", "10.0.0.2"))
+}
+
+func TestLoginThrottle_ExponentialBackoff(t *testing.T) {
+	throttle := testThrottle()
+
+	assert.Equal(t, time.Minute, throttle.lockout(0))
+	assert.Equal(t, 2*time.Minute, throttle.lockout(1))
+	assert.Equal(t, 4*time.Minute, throttle.lockout(2))
+	assert.Equal(t, 5*time.Minute, throttle.lockout(3))
+	assert.Equal(t, 5*time.Minute, throttle.lockout(50))
+}
+
+func TestLoginThrottle_SuccessResetsEmail(t *testing.T) {
+	throttle := testThrottle()
+
+	for i := 0; i < 3; i++ {
+		_, err := throttle.RecordFailure("john.doe@example.com", "")
+		require.NoError(t, err)
+	}
+	require.Error(t, throttle.Check("john.doe@example.com", ""))
+
+	require.NoError(t, throttle.RecordSuccess("john.doe@example.com"))
+	assert.NoError(t, throttle.Check("john.doe@example.com", ""))
+}
@@ -11,12 +11,32 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Database DatabaseConfig `mapstructure:"database"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
+	RBAC     RBACConfig     `mapstructure:"rbac"`
+	Account  AccountConfig  `mapstructure:"account"`
+	Mail     MailConfig     `mapstructure:"mail"`
+	MFA      MFAConfig      `mapstructure:"mfa"`
+	// PasswordPolicy applies to register, password reset and password change
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"password_policy"`
+	// PasswordHashing configures how new and upgraded passwords are hashed
+	PasswordHashing PasswordHashingConfig `mapstructure:"password_hashing"`
+	// LoginProtection throttles repeated failed logins
+	LoginProtection LoginProtectionConfig `mapstructure:"login_protection"`
+	OAuth           OAuthConfig           `mapstructure:"oauth"`
+	// Federation configures sign-in with upstream OpenID Connect providers
+	Federation FederationConfig `mapstructure:"federation"`
+	Audit      AuditConfig      `mapstructure:"audit"`
+	// Privacy configures data exports and account erasure
+	Privacy PrivacyConfig `mapstructure:"privacy"`
 	// Prometheus PrometheusConfig `mapstructure:"prometheus"`
 }
 
 type ServerConfig struct {
 	Host string `mapstructure:"host"`
 	Port int    `mapstructure:"port"`
+	// TrustedProxies may set X-Forwarded-For. The client IP is used for
+	// brute-force protection, so only the gateway should be listed; when
+	// empty, no proxy is trusted.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -28,8 +48,152 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret         string `mapstructure:"secret"`
-	ExpirationTime int    `mapstructure:"expiration_time"`
+	Secret                string `mapstructure:"secret"`
+	ExpirationTime        int    `mapstructure:"expiration_time"`
+	RefreshExpirationTime int    `mapstructure:"refresh_expiration_time"`
+	// Algorithm is HS256, RS256 or EdDSA. Asymmetric keys are generated and
+	// rotated by the service itself.
+	Algorithm           string `mapstructure:"algorithm"`
+	KeyRotationInterval int    `mapstructure:"key_rotation_interval"`
+	KeyGracePeriod      int    `mapstructure:"key_grace_period"`
+	// KeyEncryptionKey encrypts RS256 and EdDSA private keys at rest
+	KeyEncryptionKey string `mapstructure:"key_encryption_key"`
+	// AcceptHS256Until keeps HS256 tokens signed with Secret valid after
+	// switching to RS256 or EdDSA, until this RFC 3339 time. Empty refuses
+	// them.
+	AcceptHS256Until string `mapstructure:"accept_hs256_until"`
+	// Issuer is the iss claim of issued tokens and the issuer advertised in
+	// the OpenID discovery document
+	Issuer string `mapstructure:"issuer"`
+}
+
+type RBACConfig struct {
+	// BootstrapAdmins are emails granted the admin role at startup
+	BootstrapAdmins []string `mapstructure:"bootstrap_admins"`
+}
+
+type AccountConfig struct {
+	// PublicURL is the base URL used in links sent by email
+	PublicURL string `mapstructure:"public_url"`
+	// RequireEmailVerification blocks login until the email address is verified
+	RequireEmailVerification bool `mapstructure:"require_email_verification"`
+	PasswordResetTTL         int  `mapstructure:"password_reset_ttl"`
+	EmailVerificationTTL     int  `mapstructure:"email_verification_ttl"`
+}
+
+type PasswordPolicyConfig struct {
+	MinLength        int  `mapstructure:"min_length"`
+	MaxLength        int  `mapstructure:"max_length"`
+	RequireUppercase bool `mapstructure:"require_uppercase"`
+	RequireLowercase bool `mapstructure:"require_lowercase"`
+	RequireDigit     bool `mapstructure:"require_digit"`
+	RequireSymbol    bool `mapstructure:"require_symbol"`
+	// DisallowPersonalInfo rejects passwords containing the email or name
+	DisallowPersonalInfo bool `mapstructure:"disallow_personal_info"`
+	// BreachedList is a file of SHA-1 hashes of breached passwords; empty
+	// disables the check
+	BreachedList string `mapstructure:"breached_list"`
+}
+
+type PasswordHashingConfig struct {
+	// Algorithm is argon2id or bcrypt
+	Algorithm  string       `mapstructure:"algorithm"`
+	Argon2     Argon2Config `mapstructure:"argon2"`
+	BcryptCost int          `mapstructure:"bcrypt_cost"`
+}
+
+type Argon2Config struct {
+	// Memory is in KiB
+	Memory      uint32 `mapstructure:"memory"`
+	Iterations  uint32 `mapstructure:"iterations"`
+	Parallelism uint8  `mapstructure:"parallelism"`
+	SaltLength  uint32 `mapstructure:"salt_length"`
+	KeyLength   uint32 `mapstructure:"key_length"`
+}
+
+type MailConfig struct {
+	// Driver is smtp, file or log
+	Driver string     `mapstructure:"driver"`
+	From   string     `mapstructure:"from"`
+	Dir    string     `mapstructure:"dir"`
+	SMTP   SMTPConfig `mapstructure:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type MFAConfig struct {
+	// Issuer is the account name shown in authenticator apps
+	Issuer string `mapstructure:"issuer"`
+	// EncryptionKey encrypts TOTP secrets at rest
+	EncryptionKey string `mapstructure:"encryption_key"`
+	// ChallengeTTL is the lifetime of the login MFA challenge, in minutes
+	ChallengeTTL int `mapstructure:"challenge_ttl"`
+}
+
+type LoginProtectionConfig struct {
+	// Store is memory (single instance) or database (shared by replicas)
+	Store         string `mapstructure:"store"`
+	MaxAttempts   int    `mapstructure:"max_attempts"`
+	IPMaxAttempts int    `mapstructure:"ip_max_attempts"`
+	// Window, LockoutDuration and MaxLockoutDuration are in seconds
+	Window             int `mapstructure:"window"`
+	LockoutDuration    int `mapstructure:"lockout_duration"`
+	MaxLockoutDuration int `mapstructure:"max_lockout_duration"`
+}
+
+type OAuthConfig struct {
+	// PublicURL is the base URL of the OAuth endpoints as seen by clients,
+	// used in the discovery document
+	PublicURL string `mapstructure:"public_url"`
+	// ClientTokenTTL is the lifetime of client_credentials tokens, in minutes
+	ClientTokenTTL int `mapstructure:"client_token_ttl"`
+	// AuthorizationCodeTTL is the lifetime of authorization codes, in seconds
+	AuthorizationCodeTTL int `mapstructure:"authorization_code_ttl"`
+}
+
+type FederationConfig struct {
+	// StateTTL is the time a user has to sign in at the provider, in seconds
+	StateTTL  int                        `mapstructure:"state_ttl"`
+	Providers []FederationProviderConfig `mapstructure:"providers"`
+}
+
+type FederationProviderConfig struct {
+	// Name is used in the callback URL and stored with linked identities
+	Name         string   `mapstructure:"name"`
+	DisplayName  string   `mapstructure:"display_name"`
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	Scopes       []string `mapstructure:"scopes"`
+	// AllowedDomains restricts sign-in to these email domains
+	AllowedDomains []string `mapstructure:"allowed_domains"`
+	AutoProvision  bool     `mapstructure:"auto_provision"`
+	LinkByEmail    bool     `mapstructure:"link_by_email"`
+	TrustEmail     bool     `mapstructure:"trust_email"`
+	DefaultRoles   []string `mapstructure:"default_roles"`
+}
+
+type AuditConfig struct {
+	// QueueSize is the number of events waiting to be written before new
+	// events are dropped
+	QueueSize int `mapstructure:"queue_size"`
+	BatchSize int `mapstructure:"batch_size"`
+	// FlushInterval is the longest an event waits to be written, in
+	// milliseconds
+	FlushInterval int `mapstructure:"flush_interval"`
+}
+
+// PrivacyConfig points to the order-service API, which data exports and
+// account erasures reach into. Without OrderServiceURL orders are left out.
+type PrivacyConfig struct {
+	OrderServiceURL string `mapstructure:"order_service_url"`
+	// RequestTimeout is in seconds
+	RequestTimeout int `mapstructure:"request_timeout"`
 }
 
 // type PrometheusConfig struct {
@@ -50,7 +50,28 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 }
 
 func (d *Database) AutoMigrate() error {
-	if err := d.DB.AutoMigrate(&models.User{}); err != nil {
+	if err := d.DB.AutoMigrate(
+		&models.User{},
+		&models.Role{},
+		&models.Permission{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.SigningKey{},
+		&models.OneTimeToken{},
+		&models.TOTPFactor{},
+		&models.RecoveryCode{},
+		&models.LoginAttempt{},
+		&models.OAuthClient{},
+		&models.AuthorizationCode{},
+		&models.Identity{},
+		&models.FederatedLoginState{},
+		&models.APIKey{},
+		&models.Session{},
+		&models.AuditEvent{},
+		&models.ErasureRecord{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+	); err != nil {
 		return fmt.Errorf("failed to auto-migrate database: %w", err)
 	}
 
@@ -58,6 +79,96 @@ func (d *Database) AutoMigrate() error {
 	return nil
 }
 
+var defaultPermissions = map[string]string{
+	models.PermissionOrdersReadAny:    "View orders of any user",
+	models.PermissionOrdersUpdateAny:  "Update orders of any user",
+	models.PermissionOrdersDeleteAny:  "Delete orders of any user",
+	models.PermissionOrdersEraseAny:   "Pseudonymize the orders of erased users",
+	models.PermissionUsersRead:        "View user accounts",
+	models.PermissionUsersWrite:       "Manage user accounts",
+	models.PermissionRolesManage:      "Manage roles and role assignments",
+	models.PermissionClientsManage:    "Manage OAuth2 clients",
+	models.PermissionTokensIntrospect: "Introspect access and refresh tokens",
+	models.PermissionAuditRead:        "View and export the security audit log",
+	models.PermissionOrgsManage:       "Manage organizations and their members",
+}
+
+var defaultRoles = map[string][]string{
+	models.RoleAdmin: {
+		models.PermissionOrdersReadAny,
+		models.PermissionOrdersUpdateAny,
+		models.PermissionOrdersDeleteAny,
+		models.PermissionOrdersEraseAny,
+		models.PermissionUsersRead,
+		models.PermissionUsersWrite,
+		models.PermissionRolesManage,
+		models.PermissionClientsManage,
+		models.PermissionTokensIntrospect,
+		models.PermissionAuditRead,
+		models.PermissionOrgsManage,
+	},
+	models.RoleSupport: {
+		models.PermissionOrdersReadAny,
+		models.PermissionOrdersUpdateAny,
+		models.PermissionUsersRead,
+	},
+}
+
+// SeedRoles creates the built-in permissions and roles if they are missing and
+// grants the admin role to the bootstrap admin emails that have an account.
+// Permissions of existing roles are left untouched.
+func (d *Database) SeedRoles(bootstrapAdmins []string) error {
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		permissions := make(map[string]models.Permission, len(defaultPermissions))
+		for name, description := range defaultPermissions {
+			permission := models.Permission{Name: name, Description: description}
+			if err := tx.Where("name = ?", name).FirstOrCreate(&permission).Error; err != nil {
+				return fmt.Errorf("failed to seed permission %s: %w", name, err)
+			}
+			permissions[name] = permission
+		}
+
+		for name, granted := range defaultRoles {
+			var role models.Role
+			result := tx.Where("name = ?", name).Limit(1).Find(&role)
+			if result.Error != nil {
+				return fmt.Errorf("failed to seed role %s: %w", name, result.Error)
+			}
+			if result.RowsAffected > 0 {
+				continue
+			}
+
+			role = models.Role{Name: name}
+			for _, p := range granted {
+				role.Permissions = append(role.Permissions, permissions[p])
+			}
+			if err := tx.Create(&role).Error; err != nil {
+				return fmt.Errorf("failed to seed role %s: %w", name, err)
+			}
+		}
+
+		if len(bootstrapAdmins) == 0 {
+			return nil
+		}
+
+		var admin models.Role
+		if err := tx.Where("name = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+			return fmt.Errorf("failed to load admin role: %w", err)
+		}
+
+		var users []models.User
+		if err := tx.Where("email IN ?", bootstrapAdmins).Find(&users).Error; err != nil {
+			return fmt.Errorf("failed to load bootstrap admins: %w", err)
+		}
+		for i := range users {
+			if err := tx.Model(&users[i]).Association("Roles").Append(&admin); err != nil {
+				return fmt.Errorf("failed to grant admin role to %s: %w", users[i].Email, err)
+			}
+		}
+		return nil
+	})
+}
+
 func (d *Database) Close() error {
 	sqlDB, err := d.DB.DB()
 	if err != nil {
@@ -0,0 +1,200 @@
+package federation
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubIdP is a minimal OpenID Connect provider: discovery, JWKS and a token
+// endpoint that answers every code with the configured ID token claims.
+type stubIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+	// form is the last token request
+	form url.Values
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &stubIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "stub",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		idp.form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "upstream",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, idp.claims),
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	idp.claims = jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "employee-42",
+		"aud":            "auth-service",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          "n-0S6",
+		"email":          "Jane.Doe@Example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+	}
+	return idp
+}
+
+func (idp *stubIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "stub"
+	signed, err := token.SignedString(idp.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func (idp *stubIdP) provider() *Provider {
+	return NewProvider(ProviderConfig{
+		Name:         "corp",
+		Issuer:       idp.server.URL,
+		ClientID:     "auth-service",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/federated/corp/callback",
+	}, idp.server.Client())
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := idp.provider()
+
+	authURL, err := provider.AuthCodeURL("state-1", "n-0S6", "challenge")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, idp.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	query := parsed.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "auth-service", query.Get("client_id"))
+	assert.Equal(t, "state-1", query.Get("state"))
+	assert.Equal(t, "n-0S6", query.Get("nonce"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	t.Run("valid ID token", func(t *testing.T) {
+		idp := newStubIdP(t)
+
+		identity, err := idp.provider().Exchange("good-code", "verifier", "n-0S6")
+		require.NoError(t, err)
+		assert.Equal(t, "employee-42", identity.Subject)
+		assert.Equal(t, "jane.doe@example.com", identity.Email)
+		assert.True(t, identity.EmailVerified)
+		assert.Equal(t, "Jane", identity.GivenName)
+		assert.Equal(t, "verifier", idp.form.Get("code_verifier"))
+	})
+
+	t.Run("rejected code", func(t *testing.T) {
+		idp := newStubIdP(t)
+
+		_, err := idp.provider().Exchange("bad-code", "verifier", "n-0S6")
+		assert.Error(t, err)
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		idp := newStubIdP(t)
+
+		_, err := idp.provider().Exchange("good-code", "verifier", "other")
+		assert.ErrorIs(t, err, ErrInvalidIDToken)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		idp := newStubIdP(t)
+		idp.claims["aud"] = "another-client"
+
+		_, err := idp.provider().Exchange("good-code", "verifier", "n-0S6")
+		assert.ErrorIs(t, err, ErrInvalidIDToken)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		idp := newStubIdP(t)
+		idp.claims["exp"] = time.Now().Add(-time.Hour).Unix()
+
+		_, err := idp.provider().Exchange("good-code", "verifier", "n-0S6")
+		assert.ErrorIs(t, err, ErrInvalidIDToken)
+	})
+
+	t.Run("signed by another key", func(t *testing.T) {
+		idp := newStubIdP(t)
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims)
+		token.Header["kid"] = "stub"
+		forged, err := token.SignedString(other)
+		require.NoError(t, err)
+
+		_, err = idp.provider().VerifyIDToken(forged, "n-0S6")
+		assert.ErrorIs(t, err, ErrInvalidIDToken)
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		idp := newStubIdP(t)
+		idp.claims["email_verified"] = "false"
+
+		identity, err := idp.provider().Exchange("good-code", "verifier", "n-0S6")
+		require.NoError(t, err)
+		assert.False(t, identity.EmailVerified)
+	})
+}
+
+func TestProviderConfig_EmailAllowed(t *testing.T) {
+	config := &ProviderConfig{AllowedDomains: []string{"example.com"}}
+
+	assert.True(t, config.EmailAllowed("jane@Example.com"))
+	assert.False(t, config.EmailAllowed("jane@example.com.evil.io"))
+	assert.False(t, config.EmailAllowed("jane"))
+	assert.True(t, (&ProviderConfig{}).EmailAllowed("anyone@anywhere.io"))
+}
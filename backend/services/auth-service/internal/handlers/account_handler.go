@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService services.IAccountService
+}
+
+func NewAccountHandler(accountService services.IAccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link. The response is the same whether or not the address is registered.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   request body services.ForgotPasswordRequest true "Email"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Router /auth/password/forgot [post]
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	var req services.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.RequestPasswordReset(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request password reset"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the account exists, a password reset email has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Set a new password using a reset token. All sessions of the user are signed out.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   request body services.ResetPasswordRequest true "Reset"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 400 {object} handlers.PasswordPolicyErrorResponse
+// @Router /auth/password/reset [post]
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var req services.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	if err := h.accountService.ResetPassword(&req); err != nil {
+		if passwordPolicyError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password has been reset"})
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Confirm an email address using the token from the verification email
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   request body services.VerifyEmailRequest true "Token"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Router /auth/email/verify [post]
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	var req services.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email address verified"})
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Send a new verification link. The response is the same whether or not the address is registered.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   request body services.ResendVerificationRequest true "Email"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Router /auth/email/resend [post]
+func (h *AccountHandler) ResendVerification(c *gin.Context) {
+	var req services.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.ResendVerification(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the account exists and is unverified, a verification email has been sent"})
+}
+
+// GetMe godoc
+// @Summary Get the current user
+// @Description Profile of the signed-in user
+// @Tags auth
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.User
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /auth/me [get]
+func (h *AccountHandler) GetMe(c *gin.Context) {
+	user, err := h.accountService.GetProfile(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe godoc
+// @Summary Update the current user
+// @Description Change the first and last name of the signed-in user. Omitted fields are left unchanged.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   request body services.UpdateProfileRequest true "Profile"
+// @Success 200 {object} models.User
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/me [patch]
+func (h *AccountHandler) UpdateMe(c *gin.Context) {
+	var req services.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.accountService.UpdateProfile(c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword godoc
+// @Summary Change the password
+// @Description Set a new password after confirming the current one. All other sessions of the user are signed out.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   request body services.ChangePasswordRequest true "Passwords"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 400 {object} handlers.PasswordPolicyErrorResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Failure 429 {object} handlers.GenericErrorResponse
+// @Router /auth/password/change [post]
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	var req services.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.SessionID = currentSessionID(c)
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	if err := h.accountService.ChangePassword(c.GetUint("user_id"), &req); err != nil {
+		if passwordPolicyError(c, err) || throttledError(c, err) {
+			return
+		}
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password has been changed"})
+}
+
+// ChangeEmail godoc
+// @Summary Change the email address
+// @Description Email a confirmation link to the new address. The address is only changed once the link is used.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   request body services.ChangeEmailRequest true "New email"
+// @Success 202 {object} handlers.GenericSuccessResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Failure 409 {object} handlers.GenericErrorResponse
+// @Failure 429 {object} handlers.GenericErrorResponse
+// @Router /auth/email/change [post]
+func (h *AccountHandler) ChangeEmail(c *gin.Context) {
+	var req services.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ClientIP = c.ClientIP()
+
+	if err := h.accountService.RequestEmailChange(c.GetUint("user_id"), &req); err != nil {
+		if throttledError(c, err) {
+			return
+		}
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "a confirmation email has been sent to the new address"})
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm an email change
+// @Description Switch to the new email address using the token from the confirmation email
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   request body services.ConfirmEmailChangeRequest true "Token"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 409 {object} handlers.GenericErrorResponse
+// @Router /auth/email/change/confirm [post]
+func (h *AccountHandler) ConfirmEmailChange(c *gin.Context) {
+	var req services.ConfirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.ConfirmEmailChange(&req); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email address changed"})
+}
+
+func accountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrEmailInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// passwordPolicyError responds with the broken rules if err is a password
+// policy violation, and reports whether it was.
+func passwordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *auth.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, PasswordPolicyErrorResponse{
+		Error:      "password does not meet the policy",
+		Violations: policyErr.Violations,
+	})
+	return true
+}
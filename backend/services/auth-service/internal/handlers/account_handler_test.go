@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAccountService is a mock of IAccountService
+type MockAccountService struct {
+	mock.Mock
+}
+
+func (m *MockAccountService) RequestPasswordReset(req *services.ForgotPasswordRequest) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) ResetPassword(req *services.ResetPasswordRequest) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) ResendVerification(req *services.ResendVerificationRequest) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) VerifyEmail(req *services.VerifyEmailRequest) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) GetProfile(userID uint) (*models.User, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAccountService) UpdateProfile(userID uint, req *services.UpdateProfileRequest) (*models.User, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAccountService) ChangePassword(userID uint, req *services.ChangePasswordRequest) error {
+	args := m.Called(userID, req)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) RequestEmailChange(userID uint, req *services.ChangeEmailRequest) error {
+	args := m.Called(userID, req)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) ConfirmEmailChange(req *services.ConfirmEmailChangeRequest) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+
+func TestAccountHandler_ForgotPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.ForgotPasswordRequest{Email: "john.doe@example.com"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/password/forgot", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAccountService.On("RequestPasswordReset", reqBody).Return(nil)
+
+		accountHandler.ForgotPassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockAccountService.AssertExpectations(t)
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/password/forgot", bytes.NewBufferString(`{"email":"nope"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		accountHandler.ForgotPassword(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAccountHandler_ResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.ResetPasswordRequest{Token: "reset-token", Password: "new-password"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/password/reset", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAccountService.On("ResetPassword", reqBody).Return(nil)
+
+		accountHandler.ResetPassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockAccountService.AssertExpectations(t)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.ResetPasswordRequest{Token: "used-token", Password: "new-password"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/password/reset", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAccountService.On("ResetPassword", reqBody).Return(errors.New("invalid or expired token"))
+
+		accountHandler.ResetPassword(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockAccountService.AssertExpectations(t)
+	})
+}
+
+func TestAccountHandler_VerifyEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.VerifyEmailRequest{Token: "verify-token"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/email/verify", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAccountService.On("VerifyEmail", reqBody).Return(nil)
+
+		accountHandler.VerifyEmail(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockAccountService.AssertExpectations(t)
+	})
+}
+
+func TestAccountHandler_GetMe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/auth/me", nil)
+		c.Set("user_id", uint(1))
+
+		mockAccountService.On("GetProfile", uint(1)).Return(&models.User{ID: 1, Email: "john.doe@example.com"}, nil)
+
+		accountHandler.GetMe(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "john.doe@example.com")
+		mockAccountService.AssertExpectations(t)
+	})
+
+	t.Run("user deleted", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/auth/me", nil)
+		c.Set("user_id", uint(1))
+
+		mockAccountService.On("GetProfile", uint(1)).Return(nil, services.ErrUserNotFound)
+
+		accountHandler.GetMe(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAccountHandler_UpdateMe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPatch, "/auth/me", bytes.NewBufferString(`{"first_name":"Jane"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		mockAccountService.On("UpdateProfile", uint(1), mock.MatchedBy(func(req *services.UpdateProfileRequest) bool {
+			return req.FirstName != nil && *req.FirstName == "Jane" && req.LastName == nil
+		})).Return(&models.User{ID: 1, FirstName: "Jane", LastName: "Doe"}, nil)
+
+		accountHandler.UpdateMe(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"first_name":"Jane"`)
+		mockAccountService.AssertExpectations(t)
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPatch, "/auth/me", bytes.NewBufferString(`{"last_name":""}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		accountHandler.UpdateMe(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockAccountService.AssertNotCalled(t, "UpdateProfile", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAccountHandler_ChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("keeps current session", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/password/change",
+			bytes.NewBufferString(`{"current_password":"old-password","new_password":"new-password"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+		c.Set("claims", &auth.Claims{SessionID: "family-1"})
+
+		expectedReq := &services.ChangePasswordRequest{CurrentPassword: "old-password", NewPassword: "new-password", SessionID: "family-1"}
+		mockAccountService.On("ChangePassword", uint(1), expectedReq).Return(nil)
+
+		accountHandler.ChangePassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockAccountService.AssertExpectations(t)
+	})
+
+	t.Run("wrong current password", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/password/change",
+			bytes.NewBufferString(`{"current_password":"guess","new_password":"new-password"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		mockAccountService.On("ChangePassword", uint(1), mock.Anything).Return(services.ErrInvalidCredentials)
+
+		accountHandler.ChangePassword(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("locked out", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/password/change",
+			bytes.NewBufferString(`{"current_password":"guess","new_password":"new-password"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		mockAccountService.On("ChangePassword", uint(1), mock.Anything).Return(&auth.ThrottledError{RetryAfter: 90 * time.Second})
+
+		accountHandler.ChangePassword(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	})
+}
+
+func TestAccountHandler_ChangeEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("sends confirmation", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.ChangeEmailRequest{NewEmail: "jane.doe@example.com", Password: "password"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/email/change", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		mockAccountService.On("RequestEmailChange", uint(1), reqBody).Return(nil)
+
+		accountHandler.ChangeEmail(c)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		mockAccountService.AssertExpectations(t)
+	})
+
+	t.Run("address in use", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/email/change",
+			bytes.NewBufferString(`{"new_email":"taken@example.com","password":"password"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		mockAccountService.On("RequestEmailChange", uint(1), mock.Anything).Return(services.ErrEmailInUse)
+
+		accountHandler.ChangeEmail(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestAccountHandler_ConfirmEmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.ConfirmEmailChangeRequest{Token: "change-token"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/email/change/confirm", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAccountService.On("ConfirmEmailChange", reqBody).Return(nil)
+
+		accountHandler.ConfirmEmailChange(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockAccountService.AssertExpectations(t)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		mockAccountService := new(MockAccountService)
+		accountHandler := NewAccountHandler(mockAccountService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/email/change/confirm", bytes.NewBufferString(`{"token":"used"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAccountService.On("ConfirmEmailChange", mock.Anything).Return(errors.New("invalid or expired token"))
+
+		accountHandler.ConfirmEmailChange(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
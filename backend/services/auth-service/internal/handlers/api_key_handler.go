@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.IAPIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.IAPIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// ListKeys godoc
+// @Summary List API keys
+// @Description API keys of the current user that have not been revoked, including expired ones
+// @Tags auth
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string][]models.APIKey
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/api-keys [get]
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.ListKeys(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// CreateKey godoc
+// @Summary Create an API key
+// @Description Create a named API key for the current user. Scopes must be permissions the user holds; without scopes the key can only access the user's own resources. The generated key is only returned once.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   key body services.CreateAPIKeyRequest true "API key"
+// @Success 201 {object} services.APIKeySecretResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/api-keys [post]
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req services.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.apiKeyService.CreateKey(c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// RevokeKey godoc
+// @Summary Revoke an API key
+// @Description Revoke one of the current user's API keys. It is rejected immediately.
+// @Tags auth
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "API key ID"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /auth/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key id"})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(c.GetUint("user_id"), uint(id)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPIKeyService is a mock of IAPIKeyService
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) ListKeys(userID uint) ([]*models.APIKey, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) CreateKey(userID uint, req *services.CreateAPIKeyRequest) (*services.APIKeySecretResponse, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.APIKeySecretResponse), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeKey(userID, id uint) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func TestAPIKeyHandler_ListKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/auth/api-keys", nil)
+	c.Set("user_id", uint(7))
+
+	mockService.On("ListKeys", uint(7)).Return([]*models.APIKey{
+		{ID: 1, UserID: 7, Name: "ci", Prefix: "ak_AbCdEf", KeyHash: "deadbeef"},
+	}, nil)
+
+	handler.ListKeys(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"prefix":"ak_AbCdEf"`)
+	assert.NotContains(t, w.Body.String(), "deadbeef")
+	mockService.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_CreateKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockAPIKeyService)
+		handler := NewAPIKeyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body, _ := json.Marshal(gin.H{"name": "ci", "scopes": []string{"orders:read:any"}})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/api-keys", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(7))
+
+		expectedReq := &services.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"orders:read:any"}}
+		mockService.On("CreateKey", uint(7), expectedReq).Return(&services.APIKeySecretResponse{
+			APIKey: &models.APIKey{ID: 1, UserID: 7, Name: "ci", Prefix: "ak_AbCdEf", Scopes: "orders:read:any"},
+			Key:    "ak_AbCdEfsecret",
+		}, nil)
+
+		handler.CreateKey(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"key":"ak_AbCdEfsecret"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("scope not held", func(t *testing.T) {
+		mockService := new(MockAPIKeyService)
+		handler := NewAPIKeyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body, _ := json.Marshal(gin.H{"name": "ci", "scopes": []string{"roles:manage"}})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/api-keys", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(7))
+
+		mockService.On("CreateKey", uint(7), mock.Anything).
+			Return(nil, fmt.Errorf("%w: roles:manage", services.ErrAPIKeyScope))
+
+		handler.CreateKey(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		mockService := new(MockAPIKeyService)
+		handler := NewAPIKeyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/api-keys", bytes.NewBufferString(`{}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.CreateKey(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "CreateKey", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAPIKeyHandler_RevokeKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockAPIKeyService)
+		handler := NewAPIKeyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/auth/api-keys/3", nil)
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+		c.Set("user_id", uint(7))
+
+		mockService.On("RevokeKey", uint(7), uint(3)).Return(nil)
+
+		handler.RevokeKey(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := new(MockAPIKeyService)
+		handler := NewAPIKeyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/auth/api-keys/3", nil)
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+		c.Set("user_id", uint(7))
+
+		mockService.On("RevokeKey", uint(7), uint(3)).Return(services.ErrAPIKeyNotFound)
+
+		handler.RevokeKey(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
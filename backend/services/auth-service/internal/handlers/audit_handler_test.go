@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuditService is a mock of IAuditService
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) ListEvents(req *services.ListAuditEventsRequest) (*services.ListAuditEventsResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ListAuditEventsResponse), args.Error(1)
+}
+
+func (m *MockAuditService) ExportEvents(query *services.AuditQuery, fn func(event *models.AuditEvent) error) error {
+	args := m.Called(query)
+	if events, ok := args.Get(0).([]*models.AuditEvent); ok {
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func TestAuditHandler_ListEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("filters", func(t *testing.T) {
+		mockService := new(MockAuditService)
+		handler := NewAuditHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet,
+			"/admin/audit-events?type=login&outcome=failure&actor_email=john.doe@example.com&from=2024-01-01T00:00:00Z&limit=10", nil)
+
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockService.On("ListEvents", mock.MatchedBy(func(req *services.ListAuditEventsRequest) bool {
+			return req.Type == models.AuditEventLogin && req.Outcome == models.AuditOutcomeFailure &&
+				req.ActorEmail == "john.doe@example.com" && req.From != nil && req.From.Equal(from) &&
+				req.To == nil && req.Limit == 10
+		})).Return(&services.ListAuditEventsResponse{
+			Events: []*models.AuditEvent{{ID: 3, Type: models.AuditEventLogin, Outcome: models.AuditOutcomeFailure, Reason: "invalid credentials"}},
+			Total:  1,
+			Limit:  10,
+		}, nil)
+
+		handler.ListEvents(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"reason":"invalid credentials"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid outcome", func(t *testing.T) {
+		mockService := new(MockAuditService)
+		handler := NewAuditHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/audit-events?outcome=maybe", nil)
+
+		handler.ListEvents(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ListEvents", mock.Anything)
+	})
+}
+
+func TestAuditHandler_ExportEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("json lines", func(t *testing.T) {
+		mockService := new(MockAuditService)
+		handler := NewAuditHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/audit-events/export?type=register", nil)
+
+		mockService.On("ExportEvents", &services.AuditQuery{Type: models.AuditEventRegister}).Return([]*models.AuditEvent{
+			{ID: 1, Type: models.AuditEventRegister, Outcome: models.AuditOutcomeSuccess},
+			{ID: 2, Type: models.AuditEventRegister, Outcome: models.AuditOutcomeFailure},
+		}, nil)
+
+		handler.ExportEvents(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		assert.Len(t, lines, 2)
+		assert.Contains(t, lines[1], `"id":2`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("no events", func(t *testing.T) {
+		mockService := new(MockAuditService)
+		handler := NewAuditHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/audit-events/export", nil)
+
+		mockService.On("ExportEvents", &services.AuditQuery{}).Return(nil, nil)
+
+		handler.ExportEvents(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("failure before the first event", func(t *testing.T) {
+		mockService := new(MockAuditService)
+		handler := NewAuditHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/audit-events/export", nil)
+
+		mockService.On("ExportEvents", mock.Anything).Return(nil, errors.New("database is down"))
+
+		handler.ExportEvents(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
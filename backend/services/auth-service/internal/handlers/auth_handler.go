@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"auth-service/internal/auth"
 	"auth-service/internal/services"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -24,7 +27,7 @@ func NewAuthHandler(authService services.IAuthService) *AuthHandler {
 // @Produce  json
 // @Param   register body services.RegisterRequest true "Register"
 // @Success 201 {object} services.AuthResponse
-// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 400 {object} handlers.PasswordPolicyErrorResponse
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req services.RegisterRequest
@@ -32,9 +35,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
 
 	response, err := h.authService.Register(&req)
 	if err != nil {
+		if passwordPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -44,13 +52,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary Login a user
-// @Description Login a user with email and password
+// @Description Login a user with email and password. If the user has MFA enabled the response only carries mfa_required and an mfa_token for /auth/mfa/verify.
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Param   login body services.LoginRequest true "Login"
 // @Success 200 {object} services.AuthResponse
 // @Failure 401 {object} handlers.GenericErrorResponse
+// @Failure 429 {object} handlers.GenericErrorResponse
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req services.LoginRequest
@@ -59,8 +68,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
 	response, err := h.authService.Login(&req)
 	if err != nil {
+		if throttledError(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
@@ -70,7 +85,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 // ValidateToken godoc
 // @Summary Validate a token
-// @Description Validate a token
+// @Description Validate an access token or API key. User tokens and API keys set the X-USER-ID response header and, in an organization, X-ORG-ID and X-ORG-ROLE; API keys also return the scope delegated to the key and the organization. Tokens issued to OAuth2 clients set X-CLIENT-ID instead and return the client ID and scope.
 // @Tags auth
 // @Produce  json
 // @Security ApiKeyAuth
@@ -78,28 +93,132 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // @Failure 401 {object} handlers.GenericErrorResponse
 // @Router /auth/validate [get]
 func (h *AuthHandler) ValidateToken(c *gin.Context) {
-	tokenString := c.GetHeader("Authorization")
+	tokenString := bearerToken(c)
 	if tokenString == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
 		return
 	}
 
-	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-		tokenString = tokenString[7:]
-	}
-
-	user, err := h.authService.ValidateToken(tokenString)
+	user, claims, err := h.authService.ValidateToken(tokenString)
 	if err != nil {
+		h.authService.TokenRejected(c.ClientIP(), c.Request.UserAgent(), err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
+	if claims != nil && claims.IsClient() {
+		c.Writer.Header().Set("X-CLIENT-ID", claims.ClientID)
+		c.JSON(http.StatusOK, gin.H{"client_id": claims.ClientID, "scope": claims.Scope})
+		return
+	}
+
 	if user != nil {
 		c.Writer.Header().Set("X-USER-ID", strconv.Itoa(int(user.ID)))
 	}
+	if claims != nil && claims.OrgID != 0 {
+		c.Writer.Header().Set("X-ORG-ID", strconv.Itoa(int(claims.OrgID)))
+		c.Writer.Header().Set("X-ORG-ROLE", claims.OrgRole)
+	}
+	if claims != nil && claims.IsAPIKey() {
+		c.JSON(http.StatusOK, gin.H{"user": user, "scope": claims.Scope, "api_key_id": claims.APIKeyID,
+			"org_id": claims.OrgID, "org_role": claims.OrgRole})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"user": user})
 }
 
+// Refresh godoc
+// @Summary Refresh an access token
+// @Description Exchange a refresh token for a new access and refresh token pair. The presented refresh token is rotated and can not be used again.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   refresh body services.RefreshRequest true "Refresh"
+// @Success 200 {object} services.AuthResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req services.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authService.Refresh(&req)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyMFA godoc
+// @Summary Complete an MFA login
+// @Description Exchange the mfa_token returned by login and a TOTP or recovery code for an access and refresh token
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   verify body services.VerifyMFARequest true "MFA verification"
+// @Success 200 {object} services.AuthResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Failure 429 {object} handlers.GenericErrorResponse
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req services.VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	response, err := h.authService.VerifyMFA(&req)
+	if err != nil {
+		if throttledError(c, err) {
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout godoc
+// @Summary Logout a user
+// @Description Revoke the current access token and the given refresh token, or every session of the user when all_sessions is set
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   logout body services.LogoutRequest false "Logout"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	tokenString := bearerToken(c)
+	if tokenString == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+		return
+	}
+
+	var req services.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.authService.Logout(tokenString, &req); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
 // GetUser godoc
 // @Summary Get a user by ID
 // @Description Get a user by ID
@@ -125,3 +244,26 @@ func (h *AuthHandler) GetUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user)
 }
+
+// bearerToken returns the token from the Authorization header, with the
+// optional "Bearer " prefix stripped.
+func bearerToken(c *gin.Context) string {
+	tokenString := c.GetHeader("Authorization")
+	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+		tokenString = tokenString[7:]
+	}
+	return tokenString
+}
+
+// throttledError responds with 429 and Retry-After if err is a brute-force
+// lockout, and reports whether it was.
+func throttledError(c *gin.Context, err error) bool {
+	var throttled *auth.ThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	return true
+}
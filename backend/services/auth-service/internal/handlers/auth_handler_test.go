@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"auth-service/internal/auth"
 	"auth-service/internal/models"
 	"auth-service/internal/services"
 	"bytes"
@@ -37,12 +38,50 @@ func (m *MockAuthService) Login(req *services.LoginRequest) (*services.AuthRespo
 	return args.Get(0).(*services.AuthResponse), args.Error(1)
 }
 
-func (m *MockAuthService) ValidateToken(token string) (*models.User, error) {
+func (m *MockAuthService) VerifyMFA(req *services.VerifyMFARequest) (*services.AuthResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AuthResponse), args.Error(1)
+}
+
+func (m *MockAuthService) ValidateToken(token string) (*models.User, *auth.Claims, error) {
 	args := m.Called(token)
+	var user *models.User
+	if args.Get(0) != nil {
+		user = args.Get(0).(*models.User)
+	}
+	var claims *auth.Claims
+	if args.Get(1) != nil {
+		claims = args.Get(1).(*auth.Claims)
+	}
+	return user, claims, args.Error(2)
+}
+
+func (m *MockAuthService) TokenRejected(ipAddress, userAgent string, err error) {
+	m.Called(ipAddress, userAgent, err)
+}
+
+func (m *MockAuthService) Refresh(req *services.RefreshRequest) (*services.AuthResponse, error) {
+	args := m.Called(req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.User), args.Error(1)
+	return args.Get(0).(*services.AuthResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Logout(accessToken string, req *services.LogoutRequest) error {
+	args := m.Called(accessToken, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) Introspect(req *services.IntrospectionRequest) (*services.IntrospectionResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.IntrospectionResponse), args.Error(1)
 }
 
 func (m *MockAuthService) GetUserByID(id uint) (*models.User, error) {
@@ -108,6 +147,33 @@ func TestAuthHandler_Register(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	t.Run("password policy", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest(http.MethodPost, "/register",
+			bytes.NewBufferString(`{"email":"test@example.com","password":"test","first_name":"test","last_name":"user"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAuthService.On("Register", mock.Anything).Return(nil, &auth.PasswordPolicyError{Violations: []auth.PasswordViolation{
+			{Rule: auth.PasswordRuleMinLength, Message: "must be at least 8 characters long"},
+			{Rule: auth.PasswordRulePersonalInfo, Message: "must not contain your name or email address"},
+		}})
+
+		authHandler.Register(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var resp PasswordPolicyErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, "password does not meet the policy", resp.Error)
+		assert.Len(t, resp.Violations, 2)
+		assert.Equal(t, auth.PasswordRulePersonalInfo, resp.Violations[1].Rule)
+	})
 }
 
 func TestAuthHandler_Login(t *testing.T) {
@@ -161,6 +227,32 @@ func TestAuthHandler_Login(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+	t.Run("locked out", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.LoginRequest{
+			Email:    "test@example.com",
+			Password: "guess",
+		}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Request.RemoteAddr = "10.0.0.1:4711"
+
+		expectedReq := *reqBody
+		expectedReq.ClientIP = "10.0.0.1"
+		mockAuthService.On("Login", &expectedReq).Return(nil, &auth.ThrottledError{RetryAfter: 90 * time.Second})
+
+		authHandler.Login(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "90", w.Header().Get("Retry-After"))
+		mockAuthService.AssertExpectations(t)
+	})
 }
 
 func TestAuthHandler_ValidateToken(t *testing.T) {
@@ -182,15 +274,110 @@ func TestAuthHandler_ValidateToken(t *testing.T) {
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
-		mockAuthService.On("ValidateToken", "some-jwt-token").Return(mockUser, nil)
+		mockAuthService.On("ValidateToken", "some-jwt-token").Return(mockUser, &auth.Claims{UserID: 1, SubjectType: auth.SubjectTypeUser}, nil)
+
+		authHandler.ValidateToken(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp gin.H
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.NotNil(t, resp["user"])
+		assert.Equal(t, "1", w.Header().Get("X-USER-ID"))
+		assert.Empty(t, w.Header().Get("X-ORG-ID"))
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("organization", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest(http.MethodGet, "/validate", nil)
+		c.Request.Header.Set("Authorization", "Bearer some-jwt-token")
+
+		claims := &auth.Claims{UserID: 1, SubjectType: auth.SubjectTypeUser, OrgID: 2, OrgRole: models.OrgRoleAdmin}
+		mockAuthService.On("ValidateToken", "some-jwt-token").Return(&models.User{ID: 1}, claims, nil)
+
+		authHandler.ValidateToken(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-ORG-ID"))
+		assert.Equal(t, "admin", w.Header().Get("X-ORG-ROLE"))
+	})
+
+	t.Run("client token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest(http.MethodGet, "/validate", nil)
+		c.Request.Header.Set("Authorization", "Bearer client-jwt-token")
+
+		claims := &auth.Claims{SubjectType: auth.SubjectTypeClient, ClientID: "reporting", Scope: "orders:read:any"}
+		mockAuthService.On("ValidateToken", "client-jwt-token").Return(nil, claims, nil)
 
 		authHandler.ValidateToken(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-USER-ID"))
+		assert.Equal(t, "reporting", w.Header().Get("X-CLIENT-ID"))
+		var resp gin.H
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, "reporting", resp["client_id"])
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest(http.MethodGet, "/validate", nil)
+		c.Request.Header.Set("Authorization", "Bearer ak_some-api-key")
+
+		mockUser := &models.User{ID: 1, Email: "test@example.com", IsActive: true}
+		claims := &auth.Claims{UserID: 1, SubjectType: auth.SubjectTypeUser, Scope: "orders:read:any", APIKeyID: 5}
+		mockAuthService.On("ValidateToken", "ak_some-api-key").Return(mockUser, claims, nil)
+
+		authHandler.ValidateToken(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-USER-ID"))
 		var resp gin.H
 		err := json.Unmarshal(w.Body.Bytes(), &resp)
 		assert.NoError(t, err)
 		assert.NotNil(t, resp["user"])
+		assert.Equal(t, "orders:read:any", resp["scope"])
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("rejected token is audited", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest(http.MethodGet, "/validate", nil)
+		c.Request.Header.Set("Authorization", "Bearer revoked-jwt-token")
+		c.Request.Header.Set("User-Agent", "curl/8.0")
+		c.Request.RemoteAddr = "203.0.113.7:5000"
+
+		rejected := errors.New("token has been revoked")
+		mockAuthService.On("ValidateToken", "revoked-jwt-token").Return(nil, nil, rejected)
+		mockAuthService.On("TokenRejected", "203.0.113.7", "curl/8.0", rejected).Return()
+
+		authHandler.ValidateToken(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
 		mockAuthService.AssertExpectations(t)
 	})
 
@@ -209,6 +396,171 @@ func TestAuthHandler_ValidateToken(t *testing.T) {
 	})
 }
 
+func TestAuthHandler_Refresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.RefreshRequest{RefreshToken: "some-refresh-token"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/refresh", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockResponse := &services.AuthResponse{
+			Token:        "new-jwt-token",
+			RefreshToken: "new-refresh-token",
+			User: &models.User{
+				ID:    1,
+				Email: "test@example.com",
+			},
+		}
+		mockAuthService.On("Refresh", reqBody).Return(mockResponse, nil)
+
+		authHandler.Refresh(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp services.AuthResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, mockResponse, &resp)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("reused token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.RefreshRequest{RefreshToken: "old-refresh-token"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/refresh", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAuthService.On("Refresh", reqBody).Return(nil, errors.New("refresh token reuse detected"))
+
+		authHandler.Refresh(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+}
+
+func TestAuthHandler_VerifyMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.VerifyMFARequest{MFAToken: "challenge-token", Code: "123456"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/mfa/verify", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockResponse := &services.AuthResponse{
+			Token:        "jwt-token",
+			RefreshToken: "refresh-token",
+			User:         &models.User{ID: 1, Email: "test@example.com"},
+		}
+		mockAuthService.On("VerifyMFA", reqBody).Return(mockResponse, nil)
+
+		authHandler.VerifyMFA(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.VerifyMFARequest{MFAToken: "challenge-token", Code: "000000"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/mfa/verify", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAuthService.On("VerifyMFA", reqBody).Return(nil, services.ErrInvalidMFACode)
+
+		authHandler.VerifyMFA(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("locked out", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.VerifyMFARequest{MFAToken: "challenge-token", Code: "000000"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/mfa/verify", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockAuthService.On("VerifyMFA", reqBody).Return(nil, &auth.ThrottledError{RetryAfter: 90 * time.Second})
+
+		authHandler.VerifyMFA(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "90", w.Header().Get("Retry-After"))
+		mockAuthService.AssertExpectations(t)
+	})
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.LogoutRequest{RefreshToken: "some-refresh-token"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/logout", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Request.Header.Set("Authorization", "Bearer some-jwt-token")
+
+		mockAuthService.On("Logout", "some-jwt-token", reqBody).Return(nil)
+
+		authHandler.Logout(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		authHandler := NewAuthHandler(mockAuthService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest(http.MethodPost, "/logout", nil)
+
+		authHandler.Logout(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
 func TestAuthHandler_GetUser(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
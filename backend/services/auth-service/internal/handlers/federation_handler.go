@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"auth-service/internal/services"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// federatedStateCookie binds a pending federated sign-in to the browser that
+// started it.
+const federatedStateCookie = "federated_state"
+
+type FederationHandler struct {
+	federationService services.IFederationService
+	// stateTTL is the lifetime of the state cookie, in seconds
+	stateTTL int
+}
+
+func NewFederationHandler(federationService services.IFederationService, stateTTL int) *FederationHandler {
+	return &FederationHandler{
+		federationService: federationService,
+		stateTTL:          stateTTL,
+	}
+}
+
+// ListProviders godoc
+// @Summary List identity providers
+// @Description Upstream OpenID Connect providers users can sign in with instead of a password
+// @Tags auth
+// @Produce  json
+// @Success 200 {object} map[string][]services.IdentityProviderResponse
+// @Router /auth/providers [get]
+func (h *FederationHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.federationService.Providers()})
+}
+
+// Login godoc
+// @Summary Sign in with an identity provider
+// @Description Redirect the browser to the identity provider. Authorization request parameters (client_id, redirect_uri, ...) continue an OpenID Connect authorization after the sign-in; without them the callback returns tokens like /auth/login.
+// @Tags auth
+// @Param   provider path string true "Provider name"
+// @Success 302 {string} string "Redirect to the identity provider"
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /auth/federated/{provider}/login [get]
+func (h *FederationHandler) Login(c *gin.Context) {
+	req := services.FederatedLoginRequest{Provider: c.Param("provider")}
+	if c.Query("client_id") != "" {
+		var authorize services.AuthorizeRequest
+		if err := c.ShouldBindQuery(&authorize); err != nil {
+			renderAuthorizeError(c, http.StatusBadRequest, "invalid authorization request")
+			return
+		}
+		req.Authorize = &authorize
+	}
+
+	start, err := h.federationService.Begin(&req)
+	if err != nil {
+		if req.Authorize != nil && !errors.Is(err, services.ErrProviderNotFound) {
+			authorizeError(c, req.Authorize, err)
+			return
+		}
+		c.JSON(federationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(federatedStateCookie, start.State, h.stateTTL, "/api/v1/auth/federated", "", secureRequest(c), true)
+	c.Redirect(http.StatusFound, start.RedirectURL)
+}
+
+// Callback godoc
+// @Summary Identity provider callback
+// @Description Redirect URI registered with the identity provider. Returns the same response as /auth/login, or continues the OpenID Connect authorization the sign-in was started from.
+// @Tags auth
+// @Produce  json
+// @Param   provider path string true "Provider name"
+// @Param   code query string false "Authorization code"
+// @Param   state query string true "State"
+// @Success 200 {object} services.AuthResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Failure 409 {object} handlers.GenericErrorResponse
+// @Router /auth/federated/{provider}/callback [get]
+func (h *FederationHandler) Callback(c *gin.Context) {
+	var req services.FederatedCallbackRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Provider = c.Param("provider")
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	cookie, err := c.Cookie(federatedStateCookie)
+	if err != nil || req.State == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(req.State)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidFederatedState.Error()})
+		return
+	}
+	c.SetCookie(federatedStateCookie, "", -1, "/api/v1/auth/federated", "", secureRequest(c), true)
+
+	result, err := h.federationService.Complete(&req)
+	if result != nil && result.Authorize != nil {
+		h.completeAuthorize(c, result, err)
+		return
+	}
+	if err != nil {
+		c.JSON(federationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result.Auth)
+}
+
+// ListIdentities godoc
+// @Summary List linked identities
+// @Description External accounts at identity providers linked to the current user
+// @Tags auth
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string][]models.Identity
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/identities [get]
+func (h *FederationHandler) ListIdentities(c *gin.Context) {
+	identities, err := h.federationService.ListIdentities(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch identities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+// completeAuthorize continues the authorization request a federated sign-in
+// was started from.
+func (h *FederationHandler) completeAuthorize(c *gin.Context, result *services.FederatedLoginResult, err error) {
+	if err != nil {
+		var oauthErr *services.OAuthError
+		if errors.Is(err, services.ErrInvalidAuthorizeClient) || errors.As(err, &oauthErr) {
+			authorizeError(c, result.Authorize, err)
+			return
+		}
+		renderLoginPage(c, federationErrorStatus(err), &loginPageData{
+			Request:   result.Authorize,
+			Error:     "Sign-in failed: " + err.Error() + ".",
+			Providers: loginProviders(h.federationService.Providers(), result.Authorize),
+		})
+		return
+	}
+
+	if result.Result.MFAToken != "" {
+		renderLoginPage(c, http.StatusOK, &loginPageData{
+			Request:  result.Authorize,
+			MFAToken: result.Result.MFAToken,
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, result.Result.RedirectURL)
+}
+
+func federationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProviderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidFederatedState):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrFederatedEmailUnverified), errors.Is(err, services.ErrFederatedAccountNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrFederatedEmailInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
+// secureRequest reports whether the browser reached the gateway over HTTPS.
+func secureRequest(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
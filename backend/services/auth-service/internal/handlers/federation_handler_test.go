@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockFederationService is a mock of IFederationService
+type MockFederationService struct {
+	mock.Mock
+}
+
+func (m *MockFederationService) Providers() []services.IdentityProviderResponse {
+	args := m.Called()
+	return args.Get(0).([]services.IdentityProviderResponse)
+}
+
+func (m *MockFederationService) Begin(req *services.FederatedLoginRequest) (*services.FederatedLoginStart, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.FederatedLoginStart), args.Error(1)
+}
+
+func (m *MockFederationService) Complete(req *services.FederatedCallbackRequest) (*services.FederatedLoginResult, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.FederatedLoginResult), args.Error(1)
+}
+
+func (m *MockFederationService) ListIdentities(userID uint) ([]*models.Identity, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Identity), args.Error(1)
+}
+
+func newCallbackRequest(query, cookie string) *http.Request {
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/auth/federated/corp/callback?"+query, nil)
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: federatedStateCookie, Value: cookie})
+	}
+	return req
+}
+
+func TestFederationHandler_ListProviders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockFederationService)
+	handler := NewFederationHandler(mockService, 600)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/auth/providers", nil)
+
+	mockService.On("Providers").Return([]services.IdentityProviderResponse{{Name: "corp", DisplayName: "Corporate SSO"}})
+
+	handler.ListProviders(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Corporate SSO")
+}
+
+func TestFederationHandler_Login(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("redirects to provider", func(t *testing.T) {
+		mockService := new(MockFederationService)
+		handler := NewFederationHandler(mockService, 600)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/auth/federated/corp/login", nil)
+		c.Params = gin.Params{{Key: "provider", Value: "corp"}}
+
+		mockService.On("Begin", &services.FederatedLoginRequest{Provider: "corp"}).
+			Return(&services.FederatedLoginStart{RedirectURL: "https://idp.example.com/authorize?state=s1", State: "s1"}, nil)
+
+		handler.Login(c)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://idp.example.com/authorize?state=s1", w.Header().Get("Location"))
+		cookie := w.Header().Get("Set-Cookie")
+		assert.Contains(t, cookie, federatedStateCookie+"=s1")
+		assert.Contains(t, cookie, "HttpOnly")
+		assert.Contains(t, cookie, "SameSite=Lax")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("carries authorization request", func(t *testing.T) {
+		mockService := new(MockFederationService)
+		handler := NewFederationHandler(mockService, 600)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/auth/federated/corp/login?"+authorizeParams().Encode(), nil)
+		c.Params = gin.Params{{Key: "provider", Value: "corp"}}
+
+		mockService.On("Begin", mock.MatchedBy(func(req *services.FederatedLoginRequest) bool {
+			return req.Authorize != nil && req.Authorize.ClientID == "web" && req.Authorize.State == "xyz"
+		})).Return(&services.FederatedLoginStart{RedirectURL: "https://idp.example.com/authorize", State: "s1"}, nil)
+
+		handler.Login(c)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		mockService := new(MockFederationService)
+		handler := NewFederationHandler(mockService, 600)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/auth/federated/nope/login", nil)
+		c.Params = gin.Params{{Key: "provider", Value: "nope"}}
+
+		mockService.On("Begin", mock.Anything).Return(nil, services.ErrProviderNotFound)
+
+		handler.Login(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestFederationHandler_Callback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns tokens", func(t *testing.T) {
+		mockService := new(MockFederationService)
+		handler := NewFederationHandler(mockService, 600)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newCallbackRequest("code=abc&state=s1", "s1")
+		c.Params = gin.Params{{Key: "provider", Value: "corp"}}
+
+		expectedReq := &services.FederatedCallbackRequest{Provider: "corp", Code: "abc", State: "s1"}
+		mockService.On("Complete", expectedReq).
+			Return(&services.FederatedLoginResult{Auth: &services.AuthResponse{Token: "jwt", RefreshToken: "refresh"}}, nil)
+
+		handler.Callback(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp services.AuthResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, "jwt", resp.Token)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("state not bound to browser", func(t *testing.T) {
+		mockService := new(MockFederationService)
+		handler := NewFederationHandler(mockService, 600)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newCallbackRequest("code=abc&state=s1", "other")
+		c.Params = gin.Params{{Key: "provider", Value: "corp"}}
+
+		handler.Callback(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "Complete", mock.Anything)
+	})
+
+	t.Run("email in use", func(t *testing.T) {
+		mockService := new(MockFederationService)
+		handler := NewFederationHandler(mockService, 600)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newCallbackRequest("code=abc&state=s1", "s1")
+		c.Params = gin.Params{{Key: "provider", Value: "corp"}}
+
+		mockService.On("Complete", mock.Anything).Return(&services.FederatedLoginResult{}, services.ErrFederatedEmailInUse)
+
+		handler.Callback(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("continues authorization", func(t *testing.T) {
+		mockService := new(MockFederationService)
+		handler := NewFederationHandler(mockService, 600)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newCallbackRequest("code=abc&state=s1", "s1")
+		c.Params = gin.Params{{Key: "provider", Value: "corp"}}
+
+		redirect := "https://app.example.com/callback?code=xyz&state=xyz"
+		mockService.On("Complete", mock.Anything).Return(&services.FederatedLoginResult{
+			Authorize: &services.AuthorizeRequest{ClientID: "web", RedirectURI: "https://app.example.com/callback"},
+			Result:    &services.AuthorizeResult{RedirectURL: redirect},
+		}, nil)
+
+		handler.Callback(c)
+		c.Writer.WriteHeaderNow()
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, redirect, w.Header().Get("Location"))
+	})
+
+	t.Run("authorization needs mfa", func(t *testing.T) {
+		mockService := new(MockFederationService)
+		handler := NewFederationHandler(mockService, 600)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newCallbackRequest("code=abc&state=s1", "s1")
+		c.Params = gin.Params{{Key: "provider", Value: "corp"}}
+
+		mockService.On("Complete", mock.Anything).Return(&services.FederatedLoginResult{
+			Authorize: &services.AuthorizeRequest{ClientID: "web", RedirectURI: "https://app.example.com/callback"},
+			Result:    &services.AuthorizeResult{MFAToken: "challenge"},
+		}, nil)
+
+		handler.Callback(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `name="mfa_token" value="challenge"`)
+		assert.Contains(t, w.Body.String(), `action="/oauth/authorize"`)
+	})
+}
@@ -1,7 +1,30 @@
 package handlers
 
+import "auth-service/internal/auth"
+
 // GenericErrorResponse represents a generic error response.
 // @name GenericErrorResponse
 type GenericErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// GenericSuccessResponse represents a generic success response.
+// @name GenericSuccessResponse
+type GenericSuccessResponse struct {
+	Message string `json:"message"`
+}
+
+// OAuthErrorResponse is the RFC 6749 error response of the OAuth endpoints.
+// @name OAuthErrorResponse
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// PasswordPolicyErrorResponse lists the password policy rules a new password
+// breaks.
+// @name PasswordPolicyErrorResponse
+type PasswordPolicyErrorResponse struct {
+	Error      string                   `json:"error"`
+	Violations []auth.PasswordViolation `json:"violations"`
+}
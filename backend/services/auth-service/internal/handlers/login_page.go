@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"html/template"
+	"net/url"
+
+	"auth-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loginPage is the minimal sign-in page of the authorization endpoint. The
+// authorization request is carried through the form in hidden fields.
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Sign in</title>
+<style>
+body { font-family: sans-serif; background: #f5f5f5; display: flex; justify-content: center; padding-top: 10vh; }
+form, .box { background: #fff; padding: 2em; border-radius: 6px; box-shadow: 0 1px 4px rgba(0,0,0,.15); width: 20em; }
+label { display: block; margin-top: 1em; }
+input[type=email], input[type=password], input[type=text] { width: 100%; box-sizing: border-box; padding: .5em; }
+button { margin-top: 1.5em; width: 100%; padding: .6em; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+{{if .Fatal}}
+<div class="box">
+<h1>Sign-in error</h1>
+<p class="error">{{.Error}}</p>
+</div>
+{{else}}
+<form method="post" action="/oauth/authorize">
+<h1>Sign in</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{with .Request}}
+<input type="hidden" name="response_type" value="{{.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Scope}}">
+<input type="hidden" name="state" value="{{.State}}">
+<input type="hidden" name="nonce" value="{{.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.CodeChallengeMethod}}">
+{{end}}
+{{if .MFAToken}}
+<input type="hidden" name="mfa_token" value="{{.MFAToken}}">
+<label for="code">Authentication code or recovery code</label>
+<input type="text" id="code" name="code" autocomplete="one-time-code" required autofocus>
+{{else}}
+<label for="email">Email</label>
+<input type="email" id="email" name="email" value="{{.Email}}" autocomplete="username" required autofocus>
+<label for="password">Password</label>
+<input type="password" id="password" name="password" autocomplete="current-password" required>
+{{end}}
+<button type="submit">Continue</button>
+{{if and .Providers (not .MFAToken)}}
+<p>or</p>
+{{range .Providers}}<p><a href="{{.URL}}">Sign in with {{.DisplayName}}</a></p>{{end}}
+{{end}}
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type loginPageData struct {
+	Request  *services.AuthorizeRequest
+	Email    string
+	MFAToken string
+	Error    string
+	// Fatal shows only the error, for requests that can not continue
+	Fatal bool
+	// Providers are the upstream identity providers offered instead of a
+	// password
+	Providers []loginProvider
+}
+
+type loginProvider struct {
+	DisplayName string
+	URL         string
+}
+
+// loginProviders links the identity providers to their sign-in endpoint,
+// carrying the authorization request along.
+func loginProviders(providers []services.IdentityProviderResponse, req *services.AuthorizeRequest) []loginProvider {
+	if len(providers) == 0 {
+		return nil
+	}
+
+	params := url.Values{}
+	params.Set("response_type", req.ResponseType)
+	params.Set("client_id", req.ClientID)
+	params.Set("redirect_uri", req.RedirectURI)
+	params.Set("scope", req.Scope)
+	params.Set("state", req.State)
+	params.Set("nonce", req.Nonce)
+	params.Set("code_challenge", req.CodeChallenge)
+	params.Set("code_challenge_method", req.CodeChallengeMethod)
+
+	links := make([]loginProvider, 0, len(providers))
+	for _, provider := range providers {
+		links = append(links, loginProvider{
+			DisplayName: provider.DisplayName,
+			URL:         "/api/v1/auth/federated/" + url.PathEscape(provider.Name) + "/login?" + params.Encode(),
+		})
+	}
+	return links
+}
+
+// renderLoginPage writes the sign-in page. It must not be framed or cached.
+func renderLoginPage(c *gin.Context, status int, data *loginPageData) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	c.Status(status)
+	if err := loginPage.Execute(c.Writer, data); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// renderAuthorizeError shows an error that can not be sent to the client's
+// redirect URI.
+func renderAuthorizeError(c *gin.Context, status int, message string) {
+	renderLoginPage(c, status, &loginPageData{Error: message, Fatal: true})
+}
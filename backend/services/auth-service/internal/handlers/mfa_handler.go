@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaService services.IMFAService
+}
+
+func NewMFAHandler(mfaService services.IMFAService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService}
+}
+
+// Status godoc
+// @Summary Get MFA status
+// @Description Report whether MFA is enabled for the current user and how many recovery codes are left
+// @Tags mfa
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} services.MFAStatusResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/mfa [get]
+func (h *MFAHandler) Status(c *gin.Context) {
+	status, err := h.mfaService.Status(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch MFA status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Setup godoc
+// @Summary Start TOTP enrollment
+// @Description Generate a new TOTP secret and its otpauth:// provisioning URI, to be shown as a QR code. MFA is enforced once a first code is confirmed.
+// @Tags mfa
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} services.MFASetupResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Router /auth/mfa/setup [post]
+func (h *MFAHandler) Setup(c *gin.Context) {
+	setup, err := h.mfaService.Setup(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// Enable godoc
+// @Summary Enable MFA
+// @Description Confirm enrollment with a code from the authenticator. Returns the recovery codes, which are only shown once.
+// @Tags mfa
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   code body services.MFACodeRequest true "TOTP code"
+// @Success 200 {object} services.RecoveryCodesResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Router /auth/mfa/enable [post]
+func (h *MFAHandler) Enable(c *gin.Context) {
+	var req services.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.mfaService.Enable(c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// Disable godoc
+// @Summary Disable MFA
+// @Description Remove the TOTP factor and recovery codes. Requires the password and a TOTP or recovery code.
+// @Tags mfa
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   request body services.DisableMFARequest true "Password and code"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Failure 429 {object} handlers.GenericErrorResponse
+// @Router /auth/mfa/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var req services.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ClientIP = c.ClientIP()
+
+	if err := h.mfaService.Disable(c.GetUint("user_id"), &req); err != nil {
+		if throttledError(c, err) {
+			return
+		}
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes. Requires a TOTP or recovery code.
+// @Tags mfa
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   code body services.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} services.RecoveryCodesResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req services.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func mfaErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidMFACode) || errors.Is(err, services.ErrInvalidCredentials) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusBadRequest
+}
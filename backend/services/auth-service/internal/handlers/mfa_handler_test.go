@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/services"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMFAService is a mock of IMFAService
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) Status(userID uint) (*services.MFAStatusResponse, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.MFAStatusResponse), args.Error(1)
+}
+
+func (m *MockMFAService) Setup(userID uint) (*services.MFASetupResponse, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.MFASetupResponse), args.Error(1)
+}
+
+func (m *MockMFAService) Enable(userID uint, req *services.MFACodeRequest) (*services.RecoveryCodesResponse, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RecoveryCodesResponse), args.Error(1)
+}
+
+func (m *MockMFAService) Disable(userID uint, req *services.DisableMFARequest) error {
+	args := m.Called(userID, req)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) RegenerateRecoveryCodes(userID uint, req *services.MFACodeRequest) (*services.RecoveryCodesResponse, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RecoveryCodesResponse), args.Error(1)
+}
+
+func TestMFAHandler_Setup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockMFAService := new(MockMFAService)
+		mfaHandler := NewMFAHandler(mockMFAService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/mfa/setup", nil)
+
+		mockSetup := &services.MFASetupResponse{
+			Secret:          "JBSWY3DPEHPK3PXP",
+			ProvisioningURI: "otpauth://totp/auth-service:test@example.com?secret=JBSWY3DPEHPK3PXP",
+		}
+		mockMFAService.On("Setup", uint(1)).Return(mockSetup, nil)
+
+		mfaHandler.Setup(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp services.MFASetupResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, mockSetup, &resp)
+		mockMFAService.AssertExpectations(t)
+	})
+}
+
+func TestMFAHandler_Enable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockMFAService := new(MockMFAService)
+		mfaHandler := NewMFAHandler(mockMFAService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+
+		reqBody := &services.MFACodeRequest{Code: "123456"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/mfa/enable", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockCodes := &services.RecoveryCodesResponse{RecoveryCodes: []string{"abcd-efgh-ijkl-mnop"}}
+		mockMFAService.On("Enable", uint(1), reqBody).Return(mockCodes, nil)
+
+		mfaHandler.Enable(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockMFAService.AssertExpectations(t)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		mockMFAService := new(MockMFAService)
+		mfaHandler := NewMFAHandler(mockMFAService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+
+		reqBody := &services.MFACodeRequest{Code: "000000"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/mfa/enable", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockMFAService.On("Enable", uint(1), reqBody).Return(nil, services.ErrInvalidMFACode)
+
+		mfaHandler.Enable(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockMFAService.AssertExpectations(t)
+	})
+}
+
+func TestMFAHandler_Disable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("wrong password", func(t *testing.T) {
+		mockMFAService := new(MockMFAService)
+		mfaHandler := NewMFAHandler(mockMFAService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+
+		reqBody := &services.DisableMFARequest{Password: "wrong", Code: "123456"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/mfa/disable", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockMFAService.On("Disable", uint(1), reqBody).Return(services.ErrInvalidCredentials)
+
+		mfaHandler.Disable(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockMFAService.AssertExpectations(t)
+	})
+
+	t.Run("locked out", func(t *testing.T) {
+		mockMFAService := new(MockMFAService)
+		mfaHandler := NewMFAHandler(mockMFAService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+
+		reqBody := &services.DisableMFARequest{Password: "guess", Code: "123456"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/mfa/disable", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockMFAService.On("Disable", uint(1), reqBody).Return(&auth.ThrottledError{RetryAfter: time.Minute})
+
+		mfaHandler.Disable(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	})
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthHandler struct {
+	oauthService services.IOAuthService
+	authService  services.IAuthService
+	oidcService  services.IOIDCService
+}
+
+func NewOAuthHandler(oauthService services.IOAuthService, authService services.IAuthService, oidcService services.IOIDCService) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+		authService:  authService,
+		oidcService:  oidcService,
+	}
+}
+
+// Token godoc
+// @Summary OAuth2 token endpoint
+// @Description Issue tokens with the client_credentials (RFC 6749 section 4.4), authorization_code (with PKCE) or refresh_token grant. Confidential clients authenticate with HTTP Basic or with client_id and client_secret form parameters; public clients only send client_id.
+// @Tags oauth
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param   grant_type formData string true "client_credentials, authorization_code or refresh_token"
+// @Param   scope formData string false "client_credentials: space separated scopes, defaults to all scopes allowed for the client"
+// @Param   code formData string false "authorization_code: the authorization code"
+// @Param   redirect_uri formData string false "authorization_code: the redirect URI of the authorization request"
+// @Param   code_verifier formData string false "authorization_code: the PKCE code verifier"
+// @Param   refresh_token formData string false "refresh_token: the refresh token"
+// @Param   client_id formData string false "Client ID, if not sent with HTTP Basic"
+// @Param   client_secret formData string false "Client secret, if not sent with HTTP Basic"
+// @Success 200 {object} services.TokenResponse
+// @Failure 400 {object} handlers.OAuthErrorResponse
+// @Failure 401 {object} handlers.OAuthErrorResponse
+// @Router /oauth/token [post]
+func (h *OAuthHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	clientID, clientSecret, basic, err := clientCredentials(c)
+	if err != nil {
+		oauthError(c, err, basic)
+		return
+	}
+
+	var resp *services.TokenResponse
+	switch grantType := c.PostForm("grant_type"); grantType {
+	case "client_credentials":
+		resp, err = h.oauthService.ClientCredentials(&services.ClientCredentialsRequest{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scope:        c.PostForm("scope"),
+		})
+	case "authorization_code":
+		resp, err = h.oidcService.ExchangeCode(&services.CodeExchangeRequest{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Code:         c.PostForm("code"),
+			RedirectURI:  c.PostForm("redirect_uri"),
+			CodeVerifier: c.PostForm("code_verifier"),
+		})
+	case "refresh_token":
+		resp, err = h.oidcService.RefreshToken(&services.RefreshGrantRequest{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RefreshToken: c.PostForm("refresh_token"),
+		})
+	case "":
+		err = &services.OAuthError{Code: services.OAuthErrorInvalidRequest, Description: "grant_type is required"}
+	default:
+		err = &services.OAuthError{Code: services.OAuthErrorUnsupportedGrantType, Description: "unsupported grant type: " + grantType}
+	}
+	if err != nil {
+		oauthError(c, err, basic)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Introspect godoc
+// @Summary OAuth2 token introspection
+// @Description Report whether an access or refresh token is active (RFC 7662). The caller authenticates as an OAuth2 client, with HTTP Basic or client_id and client_secret form parameters, and needs the tokens:introspect scope.
+// @Tags oauth
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param   token formData string true "Token to introspect"
+// @Param   token_type_hint formData string false "access_token or refresh_token"
+// @Param   client_id formData string false "Client ID, if not sent with HTTP Basic"
+// @Param   client_secret formData string false "Client secret, if not sent with HTTP Basic"
+// @Success 200 {object} services.IntrospectionResponse
+// @Failure 400 {object} handlers.OAuthErrorResponse
+// @Failure 401 {object} handlers.OAuthErrorResponse
+// @Failure 403 {object} handlers.OAuthErrorResponse
+// @Router /oauth/introspect [post]
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	clientID, clientSecret, basic, err := clientCredentials(c)
+	if err != nil {
+		oauthError(c, err, basic)
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		oauthError(c, &services.OAuthError{Code: services.OAuthErrorInvalidRequest, Description: "token is required"}, basic)
+		return
+	}
+
+	resp, err := h.authService.Introspect(&services.IntrospectionRequest{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		Token:         token,
+		TokenTypeHint: c.PostForm("token_type_hint"),
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrIntrospectionNotAllowed) {
+			c.JSON(http.StatusForbidden, OAuthErrorResponse{Error: services.OAuthErrorUnauthorizedClient, ErrorDescription: err.Error()})
+			return
+		}
+		oauthError(c, err, basic)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListClients godoc
+// @Summary List OAuth2 clients
+// @Description List all OAuth2 clients
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.OAuthClient
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Router /admin/oauth-clients [get]
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	clients, err := h.oauthService.ListClients()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch clients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"clients": clients})
+}
+
+// GetClient godoc
+// @Summary Get an OAuth2 client
+// @Description Get an OAuth2 client by ID
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "Client ID"
+// @Success 200 {object} models.OAuthClient
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/oauth-clients/{id} [get]
+func (h *OAuthHandler) GetClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	client, err := h.oauthService.GetClient(uint(id))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, client)
+}
+
+// CreateClient godoc
+// @Summary Create an OAuth2 client
+// @Description Register a client for the client_credentials grant. The generated client_secret is only returned once.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   client body services.CreateOAuthClientRequest true "Client"
+// @Success 201 {object} services.OAuthClientSecretResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Router /admin/oauth-clients [post]
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	var req services.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.oauthService.CreateClient(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateClient godoc
+// @Summary Update an OAuth2 client
+// @Description Change the name, description, allowed scopes or active flag of a client. Tokens of a deactivated client are rejected immediately.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "Client ID"
+// @Param   client body services.UpdateOAuthClientRequest true "Client"
+// @Success 200 {object} models.OAuthClient
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/oauth-clients/{id} [put]
+func (h *OAuthHandler) UpdateClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	var req services.UpdateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, err := h.oauthService.UpdateClient(uint(id), &req)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, client)
+}
+
+// RotateSecret godoc
+// @Summary Rotate an OAuth2 client secret
+// @Description Replace the client secret. The new secret is only returned once.
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "Client ID"
+// @Success 200 {object} services.OAuthClientSecretResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/oauth-clients/{id}/secret [post]
+func (h *OAuthHandler) RotateSecret(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	resp, err := h.oauthService.RotateSecret(uint(id))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteClient godoc
+// @Summary Delete an OAuth2 client
+// @Description Delete a client. Its outstanding tokens are rejected immediately.
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "Client ID"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/oauth-clients/{id} [delete]
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	if err := h.oauthService.DeleteClient(uint(id)); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "client deleted successfully"})
+}
+
+// clientCredentials reads the client credentials from HTTP Basic or, if
+// absent, from the client_id and client_secret form parameters. basic
+// reports which one was used.
+func clientCredentials(c *gin.Context) (clientID, clientSecret string, basic bool, err error) {
+	clientID, clientSecret, basic = c.Request.BasicAuth()
+	if !basic {
+		return c.PostForm("client_id"), c.PostForm("client_secret"), false, nil
+	}
+
+	// RFC 6749 section 2.3.1: credentials are form-encoded before Basic encoding
+	malformed := &services.OAuthError{Code: services.OAuthErrorInvalidRequest, Description: "malformed client credentials"}
+	if clientID, err = url.QueryUnescape(clientID); err != nil {
+		return "", "", true, malformed
+	}
+	if clientSecret, err = url.QueryUnescape(clientSecret); err != nil {
+		return "", "", true, malformed
+	}
+	return clientID, clientSecret, true, nil
+}
+
+// oauthError writes an RFC 6749 error response. Failed client authentication
+// is 401, with a Basic challenge if the client used Basic authentication.
+func oauthError(c *gin.Context, err error, basic bool) {
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) {
+		c.JSON(http.StatusInternalServerError, OAuthErrorResponse{Error: "server_error", ErrorDescription: "internal server error"})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == services.OAuthErrorInvalidClient {
+		status = http.StatusUnauthorized
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+	}
+
+	c.JSON(status, OAuthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+}
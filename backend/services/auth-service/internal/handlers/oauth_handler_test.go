@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOAuthService is a mock of IOAuthService
+type MockOAuthService struct {
+	mock.Mock
+}
+
+func (m *MockOAuthService) ListClients() ([]*models.OAuthClient, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthService) GetClient(id uint) (*models.OAuthClient, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthService) CreateClient(req *services.CreateOAuthClientRequest) (*services.OAuthClientSecretResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.OAuthClientSecretResponse), args.Error(1)
+}
+
+func (m *MockOAuthService) UpdateClient(id uint, req *services.UpdateOAuthClientRequest) (*models.OAuthClient, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthService) RotateSecret(id uint) (*services.OAuthClientSecretResponse, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.OAuthClientSecretResponse), args.Error(1)
+}
+
+func (m *MockOAuthService) DeleteClient(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockOAuthService) ClientCredentials(req *services.ClientCredentialsRequest) (*services.TokenResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenResponse), args.Error(1)
+}
+
+func newTokenRequest(form url.Values) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestOAuthHandler_Token(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("basic authentication", func(t *testing.T) {
+		mockService := new(MockOAuthService)
+		handler := NewOAuthHandler(mockService, new(MockAuthService), new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{"grant_type": {"client_credentials"}, "scope": {"orders:read:any"}})
+		c.Request.SetBasicAuth("reporting", "s3cret%2F")
+
+		expectedReq := &services.ClientCredentialsRequest{ClientID: "reporting", ClientSecret: "s3cret/", Scope: "orders:read:any"}
+		mockResp := &services.TokenResponse{AccessToken: "jwt", TokenType: "Bearer", ExpiresIn: 3600, Scope: "orders:read:any"}
+		mockService.On("ClientCredentials", expectedReq).Return(mockResp, nil)
+
+		handler.Token(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		var resp services.TokenResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, "jwt", resp.AccessToken)
+		assert.Equal(t, int64(3600), resp.ExpiresIn)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("form credentials", func(t *testing.T) {
+		mockService := new(MockOAuthService)
+		handler := NewOAuthHandler(mockService, new(MockAuthService), new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {"reporting"},
+			"client_secret": {"s3cret"},
+		})
+
+		expectedReq := &services.ClientCredentialsRequest{ClientID: "reporting", ClientSecret: "s3cret"}
+		mockService.On("ClientCredentials", expectedReq).Return(&services.TokenResponse{AccessToken: "jwt", TokenType: "Bearer"}, nil)
+
+		handler.Token(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid client", func(t *testing.T) {
+		mockService := new(MockOAuthService)
+		handler := NewOAuthHandler(mockService, new(MockAuthService), new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{"grant_type": {"client_credentials"}})
+		c.Request.SetBasicAuth("reporting", "wrong")
+
+		mockService.On("ClientCredentials", mock.Anything).Return(nil, services.ErrInvalidClient)
+
+		handler.Token(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+		var resp OAuthErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, "invalid_client", resp.Error)
+	})
+
+	t.Run("invalid scope", func(t *testing.T) {
+		mockService := new(MockOAuthService)
+		handler := NewOAuthHandler(mockService, new(MockAuthService), new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{"grant_type": {"client_credentials"}, "scope": {"users:write"}})
+		c.Request.SetBasicAuth("reporting", "s3cret")
+
+		scopeErr := &services.OAuthError{Code: services.OAuthErrorInvalidScope, Description: "scope not allowed for client: users:write"}
+		mockService.On("ClientCredentials", mock.Anything).Return(nil, scopeErr)
+
+		handler.Token(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_scope")
+	})
+
+	t.Run("unsupported grant type", func(t *testing.T) {
+		mockService := new(MockOAuthService)
+		handler := NewOAuthHandler(mockService, new(MockAuthService), new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{"grant_type": {"password"}})
+
+		handler.Token(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "unsupported_grant_type")
+		mockService.AssertNotCalled(t, "ClientCredentials", mock.Anything)
+	})
+
+	t.Run("authorization code", func(t *testing.T) {
+		mockOIDC := new(MockOIDCService)
+		handler := NewOAuthHandler(new(MockOAuthService), new(MockAuthService), mockOIDC)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{
+			"grant_type":    {"authorization_code"},
+			"client_id":     {"web"},
+			"code":          {"abc"},
+			"redirect_uri":  {"https://app.example.com/callback"},
+			"code_verifier": {"verifier"},
+		})
+
+		expectedReq := &services.CodeExchangeRequest{
+			ClientID:     "web",
+			Code:         "abc",
+			RedirectURI:  "https://app.example.com/callback",
+			CodeVerifier: "verifier",
+		}
+		mockResp := &services.TokenResponse{AccessToken: "jwt", TokenType: "Bearer", RefreshToken: "refresh", IDToken: "id"}
+		mockOIDC.On("ExchangeCode", expectedReq).Return(mockResp, nil)
+
+		handler.Token(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp services.TokenResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, "id", resp.IDToken)
+		assert.Equal(t, "refresh", resp.RefreshToken)
+		mockOIDC.AssertExpectations(t)
+	})
+
+	t.Run("invalid authorization code", func(t *testing.T) {
+		mockOIDC := new(MockOIDCService)
+		handler := NewOAuthHandler(new(MockOAuthService), new(MockAuthService), mockOIDC)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{"grant_type": {"authorization_code"}, "client_id": {"web"}, "code": {"used"}})
+
+		grantErr := &services.OAuthError{Code: services.OAuthErrorInvalidGrant, Description: "invalid, expired or already used authorization code"}
+		mockOIDC.On("ExchangeCode", mock.Anything).Return(nil, grantErr)
+
+		handler.Token(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_grant")
+	})
+
+	t.Run("refresh token of another client", func(t *testing.T) {
+		mockOIDC := new(MockOIDCService)
+		handler := NewOAuthHandler(new(MockOAuthService), new(MockAuthService), mockOIDC)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {"mobile"},
+			"refresh_token": {"issued-to-web"},
+		})
+
+		expectedReq := &services.RefreshGrantRequest{ClientID: "mobile", RefreshToken: "issued-to-web"}
+		grantErr := &services.OAuthError{Code: services.OAuthErrorInvalidGrant, Description: "refresh token was not issued to this client"}
+		mockOIDC.On("RefreshToken", expectedReq).Return(nil, grantErr)
+
+		handler.Token(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var resp OAuthErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, "invalid_grant", resp.Error)
+		assert.NotContains(t, w.Body.String(), "access_token")
+		mockOIDC.AssertExpectations(t)
+	})
+}
+
+func TestOAuthHandler_Introspect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("active token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		handler := NewOAuthHandler(new(MockOAuthService), mockAuthService, new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{"token": {"some-jwt-token"}, "token_type_hint": {"access_token"}})
+		c.Request.SetBasicAuth("gateway", "s3cret")
+
+		expectedReq := &services.IntrospectionRequest{ClientID: "gateway", ClientSecret: "s3cret", Token: "some-jwt-token", TokenTypeHint: "access_token"}
+		mockResp := &services.IntrospectionResponse{Active: true, Sub: "1", UserID: 1, Username: "test@example.com", Exp: 1700000000}
+		mockAuthService.On("Introspect", expectedReq).Return(mockResp, nil)
+
+		handler.Introspect(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, true, resp["active"])
+		assert.Equal(t, "1", resp["sub"])
+		assert.Equal(t, "test@example.com", resp["username"])
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("inactive token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		handler := NewOAuthHandler(new(MockOAuthService), mockAuthService, new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{"token": {"expired"}})
+		c.Request.SetBasicAuth("gateway", "s3cret")
+
+		mockAuthService.On("Introspect", mock.Anything).Return(&services.IntrospectionResponse{Active: false}, nil)
+
+		handler.Introspect(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"active":false}`, w.Body.String())
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		handler := NewOAuthHandler(new(MockOAuthService), mockAuthService, new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{})
+		c.Request.SetBasicAuth("gateway", "s3cret")
+
+		handler.Introspect(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockAuthService.AssertNotCalled(t, "Introspect", mock.Anything)
+	})
+
+	t.Run("client without introspection permission", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		handler := NewOAuthHandler(new(MockOAuthService), mockAuthService, new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{"token": {"some-jwt-token"}, "client_id": {"reporting"}, "client_secret": {"s3cret"}})
+
+		mockAuthService.On("Introspect", mock.Anything).Return(nil, services.ErrIntrospectionNotAllowed)
+
+		handler.Introspect(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "unauthorized_client")
+	})
+
+	t.Run("invalid client", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		handler := NewOAuthHandler(new(MockOAuthService), mockAuthService, new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newTokenRequest(url.Values{"token": {"some-jwt-token"}})
+		c.Request.SetBasicAuth("gateway", "wrong")
+
+		mockAuthService.On("Introspect", mock.Anything).Return(nil, services.ErrInvalidClient)
+
+		handler.Introspect(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_client")
+	})
+}
+
+func TestOAuthHandler_CreateClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockOAuthService)
+		handler := NewOAuthHandler(mockService, new(MockAuthService), new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body, _ := json.Marshal(gin.H{"name": "reporting", "scopes": []string{"orders:read:any"}})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/oauth-clients", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		expectedReq := &services.CreateOAuthClientRequest{Name: "reporting", Scopes: []string{"orders:read:any"}}
+		mockResp := &services.OAuthClientSecretResponse{
+			Client:       &models.OAuthClient{ID: 1, ClientID: "abc", Name: "reporting", Scopes: "orders:read:any", IsActive: true},
+			ClientSecret: "s3cret",
+		}
+		mockService.On("CreateClient", expectedReq).Return(mockResp, nil)
+
+		handler.CreateClient(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"client_secret":"s3cret"`)
+		assert.NotContains(t, w.Body.String(), "secret_hash")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		mockService := new(MockOAuthService)
+		handler := NewOAuthHandler(mockService, new(MockAuthService), new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/oauth-clients", bytes.NewBufferString(`{}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.CreateClient(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestOAuthHandler_DeleteClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := new(MockOAuthService)
+		handler := NewOAuthHandler(mockService, new(MockAuthService), new(MockOIDCService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/admin/oauth-clients/7", nil)
+		c.Params = gin.Params{{Key: "id", Value: "7"}}
+
+		mockService.On("DeleteClient", uint(7)).Return(services.ErrClientNotFound)
+
+		handler.DeleteClient(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	oidcService services.IOIDCService
+	// providers are offered on the sign-in page
+	providers []services.IdentityProviderResponse
+}
+
+func NewOIDCHandler(oidcService services.IOIDCService, providers []services.IdentityProviderResponse) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		providers:   providers,
+	}
+}
+
+// Authorize godoc
+// @Summary OpenID Connect authorization endpoint
+// @Description Start the authorization code flow. Only response_type=code with an S256 PKCE challenge is supported. Shows the sign-in page; after a successful sign-in the browser is redirected to redirect_uri with code and state.
+// @Tags oauth
+// @Produce  html
+// @Param   response_type query string true "code"
+// @Param   client_id query string true "Client ID"
+// @Param   redirect_uri query string true "A redirect URI registered for the client"
+// @Param   scope query string false "openid, profile and email"
+// @Param   state query string false "Opaque value returned to the client"
+// @Param   nonce query string false "Copied into the ID token"
+// @Param   code_challenge query string true "PKCE code challenge"
+// @Param   code_challenge_method query string true "S256"
+// @Success 200 {string} string "Sign-in page"
+// @Failure 302 {string} string "Redirect to redirect_uri with an error"
+// @Failure 400 {string} string "Unknown client or redirect URI"
+// @Router /oauth/authorize [get]
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	var req services.AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		renderAuthorizeError(c, http.StatusBadRequest, "invalid authorization request")
+		return
+	}
+
+	if err := h.oidcService.ValidateAuthorizeRequest(&req); err != nil {
+		authorizeError(c, &req, err)
+		return
+	}
+
+	renderLoginPage(c, http.StatusOK, &loginPageData{Request: &req, Providers: loginProviders(h.providers, &req)})
+}
+
+// AuthorizeLogin godoc
+// @Summary Submit the sign-in page
+// @Description Sign in with email and password, or complete the MFA step. Redirects to redirect_uri with code and state on success and shows the page again otherwise.
+// @Tags oauth
+// @Accept  x-www-form-urlencoded
+// @Produce  html
+// @Param   email formData string false "Email"
+// @Param   password formData string false "Password"
+// @Param   mfa_token formData string false "MFA challenge from the previous step"
+// @Param   code formData string false "TOTP or recovery code"
+// @Success 303 {string} string "Redirect to redirect_uri with code and state"
+// @Failure 401 {string} string "Sign-in page with an error"
+// @Router /oauth/authorize [post]
+func (h *OIDCHandler) AuthorizeLogin(c *gin.Context) {
+	var req services.AuthorizeLoginRequest
+	if err := c.ShouldBind(&req); err != nil {
+		renderAuthorizeError(c, http.StatusBadRequest, "invalid authorization request")
+		return
+	}
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	var result *services.AuthorizeResult
+	var err error
+	if req.MFAToken != "" {
+		result, err = h.oidcService.VerifyMFA(&req)
+	} else {
+		result, err = h.oidcService.Login(&req)
+	}
+
+	if err != nil {
+		var oauthErr *services.OAuthError
+		var throttled *auth.ThrottledError
+		switch {
+		case errors.Is(err, services.ErrInvalidAuthorizeClient), errors.As(err, &oauthErr):
+			authorizeError(c, &req.AuthorizeRequest, err)
+		case errors.As(err, &throttled):
+			renderLoginPage(c, http.StatusTooManyRequests, &loginPageData{
+				Request:   &req.AuthorizeRequest,
+				Email:     req.Email,
+				Error:     "Too many failed sign-in attempts. Please try again later.",
+				Providers: loginProviders(h.providers, &req.AuthorizeRequest),
+			})
+		case req.MFAToken != "" && errors.Is(err, services.ErrInvalidMFACode):
+			renderLoginPage(c, http.StatusUnauthorized, &loginPageData{
+				Request:  &req.AuthorizeRequest,
+				MFAToken: req.MFAToken,
+				Error:    "Invalid code.",
+			})
+		default:
+			renderLoginPage(c, http.StatusUnauthorized, &loginPageData{
+				Request:   &req.AuthorizeRequest,
+				Email:     req.Email,
+				Error:     "Sign-in failed: " + err.Error() + ".",
+				Providers: loginProviders(h.providers, &req.AuthorizeRequest),
+			})
+		}
+		return
+	}
+
+	if result.MFAToken != "" {
+		renderLoginPage(c, http.StatusOK, &loginPageData{
+			Request:  &req.AuthorizeRequest,
+			MFAToken: result.MFAToken,
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, result.RedirectURL)
+}
+
+// UserInfo godoc
+// @Summary OpenID Connect UserInfo endpoint
+// @Description Claims about the user the access token was issued to
+// @Tags oauth
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} services.UserInfoResponse
+// @Failure 401 {object} handlers.OAuthErrorResponse
+// @Router /oauth/userinfo [get]
+func (h *OIDCHandler) UserInfo(c *gin.Context) {
+	tokenString := bearerToken(c)
+	if tokenString == "" {
+		c.Header("WWW-Authenticate", `Bearer`)
+		c.JSON(http.StatusUnauthorized, OAuthErrorResponse{Error: "invalid_request", ErrorDescription: "access token required"})
+		return
+	}
+
+	info, err := h.oidcService.UserInfo(tokenString)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, OAuthErrorResponse{Error: "invalid_token", ErrorDescription: err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, info)
+}
+
+// authorizeError reports a failed authorization request: to the client's
+// redirect URI if it was verified, on the page otherwise.
+func authorizeError(c *gin.Context, req *services.AuthorizeRequest, err error) {
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) {
+		if errors.Is(err, services.ErrInvalidAuthorizeClient) {
+			renderAuthorizeError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		renderAuthorizeError(c, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	params := url.Values{}
+	params.Set("error", oauthErr.Code)
+	params.Set("error_description", oauthErr.Description)
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	c.Redirect(http.StatusFound, services.AppendQuery(req.RedirectURI, params))
+}
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOIDCService is a mock of IOIDCService
+type MockOIDCService struct {
+	mock.Mock
+}
+
+func (m *MockOIDCService) ValidateAuthorizeRequest(req *services.AuthorizeRequest) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+
+func (m *MockOIDCService) Login(req *services.AuthorizeLoginRequest) (*services.AuthorizeResult, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AuthorizeResult), args.Error(1)
+}
+
+func (m *MockOIDCService) VerifyMFA(req *services.AuthorizeLoginRequest) (*services.AuthorizeResult, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AuthorizeResult), args.Error(1)
+}
+
+func (m *MockOIDCService) ExchangeCode(req *services.CodeExchangeRequest) (*services.TokenResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenResponse), args.Error(1)
+}
+
+func (m *MockOIDCService) RefreshToken(req *services.RefreshGrantRequest) (*services.TokenResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenResponse), args.Error(1)
+}
+
+func (m *MockOIDCService) UserInfo(tokenString string) (*services.UserInfoResponse, error) {
+	args := m.Called(tokenString)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.UserInfoResponse), args.Error(1)
+}
+
+func authorizeParams() url.Values {
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"web"},
+		"redirect_uri":          {"https://app.example.com/callback"},
+		"scope":                 {"openid email"},
+		"state":                 {"xyz"},
+		"code_challenge":        {"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+func newAuthorizeForm(form url.Values) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "/oauth/authorize", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestOIDCHandler_Authorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("renders login page", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/oauth/authorize?"+authorizeParams().Encode(), nil)
+
+		mockService.On("ValidateAuthorizeRequest", mock.MatchedBy(func(req *services.AuthorizeRequest) bool {
+			return req.ClientID == "web" && req.State == "xyz" && req.CodeChallengeMethod == "S256"
+		})).Return(nil)
+
+		handler.Authorize(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+		assert.Contains(t, w.Body.String(), `name="password"`)
+		assert.Contains(t, w.Body.String(), `name="state" value="xyz"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("offers identity providers", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, []services.IdentityProviderResponse{{Name: "corp", DisplayName: "Corporate SSO"}})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/oauth/authorize?"+authorizeParams().Encode(), nil)
+
+		mockService.On("ValidateAuthorizeRequest", mock.Anything).Return(nil)
+
+		handler.Authorize(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Sign in with Corporate SSO")
+		assert.Contains(t, w.Body.String(), "/api/v1/auth/federated/corp/login?")
+	})
+
+	t.Run("unregistered redirect uri", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/oauth/authorize?"+authorizeParams().Encode(), nil)
+
+		mockService.On("ValidateAuthorizeRequest", mock.Anything).Return(services.ErrInvalidAuthorizeClient)
+
+		handler.Authorize(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, w.Header().Get("Location"))
+		assert.Contains(t, w.Body.String(), "unregistered redirect_uri")
+	})
+
+	t.Run("error redirected to client", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/oauth/authorize?"+authorizeParams().Encode(), nil)
+
+		mockService.On("ValidateAuthorizeRequest", mock.Anything).
+			Return(&services.OAuthError{Code: services.OAuthErrorInvalidRequest, Description: "code_challenge is required"})
+
+		handler.Authorize(c)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		location, err := url.Parse(w.Header().Get("Location"))
+		assert.NoError(t, err)
+		assert.Equal(t, "app.example.com", location.Host)
+		assert.Equal(t, "invalid_request", location.Query().Get("error"))
+		assert.Equal(t, "xyz", location.Query().Get("state"))
+	})
+}
+
+func TestOIDCHandler_AuthorizeLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		form := authorizeParams()
+		form.Set("email", "john.doe@example.com")
+		form.Set("password", "password123")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newAuthorizeForm(form)
+
+		redirect := "https://app.example.com/callback?code=abc&state=xyz"
+		mockService.On("Login", mock.MatchedBy(func(req *services.AuthorizeLoginRequest) bool {
+			return req.Email == "john.doe@example.com" && req.ClientID == "web"
+		})).Return(&services.AuthorizeResult{RedirectURL: redirect}, nil)
+
+		handler.AuthorizeLogin(c)
+		// Redirects to POST requests have no body; flush the status as gin does
+		// after the handler returns.
+		c.Writer.WriteHeaderNow()
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, redirect, w.Header().Get("Location"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		form := authorizeParams()
+		form.Set("email", "john.doe@example.com")
+		form.Set("password", "wrong")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newAuthorizeForm(form)
+
+		mockService.On("Login", mock.Anything).Return(nil, errors.New("invalid credentials"))
+
+		handler.AuthorizeLogin(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid credentials")
+		assert.Contains(t, w.Body.String(), `value="john.doe@example.com"`)
+	})
+
+	t.Run("throttled", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		form := authorizeParams()
+		form.Set("email", "john.doe@example.com")
+		form.Set("password", "wrong")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newAuthorizeForm(form)
+
+		mockService.On("Login", mock.Anything).Return(nil, &auth.ThrottledError{RetryAfter: time.Minute})
+
+		handler.AuthorizeLogin(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("mfa required", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		form := authorizeParams()
+		form.Set("email", "john.doe@example.com")
+		form.Set("password", "password123")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newAuthorizeForm(form)
+
+		mockService.On("Login", mock.Anything).Return(&services.AuthorizeResult{MFAToken: "challenge"}, nil)
+
+		handler.AuthorizeLogin(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `name="mfa_token" value="challenge"`)
+		assert.NotContains(t, w.Body.String(), `name="password"`)
+	})
+
+	t.Run("invalid mfa code", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		form := authorizeParams()
+		form.Set("mfa_token", "challenge")
+		form.Set("code", "000000")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newAuthorizeForm(form)
+
+		mockService.On("VerifyMFA", mock.MatchedBy(func(req *services.AuthorizeLoginRequest) bool {
+			return req.MFAToken == "challenge" && req.Code == "000000"
+		})).Return(nil, services.ErrInvalidMFACode)
+
+		handler.AuthorizeLogin(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), `name="mfa_token" value="challenge"`)
+		mockService.AssertNotCalled(t, "Login", mock.Anything)
+	})
+}
+
+func TestOIDCHandler_UserInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/oauth/userinfo", nil)
+		c.Request.Header.Set("Authorization", "Bearer jwt")
+
+		mockService.On("UserInfo", "jwt").Return(&services.UserInfoResponse{Sub: "1", Email: "john.doe@example.com"}, nil)
+
+		handler.UserInfo(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp services.UserInfoResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, "1", resp.Sub)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		mockService := new(MockOIDCService)
+		handler := NewOIDCHandler(mockService, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/oauth/userinfo", nil)
+		c.Request.Header.Set("Authorization", "Bearer expired")
+
+		mockService.On("UserInfo", "expired").Return(nil, errors.New("token is expired"))
+
+		handler.UserInfo(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "invalid_token")
+	})
+}
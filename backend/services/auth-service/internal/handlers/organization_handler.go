@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationHandler struct {
+	orgService services.IOrganizationService
+}
+
+func NewOrganizationHandler(orgService services.IOrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{orgService: orgService}
+}
+
+// ListMyOrganizations godoc
+// @Summary List my organizations
+// @Description Organizations the current user belongs to, with their role. The organization of the token used for the request is marked active.
+// @Tags auth
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string][]services.MembershipResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/orgs [get]
+func (h *OrganizationHandler) ListMyOrganizations(c *gin.Context) {
+	var activeOrgID uint
+	if claims, ok := c.Get("claims"); ok {
+		activeOrgID = claims.(*auth.Claims).OrgID
+	}
+
+	memberships, err := h.orgService.ListMemberships(c.GetUint("user_id"), activeOrgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch organizations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organizations": memberships})
+}
+
+// SwitchOrganization godoc
+// @Summary Switch the active organization
+// @Description Exchange a refresh token for a new access and refresh token pair active in another organization of the user. The presented refresh token is rotated and the access token issued with it is revoked.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   request body services.SwitchOrganizationRequest true "Organization"
+// @Success 200 {object} services.AuthResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Router /auth/orgs/switch [post]
+func (h *OrganizationHandler) SwitchOrganization(c *gin.Context) {
+	var req services.SwitchOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.orgService.SwitchOrganization(&req)
+	if err != nil {
+		if errors.Is(err, services.ErrNotOrganizationMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListOrganizations godoc
+// @Summary List organizations
+// @Description List all organizations
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Organization
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Router /admin/orgs [get]
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	orgs, err := h.orgService.ListOrganizations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch organizations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organizations": orgs})
+}
+
+// CreateOrganization godoc
+// @Summary Create an organization
+// @Description Create an organization. Members are added separately.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   organization body services.CreateOrganizationRequest true "Organization"
+// @Success 201 {object} models.Organization
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Router /admin/orgs [post]
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req services.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.orgService.CreateOrganization(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// ListMembers godoc
+// @Summary List organization members
+// @Description List the members of an organization with their roles
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "Organization ID"
+// @Success 200 {array} models.OrganizationMember
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/orgs/{id}/members [get]
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	orgID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return
+	}
+
+	members, err := h.orgService.ListMembers(uint(orgID))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// SetMember godoc
+// @Summary Add or update an organization member
+// @Description Add a user to an organization or change their role (member or admin). The change applies to tokens issued afterwards, including refreshed ones.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "Organization ID"
+// @Param   user_id path int true "User ID"
+// @Param   member body services.SetMemberRequest true "Role"
+// @Success 200 {object} models.OrganizationMember
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/orgs/{id}/members/{user_id} [put]
+func (h *OrganizationHandler) SetMember(c *gin.Context) {
+	orgID, userID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	var req services.SetMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.orgService.SetMember(orgID, userID, &req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember godoc
+// @Summary Remove an organization member
+// @Description Remove a user from an organization. Their sessions in it move to their default organization on the next refresh.
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "Organization ID"
+// @Param   user_id path int true "User ID"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/orgs/{id}/members/{user_id} [delete]
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	orgID, userID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.RemoveMember(orgID, userID); err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member removed successfully"})
+}
+
+// memberParams parses the organization and user IDs of a member route,
+// responding with 400 if either is invalid.
+func memberParams(c *gin.Context) (uint, uint, bool) {
+	orgID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return 0, 0, false
+	}
+
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+
+	return uint(orgID), uint(userID), true
+}
+
+func organizationErrorStatus(err error) int {
+	if errors.Is(err, services.ErrOrganizationNotFound) || errors.Is(err, services.ErrNotOrganizationMember) ||
+		errors.Is(err, services.ErrUserNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOrganizationService is a mock of IOrganizationService
+type MockOrganizationService struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationService) ListMemberships(userID, activeOrgID uint) ([]*services.MembershipResponse, error) {
+	args := m.Called(userID, activeOrgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.MembershipResponse), args.Error(1)
+}
+
+func (m *MockOrganizationService) SwitchOrganization(req *services.SwitchOrganizationRequest) (*services.AuthResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AuthResponse), args.Error(1)
+}
+
+func (m *MockOrganizationService) ListOrganizations() ([]*models.Organization, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationService) CreateOrganization(req *services.CreateOrganizationRequest) (*models.Organization, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationService) ListMembers(orgID uint) ([]*models.OrganizationMember, error) {
+	args := m.Called(orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.OrganizationMember), args.Error(1)
+}
+
+func (m *MockOrganizationService) SetMember(orgID, userID uint, req *services.SetMemberRequest) (*models.OrganizationMember, error) {
+	args := m.Called(orgID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OrganizationMember), args.Error(1)
+}
+
+func (m *MockOrganizationService) RemoveMember(orgID, userID uint) error {
+	args := m.Called(orgID, userID)
+	return args.Error(0)
+}
+
+func TestOrganizationHandler_ListMyOrganizations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockOrganizationService)
+	handler := NewOrganizationHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", uint(1))
+	c.Set("claims", &auth.Claims{UserID: 1, OrgID: 2, OrgRole: models.OrgRoleAdmin})
+	c.Request, _ = http.NewRequest(http.MethodGet, "/auth/orgs", nil)
+
+	mockService.On("ListMemberships", uint(1), uint(2)).Return([]*services.MembershipResponse{
+		{OrganizationMember: &models.OrganizationMember{OrganizationID: 2, UserID: 1, Role: models.OrgRoleAdmin}, Active: true},
+	}, nil)
+
+	handler.ListMyOrganizations(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"active":true`)
+	mockService.AssertExpectations(t)
+}
+
+func TestOrganizationHandler_SwitchOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	switchOrg := func(mockService *MockOrganizationService, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/orgs/switch", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		NewOrganizationHandler(mockService).SwitchOrganization(c)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockOrganizationService)
+		mockService.On("SwitchOrganization", &services.SwitchOrganizationRequest{RefreshToken: "refresh", OrgID: 2}).
+			Return(&services.AuthResponse{Token: "token", RefreshToken: "next"}, nil)
+
+		w := switchOrg(mockService, `{"refresh_token": "refresh", "org_id": 2}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"refresh_token":"next"`)
+	})
+
+	t.Run("missing organization", func(t *testing.T) {
+		mockService := new(MockOrganizationService)
+
+		w := switchOrg(mockService, `{"refresh_token": "refresh"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "SwitchOrganization", mock.Anything)
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		mockService := new(MockOrganizationService)
+		mockService.On("SwitchOrganization", mock.Anything).Return(nil, services.ErrNotOrganizationMember)
+
+		assert.Equal(t, http.StatusForbidden, switchOrg(mockService, `{"refresh_token": "refresh", "org_id": 3}`).Code)
+	})
+
+	t.Run("invalid refresh token", func(t *testing.T) {
+		mockService := new(MockOrganizationService)
+		mockService.On("SwitchOrganization", mock.Anything).Return(nil, errors.New("invalid refresh token"))
+
+		assert.Equal(t, http.StatusUnauthorized, switchOrg(mockService, `{"refresh_token": "stale", "org_id": 2}`).Code)
+	})
+}
+
+func TestOrganizationHandler_SetMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockOrganizationService)
+		handler := NewOrganizationHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "2"}, {Key: "user_id", Value: "5"}}
+		c.Request, _ = http.NewRequest(http.MethodPut, "/admin/orgs/2/members/5", bytes.NewBufferString(`{"role": "admin"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockService.On("SetMember", uint(2), uint(5), &services.SetMemberRequest{Role: models.OrgRoleAdmin}).
+			Return(&models.OrganizationMember{OrganizationID: 2, UserID: 5, Role: models.OrgRoleAdmin}, nil)
+
+		handler.SetMember(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		mockService := new(MockOrganizationService)
+		handler := NewOrganizationHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "2"}, {Key: "user_id", Value: "5"}}
+		c.Request, _ = http.NewRequest(http.MethodPut, "/admin/orgs/2/members/5", bytes.NewBufferString(`{"role": "owner"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.SetMember(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "SetMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrganizationHandler_RemoveMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockOrganizationService)
+	handler := NewOrganizationHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "2"}, {Key: "user_id", Value: "9"}}
+	c.Request, _ = http.NewRequest(http.MethodDelete, "/admin/orgs/2/members/9", nil)
+
+	mockService.On("RemoveMember", uint(2), uint(9)).Return(services.ErrNotOrganizationMember)
+
+	handler.RemoveMember(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"archive/zip"
+	"auth-service/internal/services"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandler struct {
+	privacyService services.IPrivacyService
+}
+
+func NewPrivacyHandler(privacyService services.IPrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{privacyService: privacyService}
+}
+
+// ExportData godoc
+// @Summary Export my data
+// @Description Everything stored about the signed-in user: profile, linked identities, sessions, API keys, security events and orders. A ZIP archive with one JSON file per section, or a single JSON document with format=json.
+// @Tags auth
+// @Produce  application/zip
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   format query string false "zip (default) or json"
+// @Success 200 {object} services.DataExport
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Failure 500 {object} handlers.GenericErrorResponse
+// @Router /auth/me/export [get]
+func (h *PrivacyHandler) ExportData(c *gin.Context) {
+	format := c.DefaultQuery("format", "zip")
+	if format != "zip" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be zip or json"})
+		return
+	}
+
+	export, err := h.privacyService.ExportData(c.GetUint("user_id"), requestDevice(c))
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export data"})
+		return
+	}
+
+	if format == "json" {
+		c.Header("Content-Disposition", `attachment; filename="account-data.json"`)
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	archive, err := exportArchive(export)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export data"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="account-data.zip"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// EraseAccount godoc
+// @Summary Erase my account
+// @Description Permanently delete the signed-in user's account and pseudonymize their orders, which are kept for accounting. Returns the erasure record with a signed receipt.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   request body services.EraseAccountRequest true "Current password"
+// @Success 200 {object} models.ErasureRecord
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Failure 429 {object} handlers.GenericErrorResponse
+// @Failure 502 {object} handlers.GenericErrorResponse
+// @Router /auth/me/erase [post]
+func (h *PrivacyHandler) EraseAccount(c *gin.Context) {
+	var req services.EraseAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	record, err := h.privacyService.EraseAccount(c.GetUint("user_id"), &req)
+	if err != nil {
+		if throttledError(c, err) {
+			return
+		}
+		c.JSON(privacyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// EraseUser godoc
+// @Summary Erase a user
+// @Description Permanently delete a user account, including a soft-deleted one, and pseudonymize the user's orders. Returns the erasure record with a signed receipt.
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Success 200 {object} models.ErasureRecord
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Failure 502 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id}/erase [post]
+func (h *PrivacyHandler) EraseUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	record, err := h.privacyService.EraseUser(c.GetUint("user_id"), uint(userID), requestDevice(c))
+	if err != nil {
+		c.JSON(privacyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// VerifyErasureReceipt godoc
+// @Summary Verify an erasure receipt
+// @Description Check that an erasure receipt was issued by this service and not altered, and return the erasure it documents
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   request body services.VerifyErasureReceiptRequest true "Receipt"
+// @Success 200 {object} models.ErasureRecord
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Router /auth/erasure-receipts/verify [post]
+func (h *PrivacyHandler) VerifyErasureReceipt(c *gin.Context) {
+	var req services.VerifyErasureReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.privacyService.VerifyErasureReceipt(&req)
+	if err != nil {
+		c.JSON(privacyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// exportArchive writes each section of the export to its own JSON file in a
+// ZIP archive.
+func exportArchive(export *services.DataExport) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, file := range []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"identities.json", export.Identities},
+		{"sessions.json", export.Sessions},
+		{"api_keys.json", export.APIKeys},
+		{"security_events.json", export.SecurityEvents},
+		{"orders.json", export.Orders},
+	} {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func privacyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrErasureIncomplete):
+		return http.StatusBadGateway
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"archive/zip"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPrivacyService is a mock of IPrivacyService
+type MockPrivacyService struct {
+	mock.Mock
+}
+
+func (m *MockPrivacyService) ExportData(userID uint, device *services.Device) (*services.DataExport, error) {
+	args := m.Called(userID, device)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.DataExport), args.Error(1)
+}
+
+func (m *MockPrivacyService) EraseAccount(userID uint, req *services.EraseAccountRequest) (*models.ErasureRecord, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ErasureRecord), args.Error(1)
+}
+
+func (m *MockPrivacyService) EraseUser(actorID, id uint, device *services.Device) (*models.ErasureRecord, error) {
+	args := m.Called(actorID, id, device)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ErasureRecord), args.Error(1)
+}
+
+func (m *MockPrivacyService) VerifyErasureReceipt(req *services.VerifyErasureReceiptRequest) (*models.ErasureRecord, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ErasureRecord), args.Error(1)
+}
+
+func testDataExport() *services.DataExport {
+	return &services.DataExport{
+		ExportedAt:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Profile:        &models.User{ID: 1, Email: "john.doe@example.com"},
+		SecurityEvents: []*models.AuditEvent{},
+		Orders:         json.RawMessage(`[{"id": 5}]`),
+	}
+}
+
+func TestPrivacyHandler_ExportData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("zip", func(t *testing.T) {
+		mockService := new(MockPrivacyService)
+		handler := NewPrivacyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodGet, "/auth/me/export", nil)
+
+		mockService.On("ExportData", uint(1), mock.Anything).Return(testDataExport(), nil)
+
+		handler.ExportData(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		require.NoError(t, err)
+		files := map[string]*zip.File{}
+		for _, file := range archive.File {
+			files[file.Name] = file
+		}
+		assert.Len(t, files, 6)
+		require.Contains(t, files, "orders.json")
+
+		r, err := files["orders.json"].Open()
+		require.NoError(t, err)
+		defer r.Close()
+		var orders []map[string]interface{}
+		require.NoError(t, json.NewDecoder(r).Decode(&orders))
+		assert.Equal(t, float64(5), orders[0]["id"])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		mockService := new(MockPrivacyService)
+		handler := NewPrivacyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodGet, "/auth/me/export?format=json", nil)
+
+		mockService.On("ExportData", uint(1), mock.Anything).Return(testDataExport(), nil)
+
+		handler.ExportData(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"email":"john.doe@example.com"`)
+		assert.Contains(t, w.Body.String(), `"orders":[{"id":5}]`)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		mockService := new(MockPrivacyService)
+		handler := NewPrivacyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodGet, "/auth/me/export?format=csv", nil)
+
+		handler.ExportData(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ExportData", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPrivacyHandler_EraseAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	erase := func(mockService *MockPrivacyService) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/me/erase", bytes.NewBufferString(`{"password": "password"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		NewPrivacyHandler(mockService).EraseAccount(c)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockPrivacyService)
+		mockService.On("EraseAccount", uint(1), mock.MatchedBy(func(req *services.EraseAccountRequest) bool {
+			return req.Password == "password"
+		})).Return(&models.ErasureRecord{
+			ErasureID: "era_1",
+			UserID:    1,
+			Status:    models.ErasureStatusCompleted,
+			Steps:     "orders account",
+			Receipt:   "signed.receipt.jwt",
+		}, nil)
+
+		w := erase(mockService)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"receipt":"signed.receipt.jwt"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		mockService := new(MockPrivacyService)
+		mockService.On("EraseAccount", uint(1), mock.Anything).Return(nil, services.ErrInvalidCredentials)
+
+		assert.Equal(t, http.StatusForbidden, erase(mockService).Code)
+	})
+
+	t.Run("order service unavailable", func(t *testing.T) {
+		mockService := new(MockPrivacyService)
+		mockService.On("EraseAccount", uint(1), mock.Anything).Return(&models.ErasureRecord{ErasureID: "era_1"},
+			fmt.Errorf("%w (erasure era_1)", services.ErrErasureIncomplete))
+
+		w := erase(mockService)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Contains(t, w.Body.String(), "era_1")
+	})
+}
+
+func TestPrivacyHandler_EraseUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("self", func(t *testing.T) {
+		mockService := new(MockPrivacyService)
+		handler := NewPrivacyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/users/1/erase", nil)
+
+		mockService.On("EraseUser", uint(1), uint(1), mock.Anything).Return(nil, services.ErrSelfModification)
+
+		handler.EraseUser(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := new(MockPrivacyService)
+		handler := NewPrivacyHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", uint(1))
+		c.Params = gin.Params{{Key: "id", Value: "9"}}
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/users/9/erase", nil)
+
+		mockService.On("EraseUser", uint(1), uint(9), mock.Anything).Return(nil, services.ErrUserNotFound)
+
+		handler.EraseUser(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestPrivacyHandler_VerifyErasureReceipt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPrivacyService)
+	handler := NewPrivacyHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/auth/erasure-receipts/verify", bytes.NewBufferString(`{"receipt": "tampered"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("VerifyErasureReceipt", &services.VerifyErasureReceiptRequest{Receipt: "tampered"}).
+		Return(nil, services.ErrInvalidErasureReceipt)
+
+	handler.VerifyErasureReceipt(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	roleService services.IRoleService
+}
+
+func NewRoleHandler(roleService services.IRoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description List all roles with their permissions
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Role
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Router /admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// ListPermissions godoc
+// @Summary List permissions
+// @Description List all permissions that can be granted to roles
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Permission
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Router /admin/permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roleService.ListPermissions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch permissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"permissions": permissions})
+}
+
+// CreateRole godoc
+// @Summary Create a role
+// @Description Create a role with a set of permissions
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   role body services.CreateRoleRequest true "Role"
+// @Success 201 {object} models.Role
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Router /admin/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req services.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.CreateRole(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// SetRolePermissions godoc
+// @Summary Set role permissions
+// @Description Replace the permissions of a role
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "Role ID"
+// @Param   permissions body services.SetRolePermissionsRequest true "Permissions"
+// @Success 200 {object} models.Role
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/roles/{id}/permissions [put]
+func (h *RoleHandler) SetRolePermissions(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+
+	var req services.SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.SetRolePermissions(uint(roleID), &req)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// GetUserRoles godoc
+// @Summary Get user roles
+// @Description Get the roles assigned to a user
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Success 200 {array} models.Role
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id}/roles [get]
+func (h *RoleHandler) GetUserRoles(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	roles, err := h.roleService.GetUserRoles(uint(userID))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// AssignRole godoc
+// @Summary Assign a role to a user
+// @Description Assign a role to a user. The change applies to tokens issued afterwards, including refreshed ones.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Param   role body services.AssignRoleRequest true "Role"
+// @Success 200 {array} models.Role
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id}/roles [post]
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req services.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	roles, err := h.roleService.AssignRole(uint(userID), &req)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// RemoveRole godoc
+// @Summary Remove a role from a user
+// @Description Remove a role from a user
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Param   role path string true "Role name"
+// @Success 200 {array} models.Role
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id}/roles/{role} [delete]
+func (h *RoleHandler) RemoveRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	roles, err := h.roleService.RemoveRole(uint(userID), c.Param("role"))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func adminErrorStatus(err error) int {
+	if errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrRoleNotFound) ||
+		errors.Is(err, services.ErrClientNotFound) || errors.Is(err, services.ErrSessionNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
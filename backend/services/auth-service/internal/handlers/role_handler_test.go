@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRoleService is a mock of IRoleService
+type MockRoleService struct {
+	mock.Mock
+}
+
+func (m *MockRoleService) ListRoles() ([]*models.Role, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Role), args.Error(1)
+}
+
+func (m *MockRoleService) ListPermissions() ([]*models.Permission, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Permission), args.Error(1)
+}
+
+func (m *MockRoleService) CreateRole(req *services.CreateRoleRequest) (*models.Role, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *MockRoleService) SetRolePermissions(roleID uint, req *services.SetRolePermissionsRequest) (*models.Role, error) {
+	args := m.Called(roleID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *MockRoleService) GetUserRoles(userID uint) ([]models.Role, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRoleService) AssignRole(userID uint, req *services.AssignRoleRequest) ([]models.Role, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRoleService) RemoveRole(userID uint, roleName string) ([]models.Role, error) {
+	args := m.Called(userID, roleName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func TestRoleHandler_AssignRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockRoleService := new(MockRoleService)
+		roleHandler := NewRoleHandler(mockRoleService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "2"}}
+
+		reqBody := &services.AssignRoleRequest{Role: models.RoleSupport}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/users/2/roles", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockRoles := []models.Role{{ID: 2, Name: models.RoleSupport}}
+		mockRoleService.On("AssignRole", uint(2), reqBody).Return(mockRoles, nil)
+
+		roleHandler.AssignRole(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Roles []models.Role `json:"roles"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, models.RoleSupport, resp.Roles[0].Name)
+		mockRoleService.AssertExpectations(t)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		mockRoleService := new(MockRoleService)
+		roleHandler := NewRoleHandler(mockRoleService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "2"}}
+
+		reqBody := &services.AssignRoleRequest{Role: "wizard"}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/users/2/roles", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockRoleService.On("AssignRole", uint(2), reqBody).Return(nil, services.ErrRoleNotFound)
+
+		roleHandler.AssignRole(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockRoleService.AssertExpectations(t)
+	})
+}
+
+func TestRoleHandler_CreateRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockRoleService := new(MockRoleService)
+		roleHandler := NewRoleHandler(mockRoleService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		reqBody := &services.CreateRoleRequest{
+			Name:        "auditor",
+			Permissions: []string{models.PermissionOrdersReadAny},
+		}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/roles", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockRole := &models.Role{
+			ID:          3,
+			Name:        "auditor",
+			Permissions: []models.Permission{{ID: 1, Name: models.PermissionOrdersReadAny}},
+		}
+		mockRoleService.On("CreateRole", reqBody).Return(mockRole, nil)
+
+		roleHandler.CreateRole(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockRoleService.AssertExpectations(t)
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		mockRoleService := new(MockRoleService)
+		roleHandler := NewRoleHandler(mockRoleService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/roles", bytes.NewBuffer([]byte("{}")))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		roleHandler.CreateRole(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionService services.ISessionService
+}
+
+func NewSessionHandler(sessionService services.ISessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+// ListSessions godoc
+// @Summary List sessions
+// @Description Active sessions of the current user, most recently used first. The session of the token used for the request is marked current.
+// @Tags auth
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string][]services.SessionResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.sessionService.ListSessions(c.GetUint("user_id"), currentSessionID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Sign the current user out of one session. Its refresh and access tokens are rejected immediately.
+// @Tags auth
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "Session ID"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /auth/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(c.GetUint("user_id"), uint(id)); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revoke all other sessions
+// @Description Sign the current user out everywhere except the session of the token used for the request
+// @Tags auth
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 401 {object} handlers.GenericErrorResponse
+// @Router /auth/sessions [delete]
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	current := currentSessionID(c)
+	if current == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is not bound to a session, log in again"})
+		return
+	}
+
+	if err := h.sessionService.RevokeOtherSessions(c.GetUint("user_id"), current); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "other sessions revoked successfully"})
+}
+
+// ListUserSessions godoc
+// @Summary List sessions of a user
+// @Description Active sessions of any user, most recently used first
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Success 200 {object} map[string][]services.SessionResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id}/sessions [get]
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(uint(userID), "")
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeUserSession godoc
+// @Summary Revoke a session of a user
+// @Description Sign a user out of one session
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Param   session path int true "Session ID"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id}/sessions/{session} [delete]
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("session"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(uint(userID), uint(id)); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke all sessions of a user
+// @Description Sign a user out everywhere
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id}/sessions [delete]
+func (h *SessionHandler) RevokeUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.sessionService.RevokeOtherSessions(uint(userID), ""); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sessions revoked successfully"})
+}
+
+// currentSessionID returns the session of the access token set by
+// AuthMiddleware.
+func currentSessionID(c *gin.Context) string {
+	claims, ok := c.Get("claims")
+	if !ok {
+		return ""
+	}
+	return claims.(*auth.Claims).SessionID
+}
+
+// requestDevice describes where the request came from.
+func requestDevice(c *gin.Context) *services.Device {
+	return &services.Device{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSessionService is a mock of ISessionService
+type MockSessionService struct {
+	mock.Mock
+}
+
+func (m *MockSessionService) ListSessions(userID uint, currentSessionID string) ([]*services.SessionResponse, error) {
+	args := m.Called(userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.SessionResponse), args.Error(1)
+}
+
+func (m *MockSessionService) RevokeSession(userID, id uint) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) RevokeOtherSessions(userID uint, currentSessionID string) error {
+	args := m.Called(userID, currentSessionID)
+	return args.Error(0)
+}
+
+func newSessionContext(method, path string, sessionID string) (*httptest.ResponseRecorder, *gin.Context) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(method, path, nil)
+	c.Set("user_id", uint(7))
+	c.Set("claims", &auth.Claims{UserID: 7, SubjectType: auth.SubjectTypeUser, SessionID: sessionID})
+	return w, c
+}
+
+func TestSessionHandler_ListSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSessionService)
+	handler := NewSessionHandler(mockService)
+
+	w, c := newSessionContext(http.MethodGet, "/auth/sessions", "family-1")
+
+	mockService.On("ListSessions", uint(7), "family-1").Return([]*services.SessionResponse{
+		{Session: &models.Session{ID: 1, UserID: 7, FamilyID: "family-1", UserAgent: "curl/8.0", IPAddress: "10.0.0.1"}, Current: true},
+	}, nil)
+
+	handler.ListSessions(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_agent":"curl/8.0"`)
+	assert.Contains(t, w.Body.String(), `"current":true`)
+	assert.NotContains(t, w.Body.String(), "family-1")
+	mockService.AssertExpectations(t)
+}
+
+func TestSessionHandler_RevokeSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockSessionService)
+		handler := NewSessionHandler(mockService)
+
+		w, c := newSessionContext(http.MethodDelete, "/auth/sessions/3", "family-1")
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+
+		mockService.On("RevokeSession", uint(7), uint(3)).Return(nil)
+
+		handler.RevokeSession(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := new(MockSessionService)
+		handler := NewSessionHandler(mockService)
+
+		w, c := newSessionContext(http.MethodDelete, "/auth/sessions/3", "family-1")
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+
+		mockService.On("RevokeSession", uint(7), uint(3)).Return(services.ErrSessionNotFound)
+
+		handler.RevokeSession(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSessionHandler_RevokeOtherSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("keeps current session", func(t *testing.T) {
+		mockService := new(MockSessionService)
+		handler := NewSessionHandler(mockService)
+
+		w, c := newSessionContext(http.MethodDelete, "/auth/sessions", "family-1")
+
+		mockService.On("RevokeOtherSessions", uint(7), "family-1").Return(nil)
+
+		handler.RevokeOtherSessions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("token without session", func(t *testing.T) {
+		mockService := new(MockSessionService)
+		handler := NewSessionHandler(mockService)
+
+		w, c := newSessionContext(http.MethodDelete, "/auth/sessions", "")
+
+		handler.RevokeOtherSessions(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "RevokeOtherSessions", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSessionHandler_AdminEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("list sessions of user", func(t *testing.T) {
+		mockService := new(MockSessionService)
+		handler := NewSessionHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/users/9/sessions", nil)
+		c.Params = gin.Params{{Key: "id", Value: "9"}}
+
+		mockService.On("ListSessions", uint(9), "").Return([]*services.SessionResponse{}, nil)
+
+		handler.ListUserSessions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockService := new(MockSessionService)
+		handler := NewSessionHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/admin/users/9/sessions", nil)
+		c.Params = gin.Params{{Key: "id", Value: "9"}}
+
+		mockService.On("RevokeOtherSessions", uint(9), "").Return(services.ErrUserNotFound)
+
+		handler.RevokeUserSessions(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("revoke session of user", func(t *testing.T) {
+		mockService := new(MockSessionService)
+		handler := NewSessionHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/admin/users/9/sessions/4", nil)
+		c.Params = gin.Params{{Key: "id", Value: "9"}, {Key: "session", Value: "4"}}
+
+		mockService.On("RevokeSession", uint(9), uint(4)).Return(nil)
+
+		handler.RevokeUserSession(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
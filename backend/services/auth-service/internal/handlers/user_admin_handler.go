@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"auth-service/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserAdminHandler struct {
+	userAdminService services.IUserAdminService
+}
+
+func NewUserAdminHandler(userAdminService services.IUserAdminService) *UserAdminHandler {
+	return &UserAdminHandler{userAdminService: userAdminService}
+}
+
+// ListUsers godoc
+// @Summary Search users
+// @Description Search users by email and name, paginated. Soft-deleted users are only included on request.
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   email query string false "Email contains"
+// @Param   name query string false "First or last name contains"
+// @Param   is_active query bool false "Filter by active flag"
+// @Param   include_deleted query bool false "Include soft-deleted users"
+// @Param   offset query int false "Offset"
+// @Param   limit query int false "Limit (max 100)"
+// @Success 200 {object} services.ListUsersResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Router /admin/users [get]
+func (h *UserAdminHandler) ListUsers(c *gin.Context) {
+	var req services.ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userAdminService.ListUsers(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetUser godoc
+// @Summary Get a user
+// @Description Get a user by ID, including soft-deleted users
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Success 200 {object} services.AdminUser
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id} [get]
+func (h *UserAdminHandler) GetUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	user, err := h.userAdminService.GetUser(uint(userID))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserStatus godoc
+// @Summary Activate or deactivate a user
+// @Description Set the active flag of a user. Deactivating a user revokes all of their tokens.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Param   status body services.SetUserStatusRequest true "Status"
+// @Success 200 {object} services.AdminUser
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id}/status [put]
+func (h *UserAdminHandler) SetUserStatus(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req services.SetUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userAdminService.SetUserActive(c.GetUint("user_id"), uint(userID), *req.IsActive, requestDevice(c))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser godoc
+// @Summary Delete a user
+// @Description Soft-delete a user and revoke all of their tokens
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Success 200 {object} handlers.GenericSuccessResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id} [delete]
+func (h *UserAdminHandler) DeleteUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.userAdminService.DeleteUser(c.GetUint("user_id"), uint(userID), requestDevice(c)); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user deleted successfully"})
+}
+
+// RestoreUser godoc
+// @Summary Restore a deleted user
+// @Description Undo the soft-delete of a user
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   id path int true "User ID"
+// @Success 200 {object} services.AdminUser
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Router /admin/users/{id}/restore [post]
+func (h *UserAdminHandler) RestoreUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	user, err := h.userAdminService.RestoreUser(uint(userID))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
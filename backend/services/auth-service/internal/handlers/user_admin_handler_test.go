@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserAdminService is a mock of IUserAdminService
+type MockUserAdminService struct {
+	mock.Mock
+}
+
+func (m *MockUserAdminService) ListUsers(req *services.ListUsersRequest) (*services.ListUsersResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ListUsersResponse), args.Error(1)
+}
+
+func (m *MockUserAdminService) GetUser(id uint) (*services.AdminUser, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AdminUser), args.Error(1)
+}
+
+func (m *MockUserAdminService) SetUserActive(actorID, id uint, active bool, device *services.Device) (*services.AdminUser, error) {
+	args := m.Called(actorID, id, active, device)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AdminUser), args.Error(1)
+}
+
+func (m *MockUserAdminService) DeleteUser(actorID, id uint, device *services.Device) error {
+	args := m.Called(actorID, id, device)
+	return args.Error(0)
+}
+
+func (m *MockUserAdminService) RestoreUser(id uint) (*services.AdminUser, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AdminUser), args.Error(1)
+}
+
+func TestUserAdminHandler_ListUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockUserAdminService)
+		handler := NewUserAdminHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/users?email=example.com&include_deleted=true&limit=5", nil)
+
+		expectedReq := &services.ListUsersRequest{Email: "example.com", IncludeDeleted: true, Limit: 5}
+		mockResp := &services.ListUsersResponse{
+			Users: []*services.AdminUser{{User: &models.User{ID: 1, Email: "john.doe@example.com"}}},
+			Total: 1,
+			Limit: 5,
+		}
+		mockService.On("ListUsers", expectedReq).Return(mockResp, nil)
+
+		handler.ListUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp services.ListUsersResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), resp.Total)
+		assert.Equal(t, "john.doe@example.com", resp.Users[0].Email)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("limit too large", func(t *testing.T) {
+		mockService := new(MockUserAdminService)
+		handler := NewUserAdminHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/users?limit=1000", nil)
+
+		handler.ListUsers(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestUserAdminHandler_SetUserStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("deactivate", func(t *testing.T) {
+		mockService := new(MockUserAdminService)
+		handler := NewUserAdminHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "2"}}
+		c.Set("user_id", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodPut, "/admin/users/2/status", bytes.NewBufferString(`{"is_active":false}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockUser := &services.AdminUser{User: &models.User{ID: 2, IsActive: false}}
+		mockService.On("SetUserActive", uint(1), uint(2), false, mock.Anything).Return(mockUser, nil)
+
+		handler.SetUserStatus(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing is_active", func(t *testing.T) {
+		mockService := new(MockUserAdminService)
+		handler := NewUserAdminHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "2"}}
+		c.Request, _ = http.NewRequest(http.MethodPut, "/admin/users/2/status", bytes.NewBufferString(`{}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.SetUserStatus(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestUserAdminHandler_DeleteUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := new(MockUserAdminService)
+		handler := NewUserAdminHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "2"}}
+		c.Set("user_id", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/admin/users/2", nil)
+
+		mockService.On("DeleteUser", uint(1), uint(2), mock.Anything).Return(nil)
+
+		handler.DeleteUser(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := new(MockUserAdminService)
+		handler := NewUserAdminHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "99"}}
+		c.Set("user_id", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/admin/users/99", nil)
+
+		mockService.On("DeleteUser", uint(1), uint(99), mock.Anything).Return(services.ErrUserNotFound)
+
+		handler.DeleteUser(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"auth-service/internal/auth"
+	"auth-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSProvider exposes the public keys that verify issued tokens.
+type JWKSProvider interface {
+	JWKS() auth.JWKS
+}
+
+// DiscoveryDocument is the OpenID Connect discovery document, which doubles
+// as OAuth 2.0 authorization server metadata (RFC 8414).
+type DiscoveryDocument struct {
+	Issuer                                    string   `json:"issuer"`
+	AuthorizationEndpoint                     string   `json:"authorization_endpoint"`
+	JWKSURI                                   string   `json:"jwks_uri"`
+	TokenEndpoint                             string   `json:"token_endpoint"`
+	UserInfoEndpoint                          string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint                     string   `json:"introspection_endpoint"`
+	ResponseTypesSupported                    []string `json:"response_types_supported"`
+	GrantTypesSupported                       []string `json:"grant_types_supported"`
+	CodeChallengeMethodsSupported             []string `json:"code_challenge_methods_supported"`
+	ScopesSupported                           []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported         []string `json:"token_endpoint_auth_methods_supported"`
+	IntrospectionEndpointAuthMethodsSupported []string `json:"introspection_endpoint_auth_methods_supported"`
+	SubjectTypesSupported                     []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported          []string `json:"id_token_signing_alg_values_supported"`
+	ClaimsSupported                           []string `json:"claims_supported"`
+}
+
+// NewDiscoveryDocument describes the endpoints served under baseURL, the
+// public URL of auth-service. algorithm is the configured JWT algorithm.
+func NewDiscoveryDocument(issuer, baseURL, algorithm string) *DiscoveryDocument {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if algorithm == "" {
+		algorithm = auth.AlgorithmHS256
+	}
+
+	return &DiscoveryDocument{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             baseURL + "/oauth/authorize",
+		JWKSURI:                           baseURL + "/.well-known/jwks.json",
+		TokenEndpoint:                     baseURL + "/oauth/token",
+		UserInfoEndpoint:                  baseURL + "/oauth/userinfo",
+		IntrospectionEndpoint:             baseURL + "/oauth/introspect",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token", "client_credentials"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ScopesSupported:                   []string{services.ScopeOpenID, services.ScopeProfile, services.ScopeEmail},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		IntrospectionEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+		SubjectTypesSupported:                     []string{"public"},
+		IDTokenSigningAlgValuesSupported:          []string{algorithm},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "nbf", "jti", "auth_time", "nonce",
+			"email", "email_verified", "name", "given_name", "family_name",
+			"user_id", "roles", "scope", "sub_type", "client_id",
+		},
+	}
+}
+
+type WellKnownHandler struct {
+	keys      JWKSProvider
+	discovery *DiscoveryDocument
+}
+
+func NewWellKnownHandler(keys JWKSProvider, discovery *DiscoveryDocument) *WellKnownHandler {
+	return &WellKnownHandler{
+		keys:      keys,
+		discovery: discovery,
+	}
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens offline, including retired keys that are still within their grace period
+// @Tags well-known
+// @Produce  json
+// @Success 200 {object} auth.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *WellKnownHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
+
+// OpenIDConfiguration godoc
+// @Summary OpenID Connect discovery document
+// @Description Issuer, endpoints and supported features of auth-service, for proxies and client libraries that configure themselves from discovery
+// @Tags well-known
+// @Produce  json
+// @Success 200 {object} handlers.DiscoveryDocument
+// @Router /.well-known/openid-configuration [get]
+func (h *WellKnownHandler) OpenIDConfiguration(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, h.discovery)
+}
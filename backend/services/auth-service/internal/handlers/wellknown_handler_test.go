@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"auth-service/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWellKnownHandler_OpenIDConfiguration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwtSvc := auth.NewJWTService("secret", nil, "http://localhost:8080", 1, 1)
+	handler := NewWellKnownHandler(jwtSvc, NewDiscoveryDocument(jwtSvc.Issuer(), "http://localhost:8080/", ""))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+
+	handler.OpenIDConfiguration(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var doc map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080", doc["issuer"])
+	assert.Equal(t, "http://localhost:8080/oauth/token", doc["token_endpoint"])
+	assert.Equal(t, "http://localhost:8080/oauth/introspect", doc["introspection_endpoint"])
+	assert.Equal(t, "http://localhost:8080/.well-known/jwks.json", doc["jwks_uri"])
+	assert.Equal(t, "http://localhost:8080/oauth/authorize", doc["authorization_endpoint"])
+	assert.Equal(t, "http://localhost:8080/oauth/userinfo", doc["userinfo_endpoint"])
+	assert.Equal(t, []interface{}{"S256"}, doc["code_challenge_methods_supported"])
+	assert.Equal(t, []interface{}{"HS256"}, doc["id_token_signing_alg_values_supported"])
+}
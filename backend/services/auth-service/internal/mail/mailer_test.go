@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := NewFileMailer(dir, "no-reply@example.com")
+	require.NoError(t, err)
+
+	err = mailer.Send(&Message{
+		To:      "john.doe@example.com",
+		Subject: "Reset your password",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.True(t, strings.HasSuffix(files[0], "john.doe@example.com.eml"))
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: john.doe@example.com\r\n")
+	assert.Contains(t, string(data), "Subject: Reset your password\r\n")
+	assert.Contains(t, string(data), "line one\r\nline two")
+}
+
+func TestNewMailer_UnknownDriver(t *testing.T) {
+	_, err := NewMailer("pigeon", "no-reply@example.com", "", 0, "", "", "")
+	assert.Error(t, err)
+}
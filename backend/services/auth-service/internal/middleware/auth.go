@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"auth-service/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenValidator validates an access token, including revocation.
+// TokenRejected is told about every token that does not validate, for the
+// audit log.
+type TokenValidator interface {
+	ValidateClaims(tokenString string) (*auth.Claims, error)
+	TokenRejected(ipAddress, userAgent string, err error)
+}
+
+// AuthMiddleware requires a valid bearer token and stores its claims in the
+// context as "claims" and the user ID as "user_id".
+func AuthMiddleware(validator TokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+			c.Abort()
+			return
+		}
+
+		claims, err := validator.ValidateClaims(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			validator.TokenRejected(c.ClientIP(), c.Request.UserAgent(), err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			c.Abort()
+			return
+		}
+
+		if !claims.IsActive {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user account is deactivated"})
+			c.Abort()
+			return
+		}
+
+		c.Set("claims", claims)
+		c.Set("user_id", claims.UserID)
+		c.Next()
+	}
+}
+
+// RequirePermission aborts with 403 unless the token set by AuthMiddleware
+// grants the permission.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.Get("claims")
+		if !ok || !claims.(*auth.Claims).HasScope(permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RejectAPIKeys aborts with 403 when the request was authenticated with an
+// API key. It guards endpoints that manage credentials, so a leaked key can
+// not be used to mint more keys or change the second factor.
+func RejectAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.Get("claims")
+		if ok && claims.(*auth.Claims).IsAPIKey() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API keys can not be used for this endpoint"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
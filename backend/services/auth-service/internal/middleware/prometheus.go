@@ -42,6 +42,28 @@ var (
 		},
 	)
 
+	authLoginFailuresTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "auth_login_failures_total",
+			Help: "Total number of failed login attempts",
+		},
+	)
+
+	authLoginLockoutsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_login_lockouts_total",
+			Help: "Total number of failed logins that locked an email or client IP",
+		},
+		[]string{"scope"},
+	)
+
+	authAuditEventsDroppedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "auth_audit_events_dropped_total",
+			Help: "Total number of audit events dropped because the write queue was full",
+		},
+	)
+
 	authDatabaseQueryDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "auth_database_query_duration_seconds",
@@ -78,6 +100,18 @@ func RecordAuthTokenIssued() {
 	authTokensIssuedTotal.Inc()
 }
 
+func RecordLoginFailure() {
+	authLoginFailuresTotal.Inc()
+}
+
+func RecordLoginLockout(scope string) {
+	authLoginLockoutsTotal.WithLabelValues(scope).Inc()
+}
+
+func RecordAuditEventDropped() {
+	authAuditEventsDroppedTotal.Inc()
+}
+
 func RecordDatabaseQuery(operation string, duration time.Duration) {
 	authDatabaseQueryDuration.WithLabelValues(operation).Observe(duration.Seconds())
 }
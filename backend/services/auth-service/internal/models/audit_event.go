@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Audit event types
+const (
+	AuditEventRegister       = "register"
+	AuditEventLogin          = "login"
+	AuditEventLoginMFA       = "login.mfa"
+	AuditEventIdentityLink   = "identity.link"
+	AuditEventUserProvision  = "user.provision"
+	AuditEventTokenRejected  = "token.rejected"
+	AuditEventPasswordChange = "password.change"
+	AuditEventPasswordReset  = "password.reset"
+	AuditEventUserActivate   = "user.activate"
+	AuditEventUserDeactivate = "user.deactivate"
+	AuditEventUserDelete     = "user.delete"
+	AuditEventUserErase      = "user.erase"
+	AuditEventDataExport     = "user.export"
+)
+
+// Audit event outcomes
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEvent is an entry of the security audit trail. Entries are only ever
+// inserted. The actor is the user who made the request, as far as known:
+// failed logins carry the email that was tried and no actor ID. Subject is
+// the user acted upon when it is not the actor, e.g. a deactivated user.
+// Provider is the identity provider of a federated sign-in.
+type AuditEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Type       string    `json:"type" gorm:"size:64;not null;index"`
+	Outcome    string    `json:"outcome" gorm:"size:16;not null"`
+	ActorID    *uint     `json:"actor_id,omitempty" gorm:"index"`
+	ActorEmail string    `json:"actor_email,omitempty" gorm:"size:255;index"`
+	SubjectID  *uint     `json:"subject_id,omitempty" gorm:"index"`
+	IPAddress  string    `json:"ip_address,omitempty" gorm:"size:45"`
+	UserAgent  string    `json:"user_agent,omitempty" gorm:"size:255"`
+	Provider   string    `json:"provider,omitempty" gorm:"size:64"`
+	Reason     string    `json:"reason,omitempty" gorm:"size:255"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
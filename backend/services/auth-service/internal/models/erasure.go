@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Erasure statuses
+const (
+	ErasureStatusPending   = "pending"
+	ErasureStatusCompleted = "completed"
+	ErasureStatusFailed    = "failed"
+)
+
+// ErasureRecord documents the erasure of a user account and outlives it. It
+// keeps no personal data beyond the former user ID. Receipt is the signed
+// completion record handed to the requester; Steps is the space separated
+// list of what was erased.
+type ErasureRecord struct {
+	ID                  uint       `json:"-" gorm:"primaryKey"`
+	ErasureID           string     `json:"erasure_id" gorm:"uniqueIndex;size:64;not null"`
+	UserID              uint       `json:"user_id" gorm:"not null;index"`
+	RequestedBy         *uint      `json:"requested_by,omitempty"`
+	Status              string     `json:"status" gorm:"size:16;not null"`
+	Steps               string     `json:"steps" gorm:"size:255"`
+	OrdersPseudonymized int64      `json:"orders_pseudonymized"`
+	Error               string     `json:"error,omitempty" gorm:"size:255"`
+	Receipt             string     `json:"receipt,omitempty" gorm:"type:text"`
+	CreatedAt           time.Time  `json:"created_at"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Identity links a user to an account at an upstream OpenID Connect
+// provider. Provider and Subject (the sub claim) identify the external
+// account; Email is the address the provider last reported.
+type Identity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Provider    string     `json:"provider" gorm:"size:64;not null;uniqueIndex:idx_provider_subject"`
+	Subject     string     `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_provider_subject"`
+	Email       string     `json:"email" gorm:"size:255"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// FederatedLoginState is a pending sign-in at an upstream provider, looked
+// up by the state parameter when the provider redirects back. AuthorizeRequest
+// holds the JSON encoded authorization request when the sign-in was started
+// from the login page of the authorization endpoint.
+type FederatedLoginState struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	StateHash        string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	Provider         string     `json:"provider" gorm:"size:64;not null"`
+	Nonce            string     `json:"-" gorm:"size:64;not null"`
+	CodeVerifier     string     `json:"-" gorm:"size:128;not null"`
+	AuthorizeRequest string     `json:"-" gorm:"type:text"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"not null;index"`
+	UsedAt           *time.Time `json:"used_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
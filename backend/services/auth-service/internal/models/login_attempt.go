@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// LoginAttempt tracks consecutive failed logins for one key, either an email
+// address or a client IP, and the lockout derived from them.
+type LoginAttempt struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Key           string     `json:"key" gorm:"column:attempt_key;uniqueIndex;size:320;not null"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"index"`
+}
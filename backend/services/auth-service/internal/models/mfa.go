@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// TOTPFactor is a user's TOTP authenticator. It is pending until EnabledAt is
+// set by confirming a first code. The secret is stored encrypted, and
+// LastUsedStep records the most recent accepted time step so a code can not be
+// replayed.
+type TOTPFactor struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	EncryptedSecret string     `json:"-" gorm:"size:255;not null"`
+	LastUsedStep    int64      `json:"-" gorm:"not null;default:0"`
+	EnabledAt       *time.Time `json:"enabled_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// RecoveryCode is a single-use code that can stand in for a TOTP code when
+// the authenticator is lost. Only its hash is stored.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// OAuthClient is an application registered with auth-service. Confidential
+// clients authenticate with a secret, of which only the SHA-256 hash is
+// stored; public clients (SPAs, mobile apps) have no secret and must use
+// PKCE. Clients with redirect URIs can use the authorization code flow.
+type OAuthClient struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ClientID    string `json:"client_id" gorm:"uniqueIndex;size:64;not null"`
+	SecretHash  string `json:"-" gorm:"size:64"`
+	Name        string `json:"name" gorm:"size:100;not null"`
+	Description string `json:"description" gorm:"size:255"`
+	// Scopes is the space separated list of permissions the client may request
+	Scopes string `json:"scopes" gorm:"type:text"`
+	// RedirectURIs is the space separated list of allowed redirect URIs
+	RedirectURIs string    `json:"redirect_uris" gorm:"type:text"`
+	IsPublic     bool      `json:"is_public" gorm:"default:false"`
+	IsActive     bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// AuthorizationCode is a single-use code of the OpenID Connect authorization
+// code flow, bound to the client, redirect URI and PKCE challenge of the
+// authorization request. Only its hash is stored.
+type AuthorizationCode struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	CodeHash      string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	ClientID      string     `json:"client_id" gorm:"size:64;not null"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	RedirectURI   string     `json:"redirect_uri" gorm:"type:text;not null"`
+	Scope         string     `json:"scope" gorm:"size:255"`
+	Nonce         string     `json:"-" gorm:"size:255"`
+	CodeChallenge string     `json:"-" gorm:"size:128;not null"`
+	AuthTime      time.Time  `json:"auth_time"`
+	IPAddress     string     `json:"-" gorm:"size:45"`
+	UserAgent     string     `json:"-" gorm:"size:255"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null;index"`
+	UsedAt        *time.Time `json:"used_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
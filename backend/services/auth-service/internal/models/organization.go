@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Organization roles. Admins of an organization see all of its orders;
+// members only their own.
+const (
+	OrgRoleMember = "member"
+	OrgRoleAdmin  = "admin"
+)
+
+// Organization is a tenant. Users belong to any number of organizations and
+// act in one of them at a time: the active organization of their session,
+// carried in the org_id claim of their access tokens.
+type Organization struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:255;not null"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;size:100;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrganizationMember is the membership of a user in an organization.
+type OrganizationMember struct {
+	OrganizationID uint          `json:"org_id" gorm:"primaryKey"`
+	UserID         uint          `json:"user_id" gorm:"primaryKey;index"`
+	Role           string        `json:"role" gorm:"size:16;not null"`
+	Organization   *Organization `json:"organization,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Built-in permissions. Permissions are plain strings so services can check
+// them from token scopes without knowing about roles.
+const (
+	PermissionOrdersReadAny    = "orders:read:any"
+	PermissionOrdersUpdateAny  = "orders:update:any"
+	PermissionOrdersDeleteAny  = "orders:delete:any"
+	PermissionOrdersEraseAny   = "orders:erase:any"
+	PermissionUsersRead        = "users:read"
+	PermissionUsersWrite       = "users:write"
+	PermissionRolesManage      = "roles:manage"
+	PermissionClientsManage    = "clients:manage"
+	PermissionTokensIntrospect = "tokens:introspect"
+	PermissionAuditRead        = "audit:read"
+	PermissionOrgsManage       = "orgs:manage"
+)
+
+// Built-in roles
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+)
+
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"uniqueIndex;size:100;not null"`
+	Description string       `json:"description" gorm:"size:255"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type Permission struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex;size:100;not null"`
+	Description string    `json:"description" gorm:"size:255"`
+	CreatedAt   time.Time `json:"created_at"`
+}
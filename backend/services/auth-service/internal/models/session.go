@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Session is a login of a user on a device. It groups the refresh tokens of
+// one token family, and the access tokens issued with them carry the
+// family ID as sid. LastSeenAt is refreshed while the session's tokens are
+// used; ExpiresAt follows the latest refresh token. ClientID is the OAuth
+// client the session was started for, empty for first-party logins.
+type Session struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID   string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	IPAddress  string     `json:"ip_address" gorm:"size:45"`
+	ClientID   string     `json:"client_id,omitempty" gorm:"size:64"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null;index"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
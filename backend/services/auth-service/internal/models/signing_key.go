@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SigningKey is an asymmetric JWT signing key. The newest non-retired key is
+// used for signing; retired keys stay available for verification until
+// ExpiresAt. PrivateKey is the PKCS#8 PEM encrypted with the configured
+// jwt.key_encryption_key.
+type SigningKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	KID        string     `json:"kid" gorm:"column:kid;uniqueIndex;size:64;not null"`
+	Algorithm  string     `json:"algorithm" gorm:"size:16;not null"`
+	PrivateKey string     `json:"-" gorm:"type:text;not null"`
+	RetiredAt  *time.Time `json:"retired_at"`
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
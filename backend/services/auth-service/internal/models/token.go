@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// RefreshToken is a persisted, single-use refresh token. Tokens issued from the
+// same login share a FamilyID so that reuse of a rotated token can revoke the
+// whole chain. OrganizationID is the active organization of the session,
+// kept when the token is rotated. ClientID is the OAuth client the family
+// was issued to by the authorization code flow, and is empty for first-party
+// logins; a token can only be redeemed by the client it was issued to.
+type RefreshToken struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash       string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	FamilyID        string     `json:"family_id" gorm:"size:64;not null;index"`
+	AccessTokenID   string     `json:"-" gorm:"size:64"`
+	AccessExpiresAt time.Time  `json:"-"`
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	ReplacedByID    *uint      `json:"replaced_by_id"`
+	OrganizationID  *uint      `json:"-"`
+	ClientID        string     `json:"-" gorm:"size:64"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// RevokedToken records the jti of an access token that must no longer be
+// accepted, until the token would have expired anyway.
+type RevokedToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JTI       string    `json:"jti" gorm:"column:jti;uniqueIndex;size:64;not null"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeMFAChallenge      = "mfa_challenge"
+	TokenPurposeEmailChange       = "email_change"
+)
+
+// OneTimeToken is a single-use, time-limited token sent to the user by email,
+// for example to reset a password. Only its hash is stored. Data holds what
+// the token confirms, such as the new address of an email change.
+type OneTimeToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Purpose   string     `json:"purpose" gorm:"size:32;not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	Data      string     `json:"-" gorm:"size:255"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	CreatedAt time.Time  `json:"created_at"`
+}
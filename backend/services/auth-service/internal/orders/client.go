@@ -0,0 +1,95 @@
+package orders
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TokenSource returns the bearer token for a call to order-service.
+type TokenSource func() (string, error)
+
+// Client calls order-service on behalf of auth-service, to include a user's
+// orders in their data export and to pseudonymize them when the account is
+// erased.
+type Client struct {
+	baseURL    string
+	token      TokenSource
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the order-service API at baseURL, e.g.
+// http://order-service:8082/api/v1.
+func NewClient(baseURL string, token TokenSource, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type exportResponse struct {
+	Orders json.RawMessage `json:"orders"`
+}
+
+type eraseResponse struct {
+	OrdersPseudonymized int64 `json:"orders_pseudonymized"`
+}
+
+// ExportUserOrders returns all orders of the user, including deleted ones,
+// as the JSON array order-service returns.
+func (c *Client) ExportUserOrders(userID uint) (json.RawMessage, error) {
+	var body exportResponse
+	if err := c.do(http.MethodGet, fmt.Sprintf("/orders/users/%d/export", userID), &body); err != nil {
+		return nil, err
+	}
+	if len(body.Orders) == 0 || string(body.Orders) == "null" {
+		return json.RawMessage("[]"), nil
+	}
+	return body.Orders, nil
+}
+
+// EraseUserOrders detaches all orders of the user from their account and
+// returns how many orders were changed. Calling it again for the same user
+// changes nothing.
+func (c *Client) EraseUserOrders(userID uint) (int64, error) {
+	var body eraseResponse
+	if err := c.do(http.MethodPost, fmt.Sprintf("/orders/users/%d/erase", userID), &body); err != nil {
+		return 0, err
+	}
+	return body.OrdersPseudonymized, nil
+}
+
+func (c *Client) do(method, path string, v interface{}) error {
+	token, err := c.token()
+	if err != nil {
+		return fmt.Errorf("failed to issue order-service token: %w", err)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to order-service failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to order-service failed: %s %s returned %d", method, path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("invalid response from order-service: %w", err)
+	}
+	return nil
+}
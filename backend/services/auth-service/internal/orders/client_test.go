@@ -0,0 +1,68 @@
+package orders
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func staticToken() (string, error) {
+	return "service-token", nil
+}
+
+func TestClient_ExportUserOrders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v1/orders/users/7/export", r.URL.Path)
+		assert.Equal(t, "Bearer service-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"orders": [{"id": 1, "user_id": 7}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/api/v1/", staticToken, 0)
+	orders, err := client.ExportUserOrders(7)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"id": 1, "user_id": 7}]`, string(orders))
+}
+
+func TestClient_ExportUserOrders_None(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"orders": null}`))
+	}))
+	defer server.Close()
+
+	orders, err := NewClient(server.URL, staticToken, 0).ExportUserOrders(7)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(orders))
+}
+
+func TestClient_EraseUserOrders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/orders/users/7/erase", r.URL.Path)
+		w.Write([]byte(`{"orders_pseudonymized": 4}`))
+	}))
+	defer server.Close()
+
+	count, err := NewClient(server.URL, staticToken, 0).EraseUserOrders(7)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestClient_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error": "insufficient permissions"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, staticToken, 0).EraseUserOrders(7)
+	assert.ErrorContains(t, err, "403")
+
+	_, err = NewClient(server.URL, func() (string, error) { return "", errors.New("no signing key") }, 0).EraseUserOrders(7)
+	assert.ErrorContains(t, err, "no signing key")
+}
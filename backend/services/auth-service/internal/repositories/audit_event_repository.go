@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"auth-service/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditEventRepository stores the audit trail. It only inserts and reads;
+// entries are never changed.
+type AuditEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditEventRepository(db *gorm.DB) *AuditEventRepository {
+	return &AuditEventRepository{db: db}
+}
+
+// CreateBatch inserts events in one statement.
+func (r *AuditEventRepository) CreateBatch(events []*models.AuditEvent) error {
+	return r.db.Create(&events).Error
+}
+
+// AuditFilter narrows down audit events. Empty fields match everything; From
+// is inclusive and To exclusive.
+type AuditFilter struct {
+	Type       string
+	Outcome    string
+	ActorID    *uint
+	ActorEmail string
+	SubjectID  *uint
+	IPAddress  string
+	From       *time.Time
+	To         *time.Time
+}
+
+func (r *AuditEventRepository) query(filter AuditFilter) *gorm.DB {
+	query := r.db.Model(&models.AuditEvent{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.ActorEmail != "" {
+		query = query.Where("actor_email = ?", filter.ActorEmail)
+	}
+	if filter.SubjectID != nil {
+		query = query.Where("subject_id = ?", *filter.SubjectID)
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+// Search returns a page of matching events, newest first, together with the
+// total number of matches.
+func (r *AuditEventRepository) Search(filter AuditFilter, offset, limit int) ([]*models.AuditEvent, int64, error) {
+	query := r.query(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []*models.AuditEvent
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&events).Error
+	return events, total, err
+}
+
+// Each calls fn with consecutive batches of matching events, oldest first,
+// so large exports do not have to be held in memory.
+func (r *AuditEventRepository) Each(filter AuditFilter, batchSize int, fn func(events []*models.AuditEvent) error) error {
+	var events []*models.AuditEvent
+	return r.query(filter).FindInBatches(&events, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(events)
+	}).Error
+}
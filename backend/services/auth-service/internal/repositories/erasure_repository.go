@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"auth-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ErasureRepository struct {
+	db *gorm.DB
+}
+
+func NewErasureRepository(db *gorm.DB) *ErasureRepository {
+	return &ErasureRepository{db: db}
+}
+
+func (r *ErasureRepository) Create(record *models.ErasureRecord) error {
+	return r.db.Create(record).Error
+}
+
+func (r *ErasureRepository) Update(record *models.ErasureRecord) error {
+	return r.db.Save(record).Error
+}
+
+func (r *ErasureRepository) GetByErasureID(erasureID string) (*models.ErasureRecord, error) {
+	var record models.ErasureRecord
+	err := r.db.Where("erasure_id = ?", erasureID).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"auth-service/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+func (r *IdentityRepository) Create(identity *models.Identity) error {
+	return r.db.Create(identity).Error
+}
+
+// CreateWithUser provisions a new user together with the identity it was
+// created for and its default roles, in one transaction.
+func (r *IdentityRepository) CreateWithUser(user *models.User, identity *models.Identity, roles []models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if len(roles) > 0 {
+			if err := tx.Model(user).Association("Roles").Append(roles); err != nil {
+				return err
+			}
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+func (r *IdentityRepository) GetByProviderSubject(provider, subject string) (*models.Identity, error) {
+	var identity models.Identity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *IdentityRepository) ListByUser(userID uint) ([]*models.Identity, error) {
+	var identities []*models.Identity
+	err := r.db.Where("user_id = ?", userID).Order("provider").Find(&identities).Error
+	return identities, err
+}
+
+// RecordLogin stores the email the provider reported and the time of the
+// sign-in.
+func (r *IdentityRepository) RecordLogin(id uint, email string, at time.Time) error {
+	return r.db.Model(&models.Identity{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "last_login_at": at}).Error
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"auth-service/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptRepository is the shared auth.AttemptStore used when several
+// replicas have to agree on lockouts.
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+func (r *LoginAttemptRepository) Get(key string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.db.Where("attempt_key = ?", key).First(&attempt).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// Update locks the row for the key, creating it if needed, and saves the
+// result of fn in the same transaction.
+func (r *LoginAttemptRepository) Update(key string, fn func(attempt *models.LoginAttempt)) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginAttempt{Key: key}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("attempt_key = ?", key).
+			First(&attempt).Error; err != nil {
+			return err
+		}
+
+		fn(&attempt)
+		return tx.Save(&attempt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *LoginAttemptRepository) Delete(key string) error {
+	return r.db.Where("attempt_key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+func (r *LoginAttemptRepository) DeleteStale(before time.Time) error {
+	return r.db.Where("updated_at < ?", before).Delete(&models.LoginAttempt{}).Error
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"auth-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+func (r *OrganizationRepository) Create(org *models.Organization) error {
+	return r.db.Create(org).Error
+}
+
+func (r *OrganizationRepository) GetByID(id uint) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.First(&org, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *OrganizationRepository) GetBySlug(slug string) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.Where("slug = ?", slug).First(&org).Error
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *OrganizationRepository) List() ([]*models.Organization, error) {
+	var orgs []*models.Organization
+	err := r.db.Order("name").Find(&orgs).Error
+	return orgs, err
+}
+
+// ListMembers returns the members of an organization, oldest first.
+func (r *OrganizationRepository) ListMembers(orgID uint) ([]*models.OrganizationMember, error) {
+	var members []*models.OrganizationMember
+	err := r.db.Where("organization_id = ?", orgID).Order("created_at, user_id").Find(&members).Error
+	return members, err
+}
+
+// ListMemberships returns the memberships of a user with their
+// organizations, oldest first. The first one is the user's default
+// organization.
+func (r *OrganizationRepository) ListMemberships(userID uint) ([]*models.OrganizationMember, error) {
+	var members []*models.OrganizationMember
+	err := r.db.Preload("Organization").Where("user_id = ?", userID).
+		Order("created_at, organization_id").Find(&members).Error
+	return members, err
+}
+
+func (r *OrganizationRepository) GetMembership(orgID, userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := r.db.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// SetMember adds the user to the organization or changes their role.
+func (r *OrganizationRepository) SetMember(member *models.OrganizationMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+}
+
+// RemoveMember removes the user from the organization. It fails with
+// gorm.ErrRecordNotFound if they were not a member.
+func (r *OrganizationRepository) RemoveMember(orgID, userID uint) error {
+	result := r.db.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&models.OrganizationMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
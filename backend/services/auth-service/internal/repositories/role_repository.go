@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"auth-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) CreateRole(role *models.Role) error {
+	return r.db.Create(role).Error
+}
+
+func (r *RoleRepository) GetRoleByID(id uint) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions").First(&role, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) GetRoleByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) ListRoles() ([]*models.Role, error) {
+	var roles []*models.Role
+	err := r.db.Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+// SetRolePermissions replaces the permissions of a role.
+func (r *RoleRepository) SetRolePermissions(role *models.Role, permissions []models.Permission) error {
+	return r.db.Model(role).Association("Permissions").Replace(permissions)
+}
+
+func (r *RoleRepository) ListPermissions() ([]*models.Permission, error) {
+	var permissions []*models.Permission
+	err := r.db.Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *RoleRepository) GetPermissionsByName(names []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.db.Where("name IN ?", names).Find(&permissions).Error
+	return permissions, err
+}
+
+// GetUserRoles returns the roles of a user with their permissions loaded.
+func (r *RoleRepository) GetUserRoles(userID uint) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepository) AssignRole(user *models.User, role *models.Role) error {
+	return r.db.Model(user).Association("Roles").Append(role)
+}
+
+func (r *RoleRepository) RemoveRole(user *models.User, role *models.Role) error {
+	return r.db.Model(user).Association("Roles").Delete(role)
+}
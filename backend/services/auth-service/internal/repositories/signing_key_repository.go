@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"auth-service/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SigningKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+func (r *SigningKeyRepository) ListSigningKeys() ([]*models.SigningKey, error) {
+	var keys []*models.SigningKey
+	err := r.db.Order("created_at DESC, id DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *SigningKeyRepository) CreateSigningKey(key *models.SigningKey) error {
+	return r.db.Create(key).Error
+}
+
+// RetireSigningKeys retires every active key except the given one, keeping
+// them valid for verification until expiresAt.
+func (r *SigningKeyRepository) RetireSigningKeys(exceptKID string, retiredAt, expiresAt time.Time) error {
+	return r.db.Model(&models.SigningKey{}).
+		Where("kid <> ? AND retired_at IS NULL", exceptKID).
+		Updates(map[string]interface{}{
+			"retired_at": retiredAt,
+			"expires_at": expiresAt,
+		}).Error
+}
+
+func (r *SigningKeyRepository) DeleteExpiredSigningKeys(now time.Time) error {
+	return r.db.Where("expires_at IS NOT NULL AND expires_at < ?", now).
+		Delete(&models.SigningKey{}).Error
+}
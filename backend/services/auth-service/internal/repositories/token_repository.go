@@ -0,0 +1,313 @@
+package repositories
+
+import (
+	"auth-service/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// CreateSession stores a new session together with the first refresh token
+// of its family.
+func (r *TokenRepository) CreateSession(session *models.Session, token *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// ListActiveSessions returns the user's sessions that are neither revoked nor
+// expired, most recently used first.
+func (r *TokenRepository) ListActiveSessions(userID uint) ([]*models.Session, error) {
+	var sessions []*models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now().UTC()).
+		Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *TokenRepository) GetSession(id, userID uint) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// TouchSession sets the last-seen time of the session, unless it was already
+// seen after since.
+func (r *TokenRepository) TouchSession(familyID string, at, since time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("family_id = ? AND last_seen_at < ?", familyID, since).
+		Update("last_seen_at", at).Error
+}
+
+func (r *TokenRepository) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken atomically marks the old token as used and stores its
+// replacement. It fails with gorm.ErrRecordNotFound if the old token was
+// already revoked by a concurrent request.
+func (r *TokenRepository) RotateRefreshToken(old *models.RefreshToken, replacement *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(replacement).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now().UTC(),
+				"replaced_by_id": replacement.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Model(&models.Session{}).
+			Where("family_id = ?", replacement.FamilyID).
+			Updates(map[string]interface{}{
+				"last_seen_at": time.Now().UTC(),
+				"expires_at":   replacement.ExpiresAt,
+			}).Error
+	})
+}
+
+func (r *TokenRepository) RevokeRefreshToken(id uint) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC()).Error
+}
+
+// RevokeFamily revokes every refresh token in a family and blacklists the
+// access tokens that were issued with them.
+func (r *TokenRepository) RevokeFamily(familyID string) error {
+	return r.revokeWhere("family_id = ?", familyID)
+}
+
+// RevokeAllForUser revokes every refresh token of a user and blacklists the
+// access tokens that were issued with them.
+func (r *TokenRepository) RevokeAllForUser(userID uint) error {
+	return r.revokeWhere("user_id = ?", userID)
+}
+
+// RevokeOtherSessions revokes every session of a user except the one of the
+// given token family, like RevokeAllForUser.
+func (r *TokenRepository) RevokeOtherSessions(userID uint, familyID string) error {
+	return r.revokeWhere("user_id = ? AND family_id <> ?", userID, familyID)
+}
+
+// revokeWhere revokes the refresh tokens and sessions matching the query,
+// which may only refer to the user_id and family_id columns both tables
+// share.
+func (r *TokenRepository) revokeWhere(query string, args ...interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var tokens []models.RefreshToken
+		if err := tx.Where(query, args...).Find(&tokens).Error; err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, token := range tokens {
+			if token.AccessTokenID == "" || token.AccessExpiresAt.Before(now) {
+				continue
+			}
+			revoked := &models.RevokedToken{
+				JTI:       token.AccessTokenID,
+				UserID:    token.UserID,
+				ExpiresAt: token.AccessExpiresAt,
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(revoked).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.RefreshToken{}).
+			Where(query, args...).
+			Where("revoked_at IS NULL").
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Session{}).
+			Where(query, args...).
+			Where("revoked_at IS NULL").
+			Update("revoked_at", now).Error
+	})
+}
+
+func (r *TokenRepository) RevokeAccessToken(jti string, userID uint, expiresAt time.Time) error {
+	revoked := &models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(revoked).Error
+}
+
+func (r *TokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateOneTimeToken stores a new one-time token and invalidates any unused
+// token the user still has for the same purpose, so only the latest email
+// link works.
+func (r *TokenRepository) CreateOneTimeToken(token *models.OneTimeToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OneTimeToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", time.Now().UTC()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// ConsumeOneTimeToken marks an unused, unexpired token as used and returns
+// it. It fails with gorm.ErrRecordNotFound if the token does not exist, has
+// expired or was already used.
+func (r *TokenRepository) ConsumeOneTimeToken(hash, purpose string) (*models.OneTimeToken, error) {
+	now := time.Now().UTC()
+	result := r.db.Model(&models.OneTimeToken{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var token models.OneTimeToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetOneTimeToken returns an unused, unexpired token without consuming it.
+func (r *TokenRepository) GetOneTimeToken(hash, purpose string) (*models.OneTimeToken, error) {
+	var token models.OneTimeToken
+	err := r.db.Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, time.Now().UTC()).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RecordFailedAttempt counts a failed attempt against a one-time token and
+// invalidates it once maxAttempts is reached.
+func (r *TokenRepository) RecordFailedAttempt(id uint, maxAttempts int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OneTimeToken{}).
+			Where("id = ?", id).
+			Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.OneTimeToken{}).
+			Where("id = ? AND attempts >= ? AND used_at IS NULL", id, maxAttempts).
+			Update("used_at", time.Now().UTC()).Error
+	})
+}
+
+func (r *TokenRepository) CreateAuthorizationCode(code *models.AuthorizationCode) error {
+	return r.db.Create(code).Error
+}
+
+// ConsumeAuthorizationCode marks an unused, unexpired authorization code as
+// used and returns it. It returns gorm.ErrRecordNotFound if the code is
+// unknown, expired or was already redeemed.
+func (r *TokenRepository) ConsumeAuthorizationCode(hash string) (*models.AuthorizationCode, error) {
+	now := time.Now().UTC()
+	result := r.db.Model(&models.AuthorizationCode{}).
+		Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var code models.AuthorizationCode
+	if err := r.db.Where("code_hash = ?", hash).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *TokenRepository) CreateFederatedLoginState(state *models.FederatedLoginState) error {
+	return r.db.Create(state).Error
+}
+
+// ConsumeFederatedLoginState marks an unused, unexpired sign-in state of the
+// provider as used and returns it. It returns gorm.ErrRecordNotFound if the
+// state is unknown, expired or was already used.
+func (r *TokenRepository) ConsumeFederatedLoginState(hash, provider string) (*models.FederatedLoginState, error) {
+	now := time.Now().UTC()
+	result := r.db.Model(&models.FederatedLoginState{}).
+		Where("state_hash = ? AND provider = ? AND used_at IS NULL AND expires_at > ?", hash, provider, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var state models.FederatedLoginState
+	if err := r.db.Where("state_hash = ?", hash).First(&state).Error; err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// DeleteExpired removes refresh, revoked-token, one-time token, authorization
+// code, sign-in state and session rows that can no longer be used, keeping
+// the tables bounded.
+func (r *TokenRepository) DeleteExpired() error {
+	now := time.Now().UTC()
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.OneTimeToken{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.AuthorizationCode{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.FederatedLoginState{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.Session{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error
+}
@@ -2,6 +2,10 @@ package repositories
 
 import (
 	"auth-service/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -40,12 +44,162 @@ func (r *UserRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+// EmailTaken reports whether any user, including soft-deleted ones, has the
+// email address.
+func (r *UserRepository) EmailTaken(email string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *UserRepository) UpdateProfile(id uint, firstName, lastName string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"first_name": firstName,
+		"last_name":  lastName,
+	}).Error
+}
+
+// UpdateEmail switches the user to a confirmed email address.
+func (r *UserRepository) UpdateEmail(id uint, email string, verifiedAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"email":             email,
+		"email_verified_at": verifiedAt,
+	}).Error
+}
+
 func (r *UserRepository) Delete(id uint) error {
 	return r.db.Delete(&models.User{}, id).Error
 }
 
+// Erase permanently deletes a user, soft-deleted or not, together with their
+// credentials, sessions, tokens, linked identities, role assignments and
+// organization memberships. Revoked access tokens are kept until they
+// expire, so the tokens stay rejected. The user's audit events are kept but
+// pseudonymized: events of the user keep only the user ID, and events that
+// carry only the email, such as failed logins, keep a hash of it; client IP
+// addresses and user agents are cleared.
+func (r *UserRepository) Erase(id uint, email string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AuditEvent{}).Where("actor_id = ?", id).Updates(map[string]interface{}{
+			"actor_email": "",
+			"ip_address":  "",
+			"user_agent":  "",
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AuditEvent{}).Where("actor_id IS NULL AND actor_email = ?", email).Updates(map[string]interface{}{
+			"actor_email": ErasedEmail(email),
+			"ip_address":  "",
+			"user_agent":  "",
+		}).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{
+			&models.RefreshToken{},
+			&models.Session{},
+			&models.OneTimeToken{},
+			&models.AuthorizationCode{},
+			&models.TOTPFactor{},
+			&models.RecoveryCode{},
+			&models.Identity{},
+			&models.APIKey{},
+			&models.OrganizationMember{},
+		} {
+			if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.User{}, id).Error
+	})
+}
+
+// ErasedEmail is the pseudonym of the email address of an erased user in the
+// audit log. The same address always gets the same pseudonym, so failed
+// logins of an erased address can still be correlated.
+func ErasedEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return "erased:" + hex.EncodeToString(sum[:])
+}
+
 func (r *UserRepository) List(offset, limit int) ([]*models.User, error) {
 	var users []*models.User
 	err := r.db.Offset(offset).Limit(limit).Find(&users).Error
 	return users, err
 }
+
+// UserFilter narrows down a user search. Email and Name match substrings;
+// Name matches either the first or the last name.
+type UserFilter struct {
+	Email          string
+	Name           string
+	IsActive       *bool
+	IncludeDeleted bool
+}
+
+// Search returns a page of users matching the filter together with the total
+// number of matches.
+func (r *UserRepository) Search(filter UserFilter, offset, limit int) ([]*models.User, int64, error) {
+	query := r.db.Model(&models.User{})
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
+	}
+	if filter.Email != "" {
+		query = query.Where("email LIKE ?", "%"+escapeLike(filter.Email)+"%")
+	}
+	if filter.Name != "" {
+		name := "%" + escapeLike(filter.Name) + "%"
+		query = query.Where("first_name LIKE ? OR last_name LIKE ? OR CONCAT(first_name, ' ', last_name) LIKE ?", name, name, name)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []*models.User
+	err := query.Preload("Roles").Order("id").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// GetByIDUnscoped returns a user even if it was soft-deleted.
+func (r *UserRepository) GetByIDUnscoped(id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.Unscoped().Preload("Roles").First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) SetActive(id uint, active bool) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("is_active", active).Error
+}
+
+func (r *UserRepository) SetEmailVerified(id uint, verifiedAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("email_verified_at", verifiedAt).Error
+}
+
+func (r *UserRepository) UpdatePassword(id uint, hashedPassword string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("password", hashedPassword).Error
+}
+
+// ReplacePasswordHash swaps the password hash only if it is still oldHash, so
+// an upgraded hash can not overwrite a password changed in the meantime.
+func (r *UserRepository) ReplacePasswordHash(id uint, oldHash, newHash string) error {
+	return r.db.Model(&models.User{}).Where("id = ? AND password = ?", id, oldHash).Update("password", newHash).Error
+}
+
+func (r *UserRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&models.User{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,554 @@
+package services
+
+import (
+	"auth-service/internal/audit"
+	"auth-service/internal/auth"
+	"auth-service/internal/mail"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AccountService implements the email based account flows: password reset,
+// email verification and email change. They use single-use tokens that are
+// only stored hashed and expire after a configurable time. It also lets
+// signed-in users edit their profile and change their password.
+type AccountService struct {
+	userRepo            *repositories.UserRepository
+	tokenRepo           *repositories.TokenRepository
+	mailer              mail.Mailer
+	publicURL           string
+	resetTTL            time.Duration
+	verificationTTL     time.Duration
+	requireVerification bool
+	passwordPolicy      *auth.PasswordPolicy
+	passwordHasher      *auth.PasswordHasher
+	throttle            *auth.LoginThrottle
+	auditor             *audit.Logger
+}
+
+func NewAccountService(userRepo *repositories.UserRepository, tokenRepo *repositories.TokenRepository, mailer mail.Mailer, publicURL string, resetTTL, verificationTTL time.Duration, requireVerification bool, passwordPolicy *auth.PasswordPolicy, passwordHasher *auth.PasswordHasher, throttle *auth.LoginThrottle, auditor *audit.Logger) *AccountService {
+	return &AccountService{
+		userRepo:            userRepo,
+		tokenRepo:           tokenRepo,
+		mailer:              mailer,
+		publicURL:           strings.TrimRight(publicURL, "/"),
+		resetTTL:            resetTTL,
+		verificationTTL:     verificationTTL,
+		requireVerification: requireVerification,
+		passwordPolicy:      passwordPolicy,
+		passwordHasher:      passwordHasher,
+		throttle:            throttle,
+		auditor:             auditor,
+	}
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// ClientIP and UserAgent are set by the handler for the audit log
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// UpdateProfileRequest changes the fields that are set; omitted fields are
+// left unchanged. The email address is changed with RequestEmailChange.
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name" binding:"omitempty,min=1,max=100"`
+	LastName  *string `json:"last_name" binding:"omitempty,min=1,max=100"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+	// SessionID is set by the handler; that session stays signed in
+	SessionID string `json:"-"`
+	// ClientIP and UserAgent are set by the handler for the audit log
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	// ClientIP is set by the handler for brute-force protection
+	ClientIP string `json:"-"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+var ErrEmailInUse = errors.New("email address is already in use")
+
+// VerificationRequired reports whether the user must verify their email
+// address before tokens are issued.
+func (s *AccountService) VerificationRequired(user *models.User) bool {
+	return s.requireVerification && user.EmailVerifiedAt == nil
+}
+
+// ValidatePassword returns an *auth.PasswordPolicyError if the password does
+// not satisfy the password policy for the user.
+func (s *AccountService) ValidatePassword(password string, user *models.User) error {
+	if s.passwordPolicy == nil {
+		return nil
+	}
+	return s.passwordPolicy.Check(password, user.Email, user.FirstName, user.LastName)
+}
+
+// HashPassword hashes a new password with the configured algorithm.
+func (s *AccountService) HashPassword(password string) (string, error) {
+	return s.passwordHasher.Hash(password)
+}
+
+// CheckDummyPassword takes as long as checking a password, for logins of
+// unknown emails.
+func (s *AccountService) CheckDummyPassword(password string) {
+	s.passwordHasher.CheckDummy(password)
+}
+
+// RehashPassword upgrades the stored hash of a user whose password was just
+// verified, if it uses an older algorithm or other parameters than new
+// hashes. Failures are logged; the user can still sign in.
+func (s *AccountService) RehashPassword(user *models.User, password string) {
+	if !s.passwordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("Failed to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+	if err := s.userRepo.ReplacePasswordHash(user.ID, user.Password, hashedPassword); err != nil {
+		log.Printf("Failed to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+	user.Password = hashedPassword
+}
+
+// RequestPasswordReset emails a reset link to the user. Unknown or
+// deactivated accounts are silently ignored so the endpoint can not be used
+// to probe for registered addresses.
+func (s *AccountService) RequestPasswordReset(req *ForgotPasswordRequest) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("password_reset_request", time.Since(start))
+	}()
+
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if !user.IsActive {
+		return nil
+	}
+
+	token, err := s.createToken(user, models.TokenPurposePasswordReset, "", s.resetTTL)
+	if err != nil {
+		return err
+	}
+
+	s.send(&mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s.\n\n%s\n\nIf you did not ask for a password reset you can ignore this email.\n",
+			user.FirstName, s.resetTTL, s.link("/reset-password", token)),
+	})
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token. All sessions of the
+// user are revoked. Since the token proves access to the mailbox the email
+// address is marked as verified as well.
+func (s *AccountService) ResetPassword(req *ResetPasswordRequest) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("password_reset", time.Since(start))
+	}()
+
+	user, err := s.resetPassword(req)
+	s.auditor.Record(newAuditEvent(models.AuditEventPasswordReset, user, "", &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent}, err))
+	return err
+}
+
+// resetPassword returns the user of the token as soon as it is known, also
+// with an error.
+func (s *AccountService) resetPassword(req *ResetPasswordRequest) (*models.User, error) {
+	// Check the policy before the token is used up, so a rejected password
+	// can be retried with the same link
+	user, err := s.peekToken(req.Token, models.TokenPurposePasswordReset)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ValidatePassword(req.Password, user); err != nil {
+		return user, err
+	}
+
+	user, _, err = s.consumeToken(req.Token, models.TokenPurposePasswordReset)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return user, errors.New("user account is deactivated")
+	}
+
+	hashedPassword, err := s.HashPassword(req.Password)
+	if err != nil {
+		return user, err
+	}
+
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return user, err
+	}
+
+	if user.EmailVerifiedAt == nil {
+		if err := s.userRepo.SetEmailVerified(user.ID, time.Now().UTC()); err != nil {
+			return user, err
+		}
+	}
+
+	return user, s.tokenRepo.RevokeAllForUser(user.ID)
+}
+
+// SendVerificationEmail emails a verification link to the user.
+func (s *AccountService) SendVerificationEmail(user *models.User) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("email_verification_send", time.Since(start))
+	}()
+
+	token, err := s.createToken(user, models.TokenPurposeEmailVerification, "", s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	s.send(&mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address using the link below. It expires in %s.\n\n%s\n",
+			user.FirstName, s.verificationTTL, s.link("/verify-email", token)),
+	})
+	return nil
+}
+
+// ResendVerification sends a new verification link. Like
+// RequestPasswordReset it does not reveal whether the address is registered.
+func (s *AccountService) ResendVerification(req *ResendVerificationRequest) error {
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if !user.IsActive || user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return s.SendVerificationEmail(user)
+}
+
+func (s *AccountService) VerifyEmail(req *VerifyEmailRequest) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("email_verify", time.Since(start))
+	}()
+
+	user, _, err := s.consumeToken(req.Token, models.TokenPurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return s.userRepo.SetEmailVerified(user.ID, time.Now().UTC())
+}
+
+// GetProfile returns the signed-in user.
+func (s *AccountService) GetProfile(userID uint) (*models.User, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("profile_get", time.Since(start))
+	}()
+
+	return s.getUser(userID)
+}
+
+func (s *AccountService) UpdateProfile(userID uint, req *UpdateProfileRequest) (*models.User, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("profile_update", time.Since(start))
+	}()
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FirstName != nil {
+		user.FirstName = strings.TrimSpace(*req.FirstName)
+	}
+	if req.LastName != nil {
+		user.LastName = strings.TrimSpace(*req.LastName)
+	}
+	if user.FirstName == "" || user.LastName == "" {
+		return nil, errors.New("first and last name must not be empty")
+	}
+
+	if err := s.userRepo.UpdateProfile(user.ID, user.FirstName, user.LastName); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword sets a new password after checking the current one. Every
+// other session of the user is revoked; the session the request was made
+// from stays signed in.
+func (s *AccountService) ChangePassword(userID uint, req *ChangePasswordRequest) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("password_change", time.Since(start))
+	}()
+
+	user, err := s.changePassword(userID, req)
+	s.auditor.Record(newAuditEvent(models.AuditEventPasswordChange, user, "", &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent}, err))
+	return err
+}
+
+func (s *AccountService) changePassword(userID uint, req *ChangePasswordRequest) (*models.User, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := confirmPassword(s.throttle, user, req.CurrentPassword, req.ClientIP); err != nil {
+		return user, err
+	}
+
+	if err := s.ValidatePassword(req.NewPassword, user); err != nil {
+		return user, err
+	}
+
+	hashedPassword, err := s.HashPassword(req.NewPassword)
+	if err != nil {
+		return user, err
+	}
+
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return user, err
+	}
+
+	if req.SessionID != "" {
+		err = s.tokenRepo.RevokeOtherSessions(user.ID, req.SessionID)
+	} else {
+		err = s.tokenRepo.RevokeAllForUser(user.ID)
+	}
+	if err != nil {
+		return user, err
+	}
+
+	s.send(&mail.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe password of your account was just changed and your other sessions were signed out.\n\nIf you did not do this, reset your password right away:\n\n%s\n",
+			user.FirstName, s.publicURL+"/forgot-password"),
+	})
+	return user, nil
+}
+
+// RequestEmailChange emails a confirmation link to the new address. The
+// email column is only switched once the link is used, so a typo can not lock
+// the user out. The current address is told about the request.
+func (s *AccountService) RequestEmailChange(userID uint, req *ChangeEmailRequest) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("email_change_request", time.Since(start))
+	}()
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := confirmPassword(s.throttle, user, req.Password, req.ClientIP); err != nil {
+		return err
+	}
+
+	newEmail := strings.ToLower(strings.TrimSpace(req.NewEmail))
+	if strings.EqualFold(newEmail, user.Email) {
+		return errors.New("new email address is the current one")
+	}
+
+	taken, err := s.userRepo.EmailTaken(newEmail)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailInUse
+	}
+
+	token, err := s.createToken(user, models.TokenPurposeEmailChange, newEmail, s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	s.send(&mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm that you want to use this address for your account. The link expires in %s.\n\n%s\n",
+			user.FirstName, s.verificationTTL, s.link("/confirm-email-change", token)),
+	})
+	s.send(&mail.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email address of your account to %s. The change takes effect once the new address is confirmed.\n\nIf you did not do this, reset your password right away:\n\n%s\n",
+			user.FirstName, newEmail, s.publicURL+"/forgot-password"),
+	})
+	return nil
+}
+
+// ConfirmEmailChange switches the user to the address confirmed by the token.
+// The address counts as verified.
+func (s *AccountService) ConfirmEmailChange(req *ConfirmEmailChangeRequest) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("email_change_confirm", time.Since(start))
+	}()
+
+	user, token, err := s.consumeToken(req.Token, models.TokenPurposeEmailChange)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsActive {
+		return errors.New("user account is deactivated")
+	}
+
+	// The address may have been registered since the change was requested
+	taken, err := s.userRepo.EmailTaken(token.Data)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailInUse
+	}
+
+	return s.userRepo.UpdateEmail(user.ID, token.Data, time.Now().UTC())
+}
+
+func (s *AccountService) getUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AccountService) createToken(user *models.User, purpose, data string, ttl time.Duration) (string, error) {
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	record := &models.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: auth.HashToken(token),
+		Data:      data,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := s.tokenRepo.CreateOneTimeToken(record); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// peekToken returns the user of an unused, unexpired token without consuming
+// it.
+func (s *AccountService) peekToken(token, purpose string) (*models.User, error) {
+	record, err := s.tokenRepo.GetOneTimeToken(auth.HashToken(token), purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired token")
+		}
+		return nil, err
+	}
+
+	return s.tokenUser(record)
+}
+
+func (s *AccountService) consumeToken(token, purpose string) (*models.User, *models.OneTimeToken, error) {
+	record, err := s.tokenRepo.ConsumeOneTimeToken(auth.HashToken(token), purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("invalid or expired token")
+		}
+		return nil, nil, err
+	}
+
+	user, err := s.tokenUser(record)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, record, nil
+}
+
+func (s *AccountService) tokenUser(record *models.OneTimeToken) (*models.User, error) {
+	user, err := s.userRepo.GetByID(record.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired token")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return s.publicURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// send delivers the message in the background, so the response time of the
+// request does not depend on the mail server or reveal whether mail was sent.
+func (s *AccountService) send(msg *mail.Message) {
+	go func() {
+		if err := s.mailer.Send(msg); err != nil {
+			log.Printf("Failed to send %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
@@ -0,0 +1,15 @@
+package services
+
+import "auth-service/internal/models"
+
+type IAccountService interface {
+	RequestPasswordReset(req *ForgotPasswordRequest) error
+	ResetPassword(req *ResetPasswordRequest) error
+	ResendVerification(req *ResendVerificationRequest) error
+	VerifyEmail(req *VerifyEmailRequest) error
+	GetProfile(userID uint) (*models.User, error)
+	UpdateProfile(userID uint, req *UpdateProfileRequest) (*models.User, error)
+	ChangePassword(userID uint, req *ChangePasswordRequest) error
+	RequestEmailChange(userID uint, req *ChangeEmailRequest) error
+	ConfirmEmailChange(req *ConfirmEmailChangeRequest) error
+}
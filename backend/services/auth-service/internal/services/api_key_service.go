@@ -0,0 +1,141 @@
+package services
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyPrefixLength is how much of a key is kept in clear to identify it in
+// listings: the "ak_" marker and six random characters.
+const apiKeyPrefixLength = 9
+
+var (
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrInvalidAPIKey  = errors.New("invalid or expired API key")
+	// ErrAPIKeyScope is returned when a key is requested with a permission
+	// the user does not hold.
+	ErrAPIKeyScope = errors.New("permission not granted to the user")
+)
+
+// APIKeyService manages the API keys of users. Keys are validated together
+// with access tokens by AuthService.ValidateClaims.
+type APIKeyService struct {
+	apiKeyRepo *repositories.APIKeyRepository
+	userRepo   *repositories.UserRepository
+	authSvc    *AuthService
+}
+
+func NewAPIKeyService(apiKeyRepo *repositories.APIKeyRepository, userRepo *repositories.UserRepository, authSvc *AuthService) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		authSvc:    authSvc,
+	}
+}
+
+// CreateAPIKeyRequest creates a key. Scopes are permissions of the user
+// delegated to the key; without scopes the key acts for the user on their
+// own resources only. Without ExpiresAt the key does not expire.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeySecretResponse is returned when a key is created. The key is not
+// stored and can not be shown again.
+type APIKeySecretResponse struct {
+	APIKey *models.APIKey `json:"api_key"`
+	Key    string         `json:"key"`
+}
+
+func (s *APIKeyService) ListKeys(userID uint) ([]*models.APIKey, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("api_keys_list", time.Since(start))
+	}()
+
+	return s.apiKeyRepo.ListByUser(userID)
+}
+
+// CreateKey generates a key for the user, limited to permissions the user
+// currently holds.
+func (s *APIKeyService) CreateKey(userID uint, req *CreateAPIKeyRequest) (*APIKeySecretResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("api_key_create", time.Since(start))
+	}()
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.authSvc.userClaims(user)
+	if err != nil {
+		return nil, err
+	}
+
+	held := claims.Scopes()
+	var scopes []string
+	for _, scope := range req.Scopes {
+		if !containsString(held, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrAPIKeyScope, scope)
+		}
+		if !containsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	key, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &models.APIKey{
+		UserID:  userID,
+		Name:    req.Name,
+		Prefix:  key[:apiKeyPrefixLength],
+		KeyHash: auth.HashToken(key),
+		Scopes:  strings.Join(scopes, " "),
+	}
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC()
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.Create(apiKey); err != nil {
+		return nil, err
+	}
+
+	return &APIKeySecretResponse{APIKey: apiKey, Key: key}, nil
+}
+
+// RevokeKey revokes one of the user's keys. It is rejected from the next
+// request on.
+func (s *APIKeyService) RevokeKey(userID, id uint) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("api_key_revoke", time.Since(start))
+	}()
+
+	if err := s.apiKeyRepo.Revoke(id, userID, time.Now().UTC()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package services
+
+import "auth-service/internal/models"
+
+type IAPIKeyService interface {
+	ListKeys(userID uint) ([]*models.APIKey, error)
+	CreateKey(userID uint, req *CreateAPIKeyRequest) (*APIKeySecretResponse, error)
+	RevokeKey(userID, id uint) error
+}
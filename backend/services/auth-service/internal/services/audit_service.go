@@ -0,0 +1,128 @@
+package services
+
+import (
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"time"
+)
+
+// auditExportBatchSize is the number of events read per query while exporting.
+const auditExportBatchSize = 500
+
+// AuditService lets administrators search and export the audit trail. Events
+// are recorded by the services that handle the audited requests, through an
+// audit.Logger.
+type AuditService struct {
+	auditRepo *repositories.AuditEventRepository
+}
+
+func NewAuditService(auditRepo *repositories.AuditEventRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// AuditQuery filters audit events. From and To are RFC 3339 times; From is
+// inclusive and To exclusive.
+type AuditQuery struct {
+	Type       string     `form:"type"`
+	Outcome    string     `form:"outcome" binding:"omitempty,oneof=success failure"`
+	ActorID    *uint      `form:"actor_id"`
+	ActorEmail string     `form:"actor_email"`
+	SubjectID  *uint      `form:"subject_id"`
+	IPAddress  string     `form:"ip"`
+	From       *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type ListAuditEventsRequest struct {
+	AuditQuery
+	Offset int `form:"offset" binding:"min=0"`
+	Limit  int `form:"limit" binding:"min=0,max=100"`
+}
+
+type ListAuditEventsResponse struct {
+	Events []*models.AuditEvent `json:"events"`
+	Total  int64                `json:"total"`
+	Offset int                  `json:"offset"`
+	Limit  int                  `json:"limit"`
+}
+
+// ListEvents returns a page of matching events, newest first.
+func (s *AuditService) ListEvents(req *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("audit_events_list", time.Since(start))
+	}()
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = 50
+	}
+
+	events, total, err := s.auditRepo.Search(req.AuditQuery.filter(), req.Offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListAuditEventsResponse{
+		Events: events,
+		Total:  total,
+		Offset: req.Offset,
+		Limit:  limit,
+	}, nil
+}
+
+// ExportEvents calls fn with every matching event, oldest first. It stops at
+// the first error of fn.
+func (s *AuditService) ExportEvents(query *AuditQuery, fn func(event *models.AuditEvent) error) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("audit_events_export", time.Since(start))
+	}()
+
+	return s.auditRepo.Each(query.filter(), auditExportBatchSize, func(events []*models.AuditEvent) error {
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (q *AuditQuery) filter() repositories.AuditFilter {
+	return repositories.AuditFilter{
+		Type:       q.Type,
+		Outcome:    q.Outcome,
+		ActorID:    q.ActorID,
+		ActorEmail: q.ActorEmail,
+		SubjectID:  q.SubjectID,
+		IPAddress:  q.IPAddress,
+		From:       q.From,
+		To:         q.To,
+	}
+}
+
+// newAuditEvent describes an event of a request from device. The actor is
+// user, or only the email when the user is not known, e.g. for a failed
+// login. A non-nil err makes it a failure with the error as reason.
+func newAuditEvent(eventType string, user *models.User, email string, device *Device, err error) *models.AuditEvent {
+	event := &models.AuditEvent{
+		Type:       eventType,
+		Outcome:    models.AuditOutcomeSuccess,
+		ActorEmail: email,
+	}
+	if user != nil {
+		event.ActorID = &user.ID
+		event.ActorEmail = user.Email
+	}
+	if device != nil {
+		event.IPAddress = device.IPAddress
+		event.UserAgent = device.UserAgent
+	}
+	if err != nil {
+		event.Outcome = models.AuditOutcomeFailure
+		event.Reason = err.Error()
+	}
+	return event
+}
@@ -0,0 +1,8 @@
+package services
+
+import "auth-service/internal/models"
+
+type IAuditService interface {
+	ListEvents(req *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+	ExportEvents(query *AuditQuery, fn func(event *models.AuditEvent) error) error
+}
@@ -1,57 +1,174 @@
 package services
 
 import (
+	"auth-service/internal/audit"
 	"auth-service/internal/auth"
 	"auth-service/internal/middleware"
 	"auth-service/internal/models"
 	"auth-service/internal/repositories"
 	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
 
 type AuthService struct {
-	userRepo *repositories.UserRepository
-	jwtSvc   *auth.JWTService
+	userRepo   *repositories.UserRepository
+	tokenRepo  *repositories.TokenRepository
+	roleRepo   *repositories.RoleRepository
+	jwtSvc     *auth.JWTService
+	accountSvc *AccountService
+	mfaSvc     *MFAService
+	throttle   *auth.LoginThrottle
+	oauthSvc   *OAuthService
+	apiKeyRepo *repositories.APIKeyRepository
+	orgRepo    *repositories.OrganizationRepository
+	auditor    *audit.Logger
 }
 
-func NewAuthService(userRepo *repositories.UserRepository, jwtSvc *auth.JWTService) *AuthService {
+func NewAuthService(userRepo *repositories.UserRepository, tokenRepo *repositories.TokenRepository, roleRepo *repositories.RoleRepository, jwtSvc *auth.JWTService, accountSvc *AccountService, mfaSvc *MFAService, throttle *auth.LoginThrottle, oauthSvc *OAuthService, apiKeyRepo *repositories.APIKeyRepository, orgRepo *repositories.OrganizationRepository, auditor *audit.Logger) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
-		jwtSvc:   jwtSvc,
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		roleRepo:   roleRepo,
+		jwtSvc:     jwtSvc,
+		accountSvc: accountSvc,
+		mfaSvc:     mfaSvc,
+		throttle:   throttle,
+		oauthSvc:   oauthSvc,
+		apiKeyRepo: apiKeyRepo,
+		orgRepo:    orgRepo,
+		auditor:    auditor,
 	}
 }
 
+// apiKeyTouchInterval and sessionTouchInterval limit how often the last-used
+// time of an API key or session is written, so busy callers do not cause a
+// write per request.
+const (
+	apiKeyTouchInterval  = time.Minute
+	sessionTouchInterval = time.Minute
+)
+
 type RegisterRequest struct {
 	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
+	Password  string `json:"password" binding:"required"`
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
+	// ClientIP and UserAgent are set by the handler for the session
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	// ClientIP is set by the handler for brute-force protection and, with
+	// UserAgent, for the session
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	AllSessions  bool   `json:"all_sessions"`
 }
 
+// AuthResponse carries the issued tokens. Token is empty when the account
+// has to verify its email address first, or when MFARequired is set and the
+// MFAToken has to be exchanged at /auth/mfa/verify.
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  *models.User `json:"user"`
+	Token        string       `json:"token,omitempty"`
+	RefreshToken string       `json:"refresh_token,omitempty"`
+	MFARequired  bool         `json:"mfa_required,omitempty"`
+	MFAToken     string       `json:"mfa_token,omitempty"`
+	User         *models.User `json:"user,omitempty"`
+}
+
+// IntrospectionRequest is an RFC 7662 introspection request, made by an
+// OAuth2 client holding the tokens:introspect permission.
+type IntrospectionRequest struct {
+	ClientID      string
+	ClientSecret  string
+	Token         string
+	TokenTypeHint string
+}
+
+// IntrospectionResponse is the RFC 7662 introspection response. Only Active
+// is set for tokens that are invalid, expired or revoked.
+type IntrospectionResponse struct {
+	Active      bool     `json:"active"`
+	Scope       string   `json:"scope,omitempty"`
+	ClientID    string   `json:"client_id,omitempty"`
+	Username    string   `json:"username,omitempty"`
+	TokenType   string   `json:"token_type,omitempty"`
+	Exp         int64    `json:"exp,omitempty"`
+	Iat         int64    `json:"iat,omitempty"`
+	Nbf         int64    `json:"nbf,omitempty"`
+	Sub         string   `json:"sub,omitempty"`
+	Iss         string   `json:"iss,omitempty"`
+	Jti         string   `json:"jti,omitempty"`
+	SubjectType string   `json:"sub_type,omitempty"`
+	UserID      uint     `json:"user_id,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	OrgID       uint     `json:"org_id,omitempty"`
+	OrgRole     string   `json:"org_role,omitempty"`
 }
 
+// ErrIntrospectionNotAllowed is returned when an authenticated client lacks
+// the tokens:introspect permission.
+var ErrIntrospectionNotAllowed = &OAuthError{Code: OAuthErrorUnauthorizedClient, Description: "client is not allowed to introspect tokens"}
+
 func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 	start := time.Now()
 	defer func() {
 		middleware.RecordDatabaseQuery("user_register", time.Since(start))
 	}()
 
+	device := &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent}
+	user, err := s.createUser(req)
+	s.auditor.Record(newAuditEvent(models.AuditEventRegister, user, req.Email, device, err))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountSvc.SendVerificationEmail(user); err != nil {
+		return nil, err
+	}
+
+	if s.accountSvc.VerificationRequired(user) {
+		user.Password = ""
+		return &AuthResponse{User: user}, nil
+	}
+
+	return s.issueTokens(user, device)
+}
+
+// createUser creates the account of a registration.
+func (s *AuthService) createUser(req *RegisterRequest) (*models.User, error) {
 	existingUser, err := s.userRepo.GetByEmail(req.Email)
 	if err == nil && existingUser != nil {
 		return nil, errors.New("user with this email already exists")
 	}
 
-	hashedPassword, err := auth.HashPassword(req.Password)
+	if err := s.accountSvc.ValidatePassword(req.Password, &models.User{
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+	}); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.accountSvc.HashPassword(req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -68,18 +185,7 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 		return nil, err
 	}
 
-	token, err := s.jwtSvc.GenerateToken(user.ID, user.Email, user.IsActive)
-	if err != nil {
-		return nil, err
-	}
-
-	middleware.RecordAuthTokenIssued()
-
-	user.Password = ""
-	return &AuthResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	return user, nil
 }
 
 func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
@@ -88,57 +194,428 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 		middleware.RecordDatabaseQuery("user_login", time.Since(start))
 	}()
 
+	user, err := s.authenticate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaEnabled, err := s.mfaSvc.Enabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mfaEnabled {
+		challenge, err := s.mfaSvc.CreateChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthResponse{MFARequired: true, MFAToken: challenge}, nil
+	}
+
+	resetLoginAttempts(s.throttle, user.Email)
+	return s.issueTokens(user, &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent})
+}
+
+// authenticate checks the email and password under brute-force protection
+// and returns the user if they may sign in. A second factor is not checked,
+// so the failed attempts of the email are only reset by the caller once the
+// login is complete. The outcome is recorded in the audit log.
+func (s *AuthService) authenticate(req *LoginRequest) (*models.User, error) {
+	user, err := s.checkCredentials(req)
+	s.auditor.Record(newAuditEvent(models.AuditEventLogin, user, req.Email, &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent}, err))
+	return user, err
+}
+
+func (s *AuthService) checkCredentials(req *LoginRequest) (*models.User, error) {
+	if err := s.throttle.Check(req.Email, req.ClientIP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByEmail(req.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Answer as slowly as for a registered email
+			s.accountSvc.CheckDummyPassword(req.Password)
+			s.recordLoginFailure(req)
 			return nil, errors.New("invalid credentials")
 		}
 		return nil, err
 	}
 
+	if err := auth.CheckPassword(user.Password, req.Password); err != nil {
+		s.recordLoginFailure(req)
+		return nil, errors.New("invalid credentials")
+	}
+
+	// Only tell someone who knows the password that the account is deactivated
 	if !user.IsActive {
 		return nil, errors.New("user account is deactivated")
 	}
 
-	if err := auth.CheckPassword(user.Password, req.Password); err != nil {
-		return nil, errors.New("invalid credentials")
+	s.accountSvc.RehashPassword(user, req.Password)
+
+	if s.accountSvc.VerificationRequired(user) {
+		return nil, errors.New("email address is not verified")
 	}
 
-	token, err := s.jwtSvc.GenerateToken(user.ID, user.Email, user.IsActive)
+	return user, nil
+}
+
+// VerifyMFA completes a login that requires MFA by exchanging the challenge
+// token from the password step and a TOTP or recovery code for tokens.
+func (s *AuthService) VerifyMFA(req *VerifyMFARequest) (*AuthResponse, error) {
+	user, err := s.completeMFA(req)
 	if err != nil {
 		return nil, err
 	}
 
-	middleware.RecordAuthTokenIssued()
+	return s.issueTokens(user, &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent})
+}
+
+// completeMFA checks the second factor of a login and records the outcome in
+// the audit log.
+func (s *AuthService) completeMFA(req *VerifyMFARequest) (*models.User, error) {
+	user, err := s.mfaSvc.CompleteChallenge(req)
+	s.auditor.Record(newAuditEvent(models.AuditEventLoginMFA, user, "", &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent}, err))
+	return user, err
+}
+
+// TokenRejected records a request that was refused because its access token
+// or API key did not validate.
+func (s *AuthService) TokenRejected(ipAddress, userAgent string, err error) {
+	s.auditor.Record(newAuditEvent(models.AuditEventTokenRejected, nil, "", &Device{IPAddress: ipAddress, UserAgent: userAgent}, err))
+}
+
+// ValidateToken validates an access token or API key and loads the user it
+// was issued to. Tokens of OAuth2 clients carry no user; for those the
+// returned user is nil and the caller is identified by the claims.
+func (s *AuthService) ValidateToken(tokenString string) (*models.User, *auth.Claims, error) {
+	claims, err := s.ValidateClaims(tokenString)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if claims.IsClient() {
+		return nil, claims, nil
+	}
+
+	if !claims.IsActive {
+		return nil, nil, errors.New("user account is deactivated")
+	}
+
+	user, err := s.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !user.IsActive {
+		return nil, nil, errors.New("user account is deactivated")
+	}
 
 	user.Password = ""
-	return &AuthResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	return user, claims, nil
 }
 
-func (s *AuthService) ValidateToken(tokenString string) (*models.User, error) {
+// ValidateClaims verifies the token signature and expiry and rejects revoked
+// tokens and tokens of disabled OAuth2 clients. It does not load the user.
+// API keys are accepted in place of access tokens, see apiKeyClaims.
+func (s *AuthService) ValidateClaims(tokenString string) (*auth.Claims, error) {
+	if auth.IsAPIKey(tokenString) {
+		return s.apiKeyClaims(tokenString)
+	}
+
 	claims, err := s.jwtSvc.ValidateToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	if !claims.IsActive {
-		return nil, errors.New("user account is deactivated")
+	revoked, err := s.tokenRepo.IsAccessTokenRevoked(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("token has been revoked")
 	}
 
-	user, err := s.userRepo.GetByID(claims.UserID)
+	if claims.IsClient() {
+		active, err := s.oauthSvc.ClientActive(claims.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, errors.New("client is disabled")
+		}
+	}
+
+	if claims.SessionID != "" {
+		now := time.Now().UTC()
+		if err := s.tokenRepo.TouchSession(claims.SessionID, now, now.Add(-sessionTouchInterval)); err != nil {
+			log.Printf("Failed to record session activity: %v", err)
+		}
+	}
+
+	return claims, nil
+}
+
+// apiKeyClaims looks up an API key and builds claims for it like for an
+// access token of the key's user, in the user's default organization. The
+// scope is the part of the key's scopes the user still holds, so removing a
+// role from the user also takes the permission away from their keys.
+func (s *AuthService) apiKeyClaims(key string) (*auth.Claims, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("api_key_validate", time.Since(start))
+	}()
+
+	apiKey, err := s.apiKeyRepo.GetByHash(auth.HashToken(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !apiKey.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetByID(apiKey.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	claims, err := s.userClaims(user)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.applyOrganization(claims, nil); err != nil {
+		return nil, err
+	}
+
+	var scopes []string
+	held := claims.Scopes()
+	for _, scope := range strings.Fields(apiKey.Scopes) {
+		if containsString(held, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	claims.Scope = strings.Join(scopes, " ")
+	claims.APIKeyID = apiKey.ID
+	claims.Subject = fmt.Sprintf("%d", user.ID)
+	claims.Issuer = s.jwtSvc.Issuer()
+	claims.IssuedAt = jwt.NewNumericDate(apiKey.CreatedAt)
+	if apiKey.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*apiKey.ExpiresAt)
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchLastUsed(apiKey.ID, now); err != nil {
+			log.Printf("Failed to record API key use: %v", err)
+		}
+	}
+
+	return claims, nil
+}
+
+// Introspect authenticates the calling client and reports whether the token
+// is an active access or refresh token. The token type hint only decides
+// which kind is tried first.
+func (s *AuthService) Introspect(req *IntrospectionRequest) (*IntrospectionResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("token_introspect", time.Since(start))
+	}()
+
+	client, err := s.oauthSvc.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(strings.Fields(client.Scopes), models.PermissionTokensIntrospect) {
+		return nil, ErrIntrospectionNotAllowed
+	}
+
+	if req.TokenTypeHint == "refresh_token" {
+		if resp := s.introspectRefreshToken(req.Token); resp != nil {
+			return resp, nil
+		}
+		if resp := s.introspectAccessToken(req.Token); resp != nil {
+			return resp, nil
+		}
+	} else {
+		if resp := s.introspectAccessToken(req.Token); resp != nil {
+			return resp, nil
+		}
+		if resp := s.introspectRefreshToken(req.Token); resp != nil {
+			return resp, nil
+		}
+	}
+
+	return &IntrospectionResponse{Active: false}, nil
+}
+
+func (s *AuthService) introspectAccessToken(token string) *IntrospectionResponse {
+	user, claims, err := s.ValidateToken(token)
+	if err != nil {
+		return nil
+	}
+
+	resp := &IntrospectionResponse{
+		Active:      true,
+		Scope:       claims.Scope,
+		TokenType:   "Bearer",
+		Sub:         claims.Subject,
+		Iss:         claims.Issuer,
+		Jti:         claims.ID,
+		SubjectType: claims.SubjectType,
+		Roles:       claims.Roles,
+		OrgID:       claims.OrgID,
+		OrgRole:     claims.OrgRole,
+	}
+	if claims.ExpiresAt != nil {
+		resp.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.Iat = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		resp.Nbf = claims.NotBefore.Unix()
+	}
+	if claims.IsClient() {
+		resp.ClientID = claims.ClientID
+	} else {
+		resp.UserID = user.ID
+		resp.Username = user.Email
+	}
+	return resp
+}
+
+func (s *AuthService) introspectRefreshToken(token string) *IntrospectionResponse {
+	stored, err := s.tokenRepo.GetRefreshTokenByHash(auth.HashToken(token))
+	if err != nil || stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil || !user.IsActive {
+		return nil
+	}
+
+	return &IntrospectionResponse{
+		Active:      true,
+		TokenType:   "refresh_token",
+		Exp:         stored.ExpiresAt.Unix(),
+		Iat:         stored.CreatedAt.Unix(),
+		Sub:         fmt.Sprintf("%d", user.ID),
+		Iss:         s.jwtSvc.Issuer(),
+		SubjectType: auth.SubjectTypeUser,
+		UserID:      user.ID,
+		Username:    user.Email,
+		ClientID:    stored.ClientID,
+	}
+}
+
+// Refresh exchanges a refresh token for a new access/refresh token pair. The
+// presented token is rotated; presenting an already rotated token is treated
+// as theft and revokes every token in its family.
+func (s *AuthService) Refresh(req *RefreshRequest) (*AuthResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("token_refresh", time.Since(start))
+	}()
+
+	user, stored, err := s.checkRefreshToken(req.RefreshToken, "")
 	if err != nil {
 		return nil, err
 	}
 
+	return s.rotateTokens(user, stored, stored.OrganizationID)
+}
+
+// checkRefreshToken looks up a refresh token that may be rotated by the OAuth
+// client clientID, empty for first-party callers, and the active user it
+// belongs to. Presenting an already rotated token revokes its family.
+func (s *AuthService) checkRefreshToken(token, clientID string) (*models.User, *models.RefreshToken, error) {
+	stored, err := s.tokenRepo.GetRefreshTokenByHash(auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("invalid refresh token")
+		}
+		return nil, nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		if err := s.tokenRepo.RevokeFamily(stored.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("refresh token reuse detected")
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, nil, errors.New("refresh token expired")
+	}
+
+	if stored.ClientID != clientID {
+		return nil, nil, errors.New("refresh token was not issued to this client")
+	}
+
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("invalid refresh token")
+		}
+		return nil, nil, err
+	}
+
 	if !user.IsActive {
-		return nil, errors.New("user account is deactivated")
+		return nil, nil, errors.New("user account is deactivated")
 	}
 
-	user.Password = ""
-	return user, nil
+	return user, stored, nil
+}
+
+// Logout revokes the presented access token and, if given, the refresh token
+// family it belongs to. With AllSessions every refresh token of the user is
+// revoked as well.
+func (s *AuthService) Logout(accessToken string, req *LogoutRequest) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("user_logout", time.Since(start))
+	}()
+
+	claims, err := s.jwtSvc.ValidateToken(accessToken)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.RevokeAccessToken(claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	if req.AllSessions {
+		return s.tokenRepo.RevokeAllForUser(claims.UserID)
+	}
+
+	if req.RefreshToken == "" {
+		return nil
+	}
+
+	stored, err := s.tokenRepo.GetRefreshTokenByHash(auth.HashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if stored.UserID != claims.UserID {
+		return errors.New("refresh token does not belong to user")
+	}
+
+	return s.tokenRepo.RevokeFamily(stored.FamilyID)
 }
 
 func (s *AuthService) GetUserByID(userID uint) (*models.User, error) {
@@ -155,3 +632,205 @@ func (s *AuthService) GetUserByID(userID uint) (*models.User, error) {
 	user.Password = ""
 	return user, nil
 }
+
+// recordLoginFailure counts a failed password check towards the lockout of
+// the email and the client IP.
+func (s *AuthService) recordLoginFailure(req *LoginRequest) {
+	recordAuthFailure(s.throttle, req.Email, req.ClientIP)
+}
+
+// confirmPassword checks the password of a signed-in user before a sensitive
+// change. Wrong passwords count towards the same lockout as failed logins,
+// so a stolen access token can not be used to guess the password.
+func confirmPassword(throttle *auth.LoginThrottle, user *models.User, password, clientIP string) error {
+	if err := throttle.Check(user.Email, clientIP); err != nil {
+		return err
+	}
+
+	if err := auth.CheckPassword(user.Password, password); err != nil {
+		recordAuthFailure(throttle, user.Email, clientIP)
+		return ErrInvalidCredentials
+	}
+
+	resetLoginAttempts(throttle, user.Email)
+	return nil
+}
+
+// recordAuthFailure counts a wrong password or MFA code towards the lockout
+// of the email and the client IP.
+func recordAuthFailure(throttle *auth.LoginThrottle, email, clientIP string) {
+	middleware.RecordLoginFailure()
+
+	locked, err := throttle.RecordFailure(email, clientIP)
+	if err != nil {
+		log.Printf("Failed to record login attempt: %v", err)
+	}
+	for _, scope := range locked {
+		middleware.RecordLoginLockout(scope)
+	}
+}
+
+// resetLoginAttempts clears the failed attempts of the email once a login,
+// including its second factor, or a password confirmation has succeeded.
+func resetLoginAttempts(throttle *auth.LoginThrottle, email string) {
+	if err := throttle.RecordSuccess(email); err != nil {
+		log.Printf("Failed to reset login attempts: %v", err)
+	}
+}
+
+// issueTokens starts a new session for the user on the device, in their
+// default organization: it signs an access token and stores the first
+// refresh token of a new token family.
+func (s *AuthService) issueTokens(user *models.User, device *Device) (*AuthResponse, error) {
+	return s.issueClientTokens(user, device, "")
+}
+
+// issueClientTokens starts a session for the OAuth client clientID, whose
+// refresh tokens only that client can redeem. First-party logins have no
+// client.
+func (s *AuthService) issueClientTokens(user *models.User, device *Device, clientID string) (*AuthResponse, error) {
+	token, refresh, record, err := s.newTokenPair(user, "", clientID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.CreateSession(newSession(record, device), record); err != nil {
+		return nil, err
+	}
+
+	middleware.RecordAuthTokenIssued()
+
+	user.Password = ""
+	return &AuthResponse{
+		Token:        token,
+		RefreshToken: refresh,
+		User:         user,
+	}, nil
+}
+
+// rotateTokens replaces the refresh token old with a new token pair of the
+// same session, active in the organization orgID.
+func (s *AuthService) rotateTokens(user *models.User, old *models.RefreshToken, orgID *uint) (*AuthResponse, error) {
+	token, refresh, record, err := s.newTokenPair(user, old.FamilyID, old.ClientID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.RotateRefreshToken(old, record); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("refresh token reuse detected")
+		}
+		return nil, err
+	}
+
+	middleware.RecordAuthTokenIssued()
+
+	user.Password = ""
+	return &AuthResponse{
+		Token:        token,
+		RefreshToken: refresh,
+		User:         user,
+	}, nil
+}
+
+func (s *AuthService) newTokenPair(user *models.User, familyID, clientID string, orgID *uint) (string, string, *models.RefreshToken, error) {
+	claims, err := s.userClaims(user)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	orgID, err = s.applyOrganization(claims, orgID)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if familyID == "" {
+		familyID, err = auth.GenerateRandomToken(16)
+		if err != nil {
+			return "", "", nil, err
+		}
+	}
+	claims.SessionID = familyID
+
+	token, err := s.jwtSvc.GenerateToken(claims)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	refresh, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	record := &models.RefreshToken{
+		UserID:          user.ID,
+		TokenHash:       auth.HashToken(refresh),
+		FamilyID:        familyID,
+		AccessTokenID:   claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		ExpiresAt:       time.Now().Add(s.jwtSvc.RefreshExpirationTime()),
+		OrganizationID:  orgID,
+		ClientID:        clientID,
+	}
+
+	return token, refresh, record, nil
+}
+
+// userClaims builds the access token claims for a user, embedding the names
+// of their roles and the union of the roles' permissions as scope.
+func (s *AuthService) userClaims(user *models.User) (*auth.Claims, error) {
+	roles, err := s.roleRepo.GetUserRoles(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var roleNames, scopes []string
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		roleNames = append(roleNames, role.Name)
+		for _, permission := range role.Permissions {
+			if !seen[permission.Name] {
+				seen[permission.Name] = true
+				scopes = append(scopes, permission.Name)
+			}
+		}
+	}
+	sort.Strings(scopes)
+
+	return &auth.Claims{
+		UserID:      user.ID,
+		Email:       user.Email,
+		IsActive:    user.IsActive,
+		Roles:       roleNames,
+		Scope:       strings.Join(scopes, " "),
+		SubjectType: auth.SubjectTypeUser,
+	}, nil
+}
+
+// applyOrganization puts the user's membership in the organization orgID
+// into the claims and returns the organization. Without orgID, or when the
+// user is no longer a member of it, their default organization is used;
+// users without any organization get none.
+func (s *AuthService) applyOrganization(claims *auth.Claims, orgID *uint) (*uint, error) {
+	if orgID != nil {
+		member, err := s.orgRepo.GetMembership(*orgID, claims.UserID)
+		if err == nil {
+			claims.OrgID, claims.OrgRole = member.OrganizationID, member.Role
+			return &member.OrganizationID, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	members, err := s.orgRepo.ListMemberships(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	claims.OrgID, claims.OrgRole = members[0].OrganizationID, members[0].Role
+	return &members[0].OrganizationID, nil
+}
@@ -1,10 +1,18 @@
 package services
 
-import "auth-service/internal/models"
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/models"
+)
 
 type IAuthService interface {
 	Register(req *RegisterRequest) (*AuthResponse, error)
 	Login(req *LoginRequest) (*AuthResponse, error)
-	ValidateToken(tokenString string) (*models.User, error)
+	VerifyMFA(req *VerifyMFARequest) (*AuthResponse, error)
+	ValidateToken(tokenString string) (*models.User, *auth.Claims, error)
+	TokenRejected(ipAddress, userAgent string, err error)
+	Refresh(req *RefreshRequest) (*AuthResponse, error)
+	Logout(accessToken string, req *LogoutRequest) error
+	Introspect(req *IntrospectionRequest) (*IntrospectionResponse, error)
 	GetUserByID(userID uint) (*models.User, error)
 }
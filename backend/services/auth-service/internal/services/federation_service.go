@@ -0,0 +1,410 @@
+package services
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/federation"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProviderNotFound      = errors.New("identity provider not found")
+	ErrInvalidFederatedState = errors.New("invalid or expired sign-in state")
+	// ErrFederatedAuthFailed is returned when the provider reports an error
+	// or its response fails verification.
+	ErrFederatedAuthFailed        = errors.New("sign-in with the identity provider failed")
+	ErrFederatedEmailUnverified   = errors.New("the identity provider did not return a verified email address")
+	ErrFederatedAccountNotAllowed = errors.New("this account is not allowed to sign in with the identity provider")
+	ErrFederatedEmailInUse        = errors.New("an account with this email address already exists, sign in with your password")
+)
+
+// FederationService signs users in with upstream OpenID Connect providers.
+// The first sign-in of an external account links it to the local user with
+// the same email address or provisions a new user, depending on the
+// provider configuration. Afterwards the user gets the same tokens as with a
+// password login, including the MFA step.
+type FederationService struct {
+	providers    map[string]*federation.Provider
+	names        []string
+	authSvc      *AuthService
+	oidcSvc      *OIDCService
+	mfaSvc       *MFAService
+	userRepo     *repositories.UserRepository
+	identityRepo *repositories.IdentityRepository
+	tokenRepo    *repositories.TokenRepository
+	roleRepo     *repositories.RoleRepository
+	stateTTL     time.Duration
+}
+
+func NewFederationService(providers []*federation.Provider, authSvc *AuthService, oidcSvc *OIDCService, mfaSvc *MFAService, userRepo *repositories.UserRepository, identityRepo *repositories.IdentityRepository, tokenRepo *repositories.TokenRepository, roleRepo *repositories.RoleRepository, stateTTL time.Duration) *FederationService {
+	s := &FederationService{
+		providers:    make(map[string]*federation.Provider, len(providers)),
+		authSvc:      authSvc,
+		oidcSvc:      oidcSvc,
+		mfaSvc:       mfaSvc,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		tokenRepo:    tokenRepo,
+		roleRepo:     roleRepo,
+		stateTTL:     stateTTL,
+	}
+	for _, provider := range providers {
+		name := provider.Config().Name
+		s.providers[name] = provider
+		s.names = append(s.names, name)
+	}
+	return s
+}
+
+type IdentityProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type FederatedLoginRequest struct {
+	Provider string
+	// Authorize is the authorization request of the login page the sign-in
+	// was started from, if any
+	Authorize *AuthorizeRequest
+}
+
+// FederatedLoginStart is the redirect to the provider. State must also be
+// bound to the browser, so a callback can not be replayed in another one.
+type FederatedLoginStart struct {
+	RedirectURL string
+	State       string
+}
+
+type FederatedCallbackRequest struct {
+	Provider         string `form:"-"`
+	Code             string `form:"code"`
+	State            string `form:"state"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+	// ClientIP and UserAgent are set by the handler for the session and the
+	// audit log
+	ClientIP  string `form:"-"`
+	UserAgent string `form:"-"`
+}
+
+// FederatedLoginResult is the outcome of a sign-in. For a direct sign-in
+// Auth carries the tokens; for a sign-in started from the authorization
+// endpoint Authorize is the original request and Result the redirect with
+// the authorization code or the MFA challenge.
+type FederatedLoginResult struct {
+	Auth      *AuthResponse
+	Authorize *AuthorizeRequest
+	Result    *AuthorizeResult
+}
+
+// Providers lists the configured identity providers.
+func (s *FederationService) Providers() []IdentityProviderResponse {
+	providers := make([]IdentityProviderResponse, 0, len(s.names))
+	for _, name := range s.names {
+		config := s.providers[name].Config()
+		displayName := config.DisplayName
+		if displayName == "" {
+			displayName = config.Name
+		}
+		providers = append(providers, IdentityProviderResponse{Name: config.Name, DisplayName: displayName})
+	}
+	return providers
+}
+
+// Begin starts a sign-in at the provider and returns the URL to redirect the
+// browser to. The state, nonce and PKCE verifier are kept until the
+// provider redirects back.
+func (s *FederationService) Begin(req *FederatedLoginRequest) (*FederatedLoginStart, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("federated_login_begin", time.Since(start))
+	}()
+
+	provider, ok := s.providers[req.Provider]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+
+	var authorize string
+	if req.Authorize != nil {
+		if err := s.oidcSvc.ValidateAuthorizeRequest(req.Authorize); err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(req.Authorize)
+		if err != nil {
+			return nil, err
+		}
+		authorize = string(data)
+	}
+
+	state, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := auth.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	redirectURL, err := provider.AuthCodeURL(state, nonce, federation.S256Challenge(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.CreateFederatedLoginState(&models.FederatedLoginState{
+		StateHash:        auth.HashToken(state),
+		Provider:         req.Provider,
+		Nonce:            nonce,
+		CodeVerifier:     verifier,
+		AuthorizeRequest: authorize,
+		ExpiresAt:        time.Now().UTC().Add(s.stateTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &FederatedLoginStart{RedirectURL: redirectURL, State: state}, nil
+}
+
+// Complete handles the redirect back from the provider: it redeems the code,
+// resolves the local user and signs them in. Once the state is known the
+// result carries the authorization request even when the sign-in fails, so
+// the login page can be shown again. The outcome is recorded in the audit
+// log as a login with the provider.
+func (s *FederationService) Complete(req *FederatedCallbackRequest) (*FederatedLoginResult, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("federated_login_complete", time.Since(start))
+	}()
+
+	device := &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent}
+	result, user, email, err := s.complete(req, device)
+	if !errors.Is(err, ErrProviderNotFound) {
+		s.audit(models.AuditEventLogin, user, email, req.Provider, device, err)
+	}
+	return result, err
+}
+
+// complete signs the user in and also returns the user, or only the email
+// of the external account when no user was resolved, for the audit log.
+func (s *FederationService) complete(req *FederatedCallbackRequest, device *Device) (*FederatedLoginResult, *models.User, string, error) {
+	provider, ok := s.providers[req.Provider]
+	if !ok {
+		return nil, nil, "", ErrProviderNotFound
+	}
+
+	state, err := s.tokenRepo.ConsumeFederatedLoginState(auth.HashToken(req.State), req.Provider)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, "", ErrInvalidFederatedState
+		}
+		return nil, nil, "", err
+	}
+
+	result := &FederatedLoginResult{}
+	if state.AuthorizeRequest != "" {
+		result.Authorize = &AuthorizeRequest{}
+		if err := json.Unmarshal([]byte(state.AuthorizeRequest), result.Authorize); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	if req.Error != "" {
+		return result, nil, "", fmt.Errorf("%w: %s", ErrFederatedAuthFailed, req.Error)
+	}
+
+	identity, err := provider.Exchange(req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		log.Printf("Federated sign-in with %s failed: %v", req.Provider, err)
+		return result, nil, "", ErrFederatedAuthFailed
+	}
+
+	user, err := s.resolveUser(provider.Config(), identity, device)
+	if err != nil {
+		return result, nil, identity.Email, err
+	}
+
+	if !user.IsActive {
+		return result, user, "", errors.New("user account is deactivated")
+	}
+
+	mfaEnabled, err := s.mfaSvc.Enabled(user.ID)
+	if err != nil {
+		return result, user, "", err
+	}
+
+	var challenge string
+	if mfaEnabled {
+		challenge, err = s.mfaSvc.CreateChallenge(user)
+		if err != nil {
+			return result, user, "", err
+		}
+	}
+
+	if result.Authorize != nil {
+		if mfaEnabled {
+			result.Result = &AuthorizeResult{MFAToken: challenge}
+			return result, user, "", nil
+		}
+		// The client may have been disabled while the user was away
+		if err := s.oidcSvc.ValidateAuthorizeRequest(result.Authorize); err != nil {
+			return result, user, "", err
+		}
+		result.Result, err = s.oidcSvc.issueCode(result.Authorize, user, device)
+		return result, user, "", err
+	}
+
+	if mfaEnabled {
+		result.Auth = &AuthResponse{MFARequired: true, MFAToken: challenge}
+		return result, user, "", nil
+	}
+
+	result.Auth, err = s.authSvc.issueTokens(user, device)
+	return result, user, "", err
+}
+
+// audit records an event of a sign-in with the identity provider.
+func (s *FederationService) audit(eventType string, user *models.User, email, provider string, device *Device, err error) {
+	event := newAuditEvent(eventType, user, email, device, err)
+	event.Provider = provider
+	s.authSvc.auditor.Record(event)
+}
+
+// ListIdentities returns the external accounts linked to a user.
+func (s *FederationService) ListIdentities(userID uint) ([]*models.Identity, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("identity_list", time.Since(start))
+	}()
+
+	return s.identityRepo.ListByUser(userID)
+}
+
+// resolveUser finds the user linked to the external account, linking or
+// provisioning one on the first sign-in. Linking and provisioning are
+// recorded in the audit log.
+func (s *FederationService) resolveUser(config *federation.ProviderConfig, identity *federation.Identity, device *Device) (*models.User, error) {
+	if !config.EmailAllowed(identity.Email) {
+		return nil, ErrFederatedAccountNotAllowed
+	}
+
+	now := time.Now().UTC()
+	linked, err := s.identityRepo.GetByProviderSubject(config.Name, identity.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(linked.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrFederatedAccountNotAllowed
+			}
+			return nil, err
+		}
+		if err := s.identityRepo.RecordLogin(linked.ID, identity.Email, now); err != nil {
+			log.Printf("Failed to record federated sign-in: %v", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Accounts are only matched and created by verified email addresses, so
+	// an external account can not take over someone else's local account
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrFederatedEmailUnverified
+	}
+
+	link := &models.Identity{
+		Provider:    config.Name,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		LastLoginAt: &now,
+	}
+
+	existing, err := s.userRepo.GetByEmail(identity.Email)
+	if err == nil {
+		if !config.LinkByEmail {
+			return nil, ErrFederatedEmailInUse
+		}
+		link.UserID = existing.ID
+		err := s.identityRepo.Create(link)
+		s.audit(models.AuditEventIdentityLink, existing, "", config.Name, device, err)
+		if err != nil {
+			return nil, err
+		}
+		if existing.EmailVerifiedAt == nil {
+			if err := s.userRepo.SetEmailVerified(existing.ID, now); err != nil {
+				return nil, err
+			}
+			existing.EmailVerifiedAt = &now
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if !config.AutoProvision {
+		return nil, ErrFederatedAccountNotAllowed
+	}
+	user, err := s.provision(config, identity, link)
+	s.audit(models.AuditEventUserProvision, user, identity.Email, config.Name, device, err)
+	return user, err
+}
+
+// provision creates the local user for an external account. The user gets a
+// random password and can set one with the password reset flow.
+func (s *FederationService) provision(config *federation.ProviderConfig, identity *federation.Identity, link *models.Identity) (*models.User, error) {
+	password, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.authSvc.accountSvc.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	firstName, lastName := identity.GivenName, identity.FamilyName
+	if firstName == "" && lastName == "" {
+		if parts := strings.Fields(identity.Name); len(parts) > 0 {
+			firstName, lastName = parts[0], strings.Join(parts[1:], " ")
+		} else {
+			firstName, _, _ = strings.Cut(identity.Email, "@")
+		}
+	}
+
+	var roles []models.Role
+	for _, name := range config.DefaultRoles {
+		role, err := s.roleRepo.GetRoleByName(name)
+		if err != nil {
+			log.Printf("Default role %s of identity provider %s not found: %v", name, config.Name, err)
+			continue
+		}
+		roles = append(roles, *role)
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{
+		Email:           identity.Email,
+		Password:        hashedPassword,
+		FirstName:       firstName,
+		LastName:        lastName,
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.identityRepo.CreateWithUser(user, link, roles); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,10 @@
+package services
+
+import "auth-service/internal/models"
+
+type IFederationService interface {
+	Providers() []IdentityProviderResponse
+	Begin(req *FederatedLoginRequest) (*FederatedLoginStart, error)
+	Complete(req *FederatedCallbackRequest) (*FederatedLoginResult, error)
+	ListIdentities(userID uint) ([]*models.Identity, error)
+}
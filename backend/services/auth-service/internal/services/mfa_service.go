@@ -0,0 +1,420 @@
+package services
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+	// maxChallengeAttempts bounds how many codes can be tried against one MFA
+	// challenge before the user has to log in again.
+	maxChallengeAttempts = 5
+)
+
+var (
+	ErrInvalidMFACode     = errors.New("invalid MFA code")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// MFAService manages TOTP enrollment and recovery codes, and the MFA
+// challenge that is issued by the password step of a login.
+type MFAService struct {
+	mfaRepo      *repositories.MFARepository
+	userRepo     *repositories.UserRepository
+	tokenRepo    *repositories.TokenRepository
+	box          *auth.SecretBox
+	throttle     *auth.LoginThrottle
+	issuer       string
+	challengeTTL time.Duration
+}
+
+func NewMFAService(mfaRepo *repositories.MFARepository, userRepo *repositories.UserRepository, tokenRepo *repositories.TokenRepository, box *auth.SecretBox, throttle *auth.LoginThrottle, issuer string, challengeTTL time.Duration) *MFAService {
+	return &MFAService{
+		mfaRepo:      mfaRepo,
+		userRepo:     userRepo,
+		tokenRepo:    tokenRepo,
+		box:          box,
+		throttle:     throttle,
+		issuer:       issuer,
+		challengeTTL: challengeTTL,
+	}
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+	// ClientIP is set by the handler for brute-force protection
+	ClientIP string `json:"-"`
+}
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+	// ClientIP and UserAgent are set by the handler for the session
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type MFASetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFAStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (s *MFAService) Status(userID uint) (*MFAStatusResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("mfa_status", time.Since(start))
+	}()
+
+	factor, err := s.mfaRepo.GetFactor(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &MFAStatusResponse{}, nil
+		}
+		return nil, err
+	}
+
+	if factor.EnabledAt == nil {
+		return &MFAStatusResponse{}, nil
+	}
+
+	remaining, err := s.mfaRepo.CountUnusedRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAStatusResponse{Enabled: true, RecoveryCodesRemaining: remaining}, nil
+}
+
+// Setup starts enrollment by generating a new secret. MFA is not enforced
+// until the first code is confirmed with Enable.
+func (s *MFAService) Setup(userID uint) (*MFASetupResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("mfa_setup", time.Since(start))
+	}()
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if factor, err := s.mfaRepo.GetFactor(userID); err == nil && factor.EnabledAt != nil {
+		return nil, errors.New("MFA is already enabled")
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := s.box.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	factor := &models.TOTPFactor{
+		UserID:          userID,
+		EncryptedSecret: encrypted,
+	}
+	if err := s.mfaRepo.SaveFactor(factor); err != nil {
+		return nil, err
+	}
+
+	return &MFASetupResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Enable confirms enrollment with a code from the authenticator and returns
+// the recovery codes. They are only shown this once.
+func (s *MFAService) Enable(userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("mfa_enable", time.Since(start))
+	}()
+
+	factor, err := s.mfaRepo.GetFactor(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("MFA setup has not been started")
+		}
+		return nil, err
+	}
+
+	if factor.EnabledAt != nil {
+		return nil, errors.New("MFA is already enabled")
+	}
+
+	secret, err := s.box.Open(factor.EncryptedSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := auth.ValidateTOTP(secret, req.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.EnableFactor(userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable removes the factor. It requires the password and a current TOTP or
+// recovery code.
+func (s *MFAService) Disable(userID uint, req *DisableMFARequest) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("mfa_disable", time.Since(start))
+	}()
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if err := confirmPassword(s.throttle, user, req.Password, req.ClientIP); err != nil {
+		return err
+	}
+
+	factor, err := s.enabledFactor(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.verifyCode(factor, req.Code); err != nil {
+		return err
+	}
+
+	return s.mfaRepo.DeleteFactor(userID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user.
+func (s *MFAService) RegenerateRecoveryCodes(userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("mfa_recovery_codes", time.Since(start))
+	}()
+
+	factor, err := s.enabledFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCode(factor, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Enabled reports whether login requires a second factor for the user.
+func (s *MFAService) Enabled(userID uint) (bool, error) {
+	factor, err := s.mfaRepo.GetFactor(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return factor.EnabledAt != nil, nil
+}
+
+// CreateChallenge returns a short-lived, single-use token that stands for a
+// login whose password step succeeded.
+func (s *MFAService) CreateChallenge(user *models.User) (string, error) {
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	record := &models.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeMFAChallenge,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(s.challengeTTL),
+	}
+	if err := s.tokenRepo.CreateOneTimeToken(record); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// CompleteChallenge checks the code for an MFA challenge and returns the user
+// the challenge was issued to. The challenge is consumed on success and
+// invalidated after too many wrong codes. Wrong codes also count towards the
+// login lockout of the user's email and the client IP, so that starting new
+// challenges does not give unlimited guesses; the lockout is reset once a
+// code is accepted.
+func (s *MFAService) CompleteChallenge(req *VerifyMFARequest) (*models.User, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("mfa_verify", time.Since(start))
+	}()
+
+	hash := auth.HashToken(req.MFAToken)
+	challenge, err := s.tokenRepo.GetOneTimeToken(hash, models.TokenPurposeMFAChallenge)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired MFA token")
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(challenge.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired MFA token")
+		}
+		return nil, err
+	}
+
+	if err := s.throttle.Check(user.Email, req.ClientIP); err != nil {
+		return nil, err
+	}
+
+	factor, err := s.enabledFactor(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCode(factor, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			recordAuthFailure(s.throttle, user.Email, req.ClientIP)
+			if err := s.tokenRepo.RecordFailedAttempt(challenge.ID, maxChallengeAttempts); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if _, err := s.tokenRepo.ConsumeOneTimeToken(hash, models.TokenPurposeMFAChallenge); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired MFA token")
+		}
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, errors.New("user account is deactivated")
+	}
+
+	resetLoginAttempts(s.throttle, user.Email)
+	return user, nil
+}
+
+func (s *MFAService) enabledFactor(userID uint) (*models.TOTPFactor, error) {
+	factor, err := s.mfaRepo.GetFactor(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("MFA is not enabled")
+		}
+		return nil, err
+	}
+
+	if factor.EnabledAt == nil {
+		return nil, errors.New("MFA is not enabled")
+	}
+	return factor, nil
+}
+
+// verifyCode accepts either a TOTP code that was not used before or an
+// unused recovery code.
+func (s *MFAService) verifyCode(factor *models.TOTPFactor, code string) error {
+	secret, err := s.box.Open(factor.EncryptedSecret)
+	if err != nil {
+		return err
+	}
+
+	if step, ok := auth.ValidateTOTP(secret, code, time.Now()); ok {
+		fresh, err := s.mfaRepo.UseStep(factor.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(factor.UserID, auth.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes returns codes formatted for display, like
+// "abcd-efgh-ijkl-mnop", together with the hashes to store.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12]+"-"+raw[12:16])
+		hashes = append(hashes, auth.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
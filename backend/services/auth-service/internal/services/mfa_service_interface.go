@@ -0,0 +1,9 @@
+package services
+
+type IMFAService interface {
+	Status(userID uint) (*MFAStatusResponse, error)
+	Setup(userID uint) (*MFASetupResponse, error)
+	Enable(userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error)
+	Disable(userID uint, req *DisableMFARequest) error
+	RegenerateRecoveryCodes(userID uint, req *MFACodeRequest) (*RecoveryCodesResponse, error)
+}
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OAuth2 error codes from RFC 6749 section 5.2.
+const (
+	OAuthErrorInvalidRequest       = "invalid_request"
+	OAuthErrorInvalidClient        = "invalid_client"
+	OAuthErrorInvalidGrant         = "invalid_grant"
+	OAuthErrorUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrorInvalidScope         = "invalid_scope"
+	OAuthErrorUnauthorizedClient   = "unauthorized_client"
+	// Authorization endpoint errors (RFC 6749 section 4.1.2.1, OpenID Connect)
+	OAuthErrorUnsupportedResponseType = "unsupported_response_type"
+	OAuthErrorLoginRequired           = "login_required"
+)
+
+// OAuthError is an error reported to OAuth2 clients in the standard
+// {"error", "error_description"} format.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Description
+}
+
+var (
+	ErrClientNotFound = errors.New("oauth client not found")
+	ErrInvalidClient  = &OAuthError{Code: OAuthErrorInvalidClient, Description: "client authentication failed"}
+)
+
+type OAuthService struct {
+	clientRepo *repositories.OAuthClientRepository
+	roleRepo   *repositories.RoleRepository
+	jwtSvc     *auth.JWTService
+	tokenTTL   time.Duration
+}
+
+func NewOAuthService(clientRepo *repositories.OAuthClientRepository, roleRepo *repositories.RoleRepository, jwtSvc *auth.JWTService, tokenTTL time.Duration) *OAuthService {
+	return &OAuthService{
+		clientRepo: clientRepo,
+		roleRepo:   roleRepo,
+		jwtSvc:     jwtSvc,
+		tokenTTL:   tokenTTL,
+	}
+}
+
+// CreateOAuthClientRequest registers a client. Public clients get no secret
+// and can only use the authorization code flow with PKCE.
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	Description  string   `json:"description" binding:"max=255"`
+	Scopes       []string `json:"scopes"`
+	RedirectURIs []string `json:"redirect_uris"`
+	IsPublic     bool     `json:"is_public"`
+}
+
+// UpdateOAuthClientRequest changes the fields that are set; omitted fields
+// are left unchanged.
+type UpdateOAuthClientRequest struct {
+	Name         *string  `json:"name" binding:"omitempty,max=100"`
+	Description  *string  `json:"description" binding:"omitempty,max=255"`
+	Scopes       []string `json:"scopes"`
+	RedirectURIs []string `json:"redirect_uris"`
+	IsActive     *bool    `json:"is_active"`
+}
+
+// OAuthClientSecretResponse is returned when a client is created or its
+// secret is rotated. The secret is not stored and can not be shown again.
+// Public clients have no secret.
+type OAuthClientSecretResponse struct {
+	Client       *models.OAuthClient `json:"client"`
+	ClientSecret string              `json:"client_secret,omitempty"`
+}
+
+type ClientCredentialsRequest struct {
+	ClientID     string
+	ClientSecret string
+	Scope        string
+}
+
+// TokenResponse is the RFC 6749 access token response. The authorization
+// code grant also returns a refresh token and, for the openid scope, an
+// OpenID Connect ID token.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+func (s *OAuthService) ListClients() ([]*models.OAuthClient, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oauth_clients_list", time.Since(start))
+	}()
+
+	return s.clientRepo.List()
+}
+
+func (s *OAuthService) GetClient(id uint) (*models.OAuthClient, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oauth_client_get", time.Since(start))
+	}()
+
+	return s.getClient(id)
+}
+
+// CreateClient registers a client with a random client ID and secret.
+func (s *OAuthService) CreateClient(req *CreateOAuthClientRequest) (*OAuthClientSecretResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oauth_client_create", time.Since(start))
+	}()
+
+	scopes, err := s.validateScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	redirectURIs, err := validateRedirectURIs(req.RedirectURIs)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsPublic && redirectURIs == "" {
+		return nil, errors.New("public clients need at least one redirect URI")
+	}
+
+	clientID, err := auth.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &models.OAuthClient{
+		ClientID:     clientID,
+		Name:         req.Name,
+		Description:  req.Description,
+		Scopes:       scopes,
+		RedirectURIs: redirectURIs,
+		IsPublic:     req.IsPublic,
+		IsActive:     true,
+	}
+
+	var secret string
+	if !req.IsPublic {
+		secret, err = auth.GenerateRandomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		client.SecretHash = auth.HashToken(secret)
+	}
+
+	if err := s.clientRepo.Create(client); err != nil {
+		return nil, err
+	}
+
+	return &OAuthClientSecretResponse{Client: client, ClientSecret: secret}, nil
+}
+
+func (s *OAuthService) UpdateClient(id uint, req *UpdateOAuthClientRequest) (*models.OAuthClient, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oauth_client_update", time.Since(start))
+	}()
+
+	client, err := s.getClient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		client.Name = *req.Name
+	}
+	if req.Description != nil {
+		client.Description = *req.Description
+	}
+	if req.Scopes != nil {
+		scopes, err := s.validateScopes(req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		client.Scopes = scopes
+	}
+	if req.RedirectURIs != nil {
+		redirectURIs, err := validateRedirectURIs(req.RedirectURIs)
+		if err != nil {
+			return nil, err
+		}
+		if client.IsPublic && redirectURIs == "" {
+			return nil, errors.New("public clients need at least one redirect URI")
+		}
+		client.RedirectURIs = redirectURIs
+	}
+	if req.IsActive != nil {
+		client.IsActive = *req.IsActive
+	}
+
+	if err := s.clientRepo.Update(client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// RotateSecret replaces the client secret. Tokens issued with the old secret
+// stay valid until they expire.
+func (s *OAuthService) RotateSecret(id uint) (*OAuthClientSecretResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oauth_client_rotate_secret", time.Since(start))
+	}()
+
+	client, err := s.getClient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.IsPublic {
+		return nil, errors.New("public clients have no secret")
+	}
+
+	secret, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	client.SecretHash = auth.HashToken(secret)
+	if err := s.clientRepo.Update(client); err != nil {
+		return nil, err
+	}
+
+	return &OAuthClientSecretResponse{Client: client, ClientSecret: secret}, nil
+}
+
+// DeleteClient removes the client. Its outstanding tokens are rejected from
+// then on, see ClientActive.
+func (s *OAuthService) DeleteClient(id uint) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oauth_client_delete", time.Since(start))
+	}()
+
+	if _, err := s.getClient(id); err != nil {
+		return err
+	}
+	return s.clientRepo.Delete(id)
+}
+
+// ClientCredentials authenticates a client and issues an access token for
+// the requested scope, which must be a subset of the client's allowed
+// scopes. Without a requested scope all allowed scopes are granted.
+func (s *OAuthService) ClientCredentials(req *ClientCredentialsRequest) (*TokenResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oauth_client_credentials", time.Since(start))
+	}()
+
+	client, err := s.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := strings.Fields(client.Scopes)
+	granted := allowed
+	if req.Scope != "" {
+		granted = strings.Fields(req.Scope)
+		for _, scope := range granted {
+			if !containsString(allowed, scope) {
+				return nil, &OAuthError{Code: OAuthErrorInvalidScope, Description: fmt.Sprintf("scope not allowed for client: %s", scope)}
+			}
+		}
+	}
+
+	claims := &auth.Claims{
+		IsActive:    true,
+		Scope:       strings.Join(granted, " "),
+		SubjectType: auth.SubjectTypeClient,
+		ClientID:    client.ClientID,
+	}
+
+	token, err := s.jwtSvc.GenerateTokenWithTTL(claims, s.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	middleware.RecordAuthTokenIssued()
+
+	return &TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.tokenTTL.Seconds()),
+		Scope:       claims.Scope,
+	}, nil
+}
+
+// ClientActive reports whether the client still exists and is enabled, so
+// tokens of disabled or deleted clients are rejected before they expire.
+func (s *OAuthService) ClientActive(clientID string) (bool, error) {
+	client, err := s.clientRepo.GetByClientID(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return client.IsActive, nil
+}
+
+// authenticateClient authenticates a confidential client by its secret.
+func (s *OAuthService) authenticateClient(clientID, secret string) (*models.OAuthClient, error) {
+	if clientID == "" || secret == "" {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := s.clientRepo.GetByClientID(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, err
+	}
+
+	if client.IsPublic {
+		return nil, ErrInvalidClient
+	}
+	if subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(auth.HashToken(secret))) != 1 {
+		return nil, ErrInvalidClient
+	}
+	if !client.IsActive {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// authenticateCodeClient authenticates the client of an authorization code
+// or refresh token grant. Public clients only identify themselves, the
+// possession of the code or refresh token (and the PKCE verifier) is their
+// proof; confidential clients must present their secret.
+func (s *OAuthService) authenticateCodeClient(clientID, secret string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := s.clientRepo.GetByClientID(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, err
+	}
+
+	if !client.IsPublic {
+		return s.authenticateClient(clientID, secret)
+	}
+	if !client.IsActive {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// activeClient returns the client for an authorization request, which must
+// be active and have redirect URIs.
+func (s *OAuthService) activeClient(clientID string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, ErrClientNotFound
+	}
+
+	client, err := s.clientRepo.GetByClientID(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientNotFound
+		}
+		return nil, err
+	}
+
+	if !client.IsActive || client.RedirectURIs == "" {
+		return nil, ErrClientNotFound
+	}
+	return client, nil
+}
+
+func (s *OAuthService) getClient(id uint) (*models.OAuthClient, error) {
+	client, err := s.clientRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientNotFound
+		}
+		return nil, err
+	}
+	return client, nil
+}
+
+// validateScopes checks that every scope is a known permission and returns
+// them space separated.
+func (s *OAuthService) validateScopes(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", nil
+	}
+
+	permissions, err := s.roleRepo.GetPermissionsByName(scopes)
+	if err != nil {
+		return "", err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Name] = true
+	}
+	for _, scope := range scopes {
+		if !found[scope] {
+			return "", fmt.Errorf("unknown permission: %s", scope)
+		}
+	}
+
+	return strings.Join(scopes, " "), nil
+}
+
+// validateRedirectURIs checks that every URI is absolute and has no
+// fragment (RFC 6749 section 3.1.2) and returns them space separated.
+// Custom schemes are allowed for native apps.
+func validateRedirectURIs(uris []string) (string, error) {
+	for _, uri := range uris {
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Scheme == "" || parsed.Fragment != "" || strings.ContainsAny(uri, " \t\n") {
+			return "", fmt.Errorf("invalid redirect URI: %s", uri)
+		}
+		if (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host == "" {
+			return "", fmt.Errorf("invalid redirect URI: %s", uri)
+		}
+	}
+	return strings.Join(uris, " "), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
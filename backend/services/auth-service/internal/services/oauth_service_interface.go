@@ -0,0 +1,13 @@
+package services
+
+import "auth-service/internal/models"
+
+type IOAuthService interface {
+	ListClients() ([]*models.OAuthClient, error)
+	GetClient(id uint) (*models.OAuthClient, error)
+	CreateClient(req *CreateOAuthClientRequest) (*OAuthClientSecretResponse, error)
+	UpdateClient(id uint, req *UpdateOAuthClientRequest) (*models.OAuthClient, error)
+	RotateSecret(id uint) (*OAuthClientSecretResponse, error)
+	DeleteClient(id uint) error
+	ClientCredentials(req *ClientCredentialsRequest) (*TokenResponse, error)
+}
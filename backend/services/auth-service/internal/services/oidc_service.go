@@ -0,0 +1,414 @@
+package services
+
+import (
+	"auth-service/internal/auth"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OpenID Connect scopes. They select the claims of the ID token and have no
+// effect on the permissions in the access token.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// ErrInvalidAuthorizeClient is returned for authorization requests with an
+// unknown client or an unregistered redirect URI. Such errors must be shown
+// to the user instead of being redirected to the client.
+var ErrInvalidAuthorizeClient = errors.New("unknown client or unregistered redirect_uri")
+
+// OIDCService implements the OpenID Connect authorization code flow with
+// PKCE for first-party clients. Users sign in on the login page of
+// auth-service; there is no consent step.
+type OIDCService struct {
+	authSvc   *AuthService
+	oauthSvc  *OAuthService
+	mfaSvc    *MFAService
+	userRepo  *repositories.UserRepository
+	tokenRepo *repositories.TokenRepository
+	jwtSvc    *auth.JWTService
+	codeTTL   time.Duration
+}
+
+func NewOIDCService(authSvc *AuthService, oauthSvc *OAuthService, mfaSvc *MFAService, userRepo *repositories.UserRepository, tokenRepo *repositories.TokenRepository, jwtSvc *auth.JWTService, codeTTL time.Duration) *OIDCService {
+	return &OIDCService{
+		authSvc:   authSvc,
+		oauthSvc:  oauthSvc,
+		mfaSvc:    mfaSvc,
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		jwtSvc:    jwtSvc,
+		codeTTL:   codeTTL,
+	}
+}
+
+// AuthorizeRequest holds the parameters of an authorization request. They
+// arrive in the query string and are carried through the login form.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	Prompt              string `form:"prompt"`
+}
+
+// AuthorizeLoginRequest is the login form of the authorization endpoint. The
+// second step of an MFA login sends MFAToken and Code instead of the
+// password.
+type AuthorizeLoginRequest struct {
+	AuthorizeRequest
+	Email     string `form:"email"`
+	Password  string `form:"password"`
+	MFAToken  string `form:"mfa_token"`
+	Code      string `form:"code"`
+	ClientIP  string `form:"-"`
+	UserAgent string `form:"-"`
+}
+
+// AuthorizeResult is either the redirect back to the client carrying the
+// authorization code, or an MFA challenge to complete first.
+type AuthorizeResult struct {
+	RedirectURL string
+	MFAToken    string
+}
+
+type CodeExchangeRequest struct {
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+}
+
+type RefreshGrantRequest struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+}
+
+// UserInfoResponse is the OpenID Connect UserInfo response.
+type UserInfoResponse struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+}
+
+// ValidateAuthorizeRequest checks an authorization request. It returns
+// ErrInvalidAuthorizeClient if the client or redirect URI is invalid, and an
+// *OAuthError that is to be reported to the redirect URI otherwise.
+func (s *OIDCService) ValidateAuthorizeRequest(req *AuthorizeRequest) error {
+	client, err := s.oauthSvc.activeClient(req.ClientID)
+	if err != nil {
+		if errors.Is(err, ErrClientNotFound) {
+			return ErrInvalidAuthorizeClient
+		}
+		return err
+	}
+
+	if req.RedirectURI == "" || !containsString(strings.Fields(client.RedirectURIs), req.RedirectURI) {
+		return ErrInvalidAuthorizeClient
+	}
+
+	if req.ResponseType != "code" {
+		return &OAuthError{Code: OAuthErrorUnsupportedResponseType, Description: "only response_type=code is supported"}
+	}
+
+	if req.CodeChallenge == "" {
+		return &OAuthError{Code: OAuthErrorInvalidRequest, Description: "code_challenge is required"}
+	}
+	if req.CodeChallengeMethod != "S256" {
+		return &OAuthError{Code: OAuthErrorInvalidRequest, Description: "code_challenge_method must be S256"}
+	}
+	if len(req.CodeChallenge) < 43 || len(req.CodeChallenge) > 128 {
+		return &OAuthError{Code: OAuthErrorInvalidRequest, Description: "invalid code_challenge"}
+	}
+
+	for _, scope := range strings.Fields(req.Scope) {
+		if scope != ScopeOpenID && scope != ScopeProfile && scope != ScopeEmail {
+			return &OAuthError{Code: OAuthErrorInvalidScope, Description: fmt.Sprintf("unsupported scope: %s", scope)}
+		}
+	}
+
+	if req.Prompt == "none" {
+		return &OAuthError{Code: OAuthErrorLoginRequired, Description: "user must sign in"}
+	}
+
+	return nil
+}
+
+// Login signs the user in on the authorization endpoint. Users with MFA get
+// a challenge to complete with VerifyMFA.
+func (s *OIDCService) Login(req *AuthorizeLoginRequest) (*AuthorizeResult, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oidc_login", time.Since(start))
+	}()
+
+	if err := s.ValidateAuthorizeRequest(&req.AuthorizeRequest); err != nil {
+		return nil, err
+	}
+
+	user, err := s.authSvc.authenticate(&LoginRequest{
+		Email:     req.Email,
+		Password:  req.Password,
+		ClientIP:  req.ClientIP,
+		UserAgent: req.UserAgent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mfaEnabled, err := s.mfaSvc.Enabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mfaEnabled {
+		challenge, err := s.mfaSvc.CreateChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthorizeResult{MFAToken: challenge}, nil
+	}
+
+	resetLoginAttempts(s.authSvc.throttle, user.Email)
+	return s.issueCode(&req.AuthorizeRequest, user, &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent})
+}
+
+// VerifyMFA completes an MFA challenge started by Login.
+func (s *OIDCService) VerifyMFA(req *AuthorizeLoginRequest) (*AuthorizeResult, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oidc_mfa_verify", time.Since(start))
+	}()
+
+	if err := s.ValidateAuthorizeRequest(&req.AuthorizeRequest); err != nil {
+		return nil, err
+	}
+
+	user, err := s.authSvc.completeMFA(&VerifyMFARequest{
+		MFAToken:  req.MFAToken,
+		Code:      req.Code,
+		ClientIP:  req.ClientIP,
+		UserAgent: req.UserAgent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueCode(&req.AuthorizeRequest, user, &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent})
+}
+
+// ExchangeCode redeems an authorization code at the token endpoint. The
+// code must have been issued to the same client and redirect URI, and the
+// verifier must match its PKCE challenge.
+func (s *OIDCService) ExchangeCode(req *CodeExchangeRequest) (*TokenResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oidc_code_exchange", time.Since(start))
+	}()
+
+	client, err := s.oauthSvc.authenticateCodeClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidGrant := &OAuthError{Code: OAuthErrorInvalidGrant, Description: "invalid, expired or already used authorization code"}
+
+	code, err := s.tokenRepo.ConsumeAuthorizationCode(auth.HashToken(req.Code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalidGrant
+		}
+		return nil, err
+	}
+
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI {
+		return nil, invalidGrant
+	}
+	if !verifyPKCE(code.CodeChallenge, req.CodeVerifier) {
+		return nil, &OAuthError{Code: OAuthErrorInvalidGrant, Description: "code_verifier does not match code_challenge"}
+	}
+
+	user, err := s.userRepo.GetByID(code.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalidGrant
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, invalidGrant
+	}
+
+	// The session is the browser login that produced the code, not the
+	// client's backend redeeming it
+	tokens, err := s.authSvc.issueClientTokens(user, &Device{IPAddress: code.IPAddress, UserAgent: code.UserAgent}, client.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TokenResponse{
+		AccessToken:  tokens.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.jwtSvc.ExpirationTime().Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		Scope:        code.Scope,
+	}
+
+	scopes := strings.Fields(code.Scope)
+	if containsString(scopes, ScopeOpenID) {
+		resp.IDToken, err = s.idToken(user, client.ClientID, code, scopes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// RefreshToken implements the refresh_token grant on top of the refresh
+// token rotation of AuthService. Only the client a refresh token was issued
+// to can redeem it; tokens of first-party logins are refused (RFC 6749
+// section 6).
+func (s *OIDCService) RefreshToken(req *RefreshGrantRequest) (*TokenResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("oidc_refresh", time.Since(start))
+	}()
+
+	client, err := s.oauthSvc.authenticateCodeClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	user, stored, err := s.authSvc.checkRefreshToken(req.RefreshToken, client.ClientID)
+	if err != nil {
+		return nil, &OAuthError{Code: OAuthErrorInvalidGrant, Description: err.Error()}
+	}
+
+	tokens, err := s.authSvc.rotateTokens(user, stored, stored.OrganizationID)
+	if err != nil {
+		return nil, &OAuthError{Code: OAuthErrorInvalidGrant, Description: err.Error()}
+	}
+
+	return &TokenResponse{
+		AccessToken:  tokens.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.jwtSvc.ExpirationTime().Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+// UserInfo returns the claims about the user an access token was issued to.
+func (s *OIDCService) UserInfo(tokenString string) (*UserInfoResponse, error) {
+	user, _, err := s.authSvc.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("token was not issued to a user")
+	}
+
+	return &UserInfoResponse{
+		Sub:           fmt.Sprintf("%d", user.ID),
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Name:          strings.TrimSpace(user.FirstName + " " + user.LastName),
+		GivenName:     user.FirstName,
+		FamilyName:    user.LastName,
+	}, nil
+}
+
+func (s *OIDCService) issueCode(req *AuthorizeRequest, user *models.User, device *Device) (*AuthorizeResult, error) {
+	code, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.AuthorizationCode{
+		CodeHash:      auth.HashToken(code),
+		ClientID:      req.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      time.Now().UTC(),
+		IPAddress:     device.IPAddress,
+		UserAgent:     truncate(device.UserAgent, maxUserAgentLength),
+		ExpiresAt:     time.Now().UTC().Add(s.codeTTL),
+	}
+	if err := s.tokenRepo.CreateAuthorizationCode(record); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("code", code)
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	// RFC 9207: lets the client detect mix-up attacks
+	params.Set("iss", s.jwtSvc.Issuer())
+
+	return &AuthorizeResult{RedirectURL: AppendQuery(req.RedirectURI, params)}, nil
+}
+
+func (s *OIDCService) idToken(user *models.User, clientID string, code *models.AuthorizationCode, scopes []string) (string, error) {
+	claims := &auth.IDTokenClaims{
+		Nonce:    code.Nonce,
+		AuthTime: code.AuthTime.Unix(),
+	}
+	if containsString(scopes, ScopeEmail) {
+		verified := user.EmailVerifiedAt != nil
+		claims.Email = user.Email
+		claims.EmailVerified = &verified
+	}
+	if containsString(scopes, ScopeProfile) {
+		claims.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		claims.GivenName = user.FirstName
+		claims.FamilyName = user.LastName
+	}
+
+	return s.jwtSvc.GenerateIDToken(fmt.Sprintf("%d", user.ID), clientID, claims)
+}
+
+// AppendQuery adds params to the query string of a redirect URI, keeping
+// any query it already has.
+func AppendQuery(uri string, params url.Values) string {
+	separator := "?"
+	if strings.Contains(uri, "?") {
+		separator = "&"
+	}
+	return uri + separator + params.Encode()
+}
+
+// verifyPKCE checks an S256 code verifier against its challenge (RFC 7636).
+func verifyPKCE(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
@@ -0,0 +1,10 @@
+package services
+
+type IOIDCService interface {
+	ValidateAuthorizeRequest(req *AuthorizeRequest) error
+	Login(req *AuthorizeLoginRequest) (*AuthorizeResult, error)
+	VerifyMFA(req *AuthorizeLoginRequest) (*AuthorizeResult, error)
+	ExchangeCode(req *CodeExchangeRequest) (*TokenResponse, error)
+	RefreshToken(req *RefreshGrantRequest) (*TokenResponse, error)
+	UserInfo(tokenString string) (*UserInfoResponse, error)
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"errors"
+	"log"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrNotOrganizationMember = errors.New("user is not a member of the organization")
+)
+
+// orgSlugPattern restricts slugs to lowercase letters, digits and inner
+// hyphens, so they can be used in URLs and subdomains.
+var orgSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// OrganizationService manages organizations and their members, and switches
+// the active organization of a session. Tokens are issued by AuthService.
+type OrganizationService struct {
+	orgRepo   *repositories.OrganizationRepository
+	userRepo  *repositories.UserRepository
+	tokenRepo *repositories.TokenRepository
+	authSvc   *AuthService
+}
+
+func NewOrganizationService(orgRepo *repositories.OrganizationRepository, userRepo *repositories.UserRepository, tokenRepo *repositories.TokenRepository, authSvc *AuthService) *OrganizationService {
+	return &OrganizationService{
+		orgRepo:   orgRepo,
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		authSvc:   authSvc,
+	}
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+	Slug string `json:"slug" binding:"required,max=100"`
+}
+
+type SetMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=member admin"`
+}
+
+// SwitchOrganizationRequest exchanges a refresh token for tokens active in
+// another organization of the user.
+type SwitchOrganizationRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+	OrgID        uint   `json:"org_id" binding:"required"`
+}
+
+// MembershipResponse is a membership as shown to the user. Active marks the
+// organization of the access token the request was made with.
+type MembershipResponse struct {
+	*models.OrganizationMember
+	Active bool `json:"active"`
+}
+
+// ListMemberships returns the organizations of a user. activeOrgID is the
+// org_id of the caller's access token.
+func (s *OrganizationService) ListMemberships(userID, activeOrgID uint) ([]*MembershipResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("memberships_list", time.Since(start))
+	}()
+
+	members, err := s.orgRepo.ListMemberships(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*MembershipResponse, 0, len(members))
+	for _, member := range members {
+		response = append(response, &MembershipResponse{
+			OrganizationMember: member,
+			Active:             member.OrganizationID == activeOrgID,
+		})
+	}
+	return response, nil
+}
+
+// SwitchOrganization rotates the refresh token into a new token pair of the
+// same session, active in the requested organization. The access token
+// issued with the presented refresh token is revoked, so it can not be used
+// in the old organization any longer.
+func (s *OrganizationService) SwitchOrganization(req *SwitchOrganizationRequest) (*AuthResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("organization_switch", time.Since(start))
+	}()
+
+	user, stored, err := s.authSvc.checkRefreshToken(req.RefreshToken, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.orgRepo.GetMembership(req.OrgID, user.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotOrganizationMember
+		}
+		return nil, err
+	}
+
+	response, err := s.authSvc.rotateTokens(user, stored, &req.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.AccessTokenID != "" {
+		if err := s.tokenRepo.RevokeAccessToken(stored.AccessTokenID, user.ID, stored.AccessExpiresAt); err != nil {
+			log.Printf("Failed to revoke access token after organization switch: %v", err)
+		}
+	}
+
+	return response, nil
+}
+
+func (s *OrganizationService) ListOrganizations() ([]*models.Organization, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("organizations_list", time.Since(start))
+	}()
+
+	return s.orgRepo.List()
+}
+
+func (s *OrganizationService) CreateOrganization(req *CreateOrganizationRequest) (*models.Organization, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("organization_create", time.Since(start))
+	}()
+
+	if !orgSlugPattern.MatchString(req.Slug) {
+		return nil, errors.New("slug may only contain lowercase letters, digits and hyphens")
+	}
+
+	if _, err := s.orgRepo.GetBySlug(req.Slug); err == nil {
+		return nil, errors.New("organization with this slug already exists")
+	}
+
+	org := &models.Organization{
+		Name: req.Name,
+		Slug: req.Slug,
+	}
+	if err := s.orgRepo.Create(org); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (s *OrganizationService) ListMembers(orgID uint) ([]*models.OrganizationMember, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("organization_members_list", time.Since(start))
+	}()
+
+	if err := s.checkOrganization(orgID); err != nil {
+		return nil, err
+	}
+
+	return s.orgRepo.ListMembers(orgID)
+}
+
+// SetMember adds a user to an organization or changes their role. Like role
+// changes, it applies to tokens issued afterwards.
+func (s *OrganizationService) SetMember(orgID, userID uint, req *SetMemberRequest) (*models.OrganizationMember, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("organization_member_set", time.Since(start))
+	}()
+
+	if err := s.checkOrganization(orgID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	member := &models.OrganizationMember{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Role:           req.Role,
+	}
+	if err := s.orgRepo.SetMember(member); err != nil {
+		return nil, err
+	}
+
+	return s.orgRepo.GetMembership(orgID, userID)
+}
+
+// RemoveMember removes a user from an organization. Sessions active in it
+// move to the user's default organization when their tokens are refreshed.
+func (s *OrganizationService) RemoveMember(orgID, userID uint) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("organization_member_remove", time.Since(start))
+	}()
+
+	if err := s.checkOrganization(orgID); err != nil {
+		return err
+	}
+
+	if err := s.orgRepo.RemoveMember(orgID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotOrganizationMember
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *OrganizationService) checkOrganization(orgID uint) error {
+	if _, err := s.orgRepo.GetByID(orgID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrganizationNotFound
+		}
+		return err
+	}
+	return nil
+}
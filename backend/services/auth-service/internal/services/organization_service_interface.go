@@ -0,0 +1,13 @@
+package services
+
+import "auth-service/internal/models"
+
+type IOrganizationService interface {
+	ListMemberships(userID, activeOrgID uint) ([]*MembershipResponse, error)
+	SwitchOrganization(req *SwitchOrganizationRequest) (*AuthResponse, error)
+	ListOrganizations() ([]*models.Organization, error)
+	CreateOrganization(req *CreateOrganizationRequest) (*models.Organization, error)
+	ListMembers(orgID uint) ([]*models.OrganizationMember, error)
+	SetMember(orgID, userID uint, req *SetMemberRequest) (*models.OrganizationMember, error)
+	RemoveMember(orgID, userID uint) error
+}
@@ -0,0 +1,354 @@
+package services
+
+import (
+	"auth-service/internal/audit"
+	"auth-service/internal/auth"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/orders"
+	"auth-service/internal/repositories"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrErasureIncomplete is returned when an erasure stopped part way,
+	// e.g. because order-service was unreachable. It can be retried.
+	ErrErasureIncomplete = errors.New("erasure could not be completed, please retry later")
+	// ErrInvalidErasureReceipt is returned for a receipt that was not issued
+	// by this service or was altered.
+	ErrInvalidErasureReceipt = errors.New("invalid erasure receipt")
+)
+
+// Erasure steps, recorded in ErasureRecord.Steps and the receipt
+const (
+	ErasureStepOrders  = "orders"
+	ErasureStepAccount = "account"
+)
+
+// serviceClientID is the client_id of the tokens auth-service issues itself
+// to call order-service. serviceTokenTTL keeps them short-lived.
+const (
+	serviceClientID = "auth-service"
+	serviceTokenTTL = time.Minute
+)
+
+// ServiceTokenSource issues the tokens auth-service presents to order-service
+// when exporting and erasing a user's orders. They are client tokens
+// order-service verifies locally like any other.
+func ServiceTokenSource(jwtSvc *auth.JWTService) orders.TokenSource {
+	return func() (string, error) {
+		return jwtSvc.GenerateTokenWithTTL(&auth.Claims{
+			IsActive:    true,
+			Scope:       models.PermissionOrdersReadAny + " " + models.PermissionOrdersEraseAny,
+			SubjectType: auth.SubjectTypeClient,
+			ClientID:    serviceClientID,
+		}, serviceTokenTTL)
+	}
+}
+
+// PrivacyService implements the data export and the erasure of user
+// accounts. Orders live in order-service and are reached through orders;
+// with no order-service configured, exports contain no orders and erasures
+// leave them alone, which the erasure record shows in its steps.
+type PrivacyService struct {
+	userRepo     *repositories.UserRepository
+	tokenRepo    *repositories.TokenRepository
+	identityRepo *repositories.IdentityRepository
+	apiKeyRepo   *repositories.APIKeyRepository
+	auditRepo    *repositories.AuditEventRepository
+	erasureRepo  *repositories.ErasureRepository
+	throttle     *auth.LoginThrottle
+	jwtSvc       *auth.JWTService
+	orders       *orders.Client
+	auditor      *audit.Logger
+}
+
+func NewPrivacyService(userRepo *repositories.UserRepository, tokenRepo *repositories.TokenRepository, identityRepo *repositories.IdentityRepository, apiKeyRepo *repositories.APIKeyRepository, auditRepo *repositories.AuditEventRepository, erasureRepo *repositories.ErasureRepository, throttle *auth.LoginThrottle, jwtSvc *auth.JWTService, orders *orders.Client, auditor *audit.Logger) *PrivacyService {
+	return &PrivacyService{
+		userRepo:     userRepo,
+		tokenRepo:    tokenRepo,
+		identityRepo: identityRepo,
+		apiKeyRepo:   apiKeyRepo,
+		auditRepo:    auditRepo,
+		erasureRepo:  erasureRepo,
+		throttle:     throttle,
+		jwtSvc:       jwtSvc,
+		orders:       orders,
+		auditor:      auditor,
+	}
+}
+
+// DataExport is everything stored about a user, across services.
+type DataExport struct {
+	ExportedAt     time.Time            `json:"exported_at"`
+	Profile        *models.User         `json:"profile"`
+	Identities     []*models.Identity   `json:"identities"`
+	Sessions       []*models.Session    `json:"sessions"`
+	APIKeys        []*models.APIKey     `json:"api_keys"`
+	SecurityEvents []*models.AuditEvent `json:"security_events"`
+	Orders         json.RawMessage      `json:"orders"`
+}
+
+type EraseAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+	// ClientIP and UserAgent are set by the handler for the audit log
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type VerifyErasureReceiptRequest struct {
+	Receipt string `json:"receipt" binding:"required"`
+}
+
+// ExportData collects the data of a user: profile, linked identities, active
+// sessions, API keys, their security events and their orders.
+func (s *PrivacyService) ExportData(userID uint, device *Device) (*DataExport, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("privacy_export", time.Since(start))
+	}()
+
+	export, err := s.exportData(userID)
+	event := newAuditEvent(models.AuditEventDataExport, nil, "", device, err)
+	event.ActorID = &userID
+	s.auditor.Record(event)
+	return export, err
+}
+
+func (s *PrivacyService) exportData(userID uint) (*DataExport, error) {
+	user, err := s.userRepo.GetByIDUnscoped(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	export := &DataExport{
+		ExportedAt:     time.Now().UTC(),
+		Profile:        user,
+		SecurityEvents: []*models.AuditEvent{},
+		Orders:         json.RawMessage("[]"),
+	}
+
+	if export.Identities, err = s.identityRepo.ListByUser(userID); err != nil {
+		return nil, err
+	}
+	if export.Sessions, err = s.tokenRepo.ListActiveSessions(userID); err != nil {
+		return nil, err
+	}
+	if export.APIKeys, err = s.apiKeyRepo.ListByUser(userID); err != nil {
+		return nil, err
+	}
+
+	collect := func(events []*models.AuditEvent) error {
+		export.SecurityEvents = append(export.SecurityEvents, events...)
+		return nil
+	}
+	if err := s.auditRepo.Each(repositories.AuditFilter{ActorID: &userID}, auditExportBatchSize, collect); err != nil {
+		return nil, err
+	}
+	if err := s.auditRepo.Each(repositories.AuditFilter{SubjectID: &userID}, auditExportBatchSize, collect); err != nil {
+		return nil, err
+	}
+
+	if s.orders != nil {
+		if export.Orders, err = s.orders.ExportUserOrders(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return export, nil
+}
+
+// EraseAccount erases the signed-in user's own account after checking their
+// password.
+func (s *PrivacyService) EraseAccount(userID uint, req *EraseAccountRequest) (*models.ErasureRecord, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("privacy_erase", time.Since(start))
+	}()
+
+	device := &Device{IPAddress: req.ClientIP, UserAgent: req.UserAgent}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrUserNotFound
+		}
+		s.recordErasure(userID, userID, device, err)
+		return nil, err
+	}
+
+	if err := confirmPassword(s.throttle, user, req.Password, req.ClientIP); err != nil {
+		s.recordErasure(userID, userID, device, err)
+		return nil, err
+	}
+
+	record, err := s.erase(user, nil)
+	s.recordErasure(userID, userID, device, err)
+	return record, err
+}
+
+// EraseUser erases another user's account on behalf of an administrator.
+// Soft-deleted users can be erased too.
+func (s *PrivacyService) EraseUser(actorID, id uint, device *Device) (*models.ErasureRecord, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("privacy_erase", time.Since(start))
+	}()
+
+	record, err := s.eraseUser(actorID, id)
+	s.recordErasure(actorID, id, device, err)
+	return record, err
+}
+
+func (s *PrivacyService) eraseUser(actorID, id uint) (*models.ErasureRecord, error) {
+	if actorID == id {
+		return nil, ErrSelfModification
+	}
+
+	user, err := s.userRepo.GetByIDUnscoped(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return s.erase(user, &actorID)
+}
+
+// erase pseudonymizes the user's orders in order-service and then deletes
+// the account, and documents both in an erasure record. Orders go first: if
+// order-service fails, the account is kept and the erasure can be retried.
+// The user's tokens are revoked up front so no new orders are placed in
+// between.
+func (s *PrivacyService) erase(user *models.User, requestedBy *uint) (*models.ErasureRecord, error) {
+	erasureID, err := auth.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.ErasureRecord{
+		ErasureID:   "era_" + erasureID,
+		UserID:      user.ID,
+		RequestedBy: requestedBy,
+		Status:      models.ErasureStatusPending,
+	}
+	if err := s.erasureRepo.Create(record); err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(user.ID); err != nil {
+		return s.failErasure(record, err)
+	}
+
+	var steps []string
+	if s.orders != nil {
+		count, err := s.orders.EraseUserOrders(user.ID)
+		if err != nil {
+			return s.failErasure(record, err)
+		}
+		record.OrdersPseudonymized = count
+		steps = append(steps, ErasureStepOrders)
+	}
+
+	if err := s.userRepo.Erase(user.ID, user.Email); err != nil {
+		return s.failErasure(record, err)
+	}
+	steps = append(steps, ErasureStepAccount)
+
+	// The failed login counter is keyed by the email address
+	if err := s.throttle.RecordSuccess(user.Email); err != nil {
+		log.Printf("Failed to clear login attempts of erased user %d: %v", user.ID, err)
+	}
+
+	completedAt := time.Now().UTC()
+	receipt, err := s.jwtSvc.GenerateErasureReceipt(record.ErasureID, user.ID, completedAt, &auth.ErasureReceiptClaims{
+		Steps:               steps,
+		OrdersPseudonymized: record.OrdersPseudonymized,
+	})
+	if err != nil {
+		return s.failErasure(record, err)
+	}
+
+	record.Status = models.ErasureStatusCompleted
+	record.Steps = strings.Join(steps, " ")
+	record.Receipt = receipt
+	record.CompletedAt = &completedAt
+	if err := s.erasureRepo.Update(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// failErasure marks the record as failed. The cause is logged and kept on
+// the record; callers get ErrErasureIncomplete.
+func (s *PrivacyService) failErasure(record *models.ErasureRecord, cause error) (*models.ErasureRecord, error) {
+	log.Printf("Erasure %s of user %d failed: %v", record.ErasureID, record.UserID, cause)
+
+	record.Status = models.ErasureStatusFailed
+	record.Error = cause.Error()
+	if len(record.Error) > 255 {
+		record.Error = record.Error[:255]
+	}
+	if err := s.erasureRepo.Update(record); err != nil {
+		log.Printf("Failed to update erasure record %s: %v", record.ErasureID, err)
+	}
+
+	return record, fmt.Errorf("%w (erasure %s)", ErrErasureIncomplete, record.ErasureID)
+}
+
+// VerifyErasureReceipt checks that a receipt was issued by this service,
+// unaltered, and returns the erasure it documents. Receipts are compared
+// with the stored copy, so they stay verifiable after the signing key was
+// rotated out of the JWKS.
+func (s *PrivacyService) VerifyErasureReceipt(req *VerifyErasureReceiptRequest) (*models.ErasureRecord, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("privacy_verify_receipt", time.Since(start))
+	}()
+
+	claims := &auth.ErasureReceiptClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(req.Receipt, claims); err != nil || claims.ID == "" {
+		return nil, ErrInvalidErasureReceipt
+	}
+
+	record, err := s.erasureRepo.GetByErasureID(claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidErasureReceipt
+		}
+		return nil, err
+	}
+
+	if record.Receipt == "" || subtle.ConstantTimeCompare([]byte(record.Receipt), []byte(req.Receipt)) != 1 {
+		return nil, ErrInvalidErasureReceipt
+	}
+
+	return record, nil
+}
+
+// recordErasure records an erasure attempt in the audit log. The erased
+// user is the subject; no email address is recorded, and neither is the
+// device of users who erased their own account.
+func (s *PrivacyService) recordErasure(actorID, subjectID uint, device *Device, err error) {
+	if err == nil && actorID == subjectID {
+		device = nil
+	}
+	event := newAuditEvent(models.AuditEventUserErase, nil, "", device, err)
+	event.ActorID = &actorID
+	event.SubjectID = &subjectID
+	s.auditor.Record(event)
+}
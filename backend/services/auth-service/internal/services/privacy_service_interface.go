@@ -0,0 +1,10 @@
+package services
+
+import "auth-service/internal/models"
+
+type IPrivacyService interface {
+	ExportData(userID uint, device *Device) (*DataExport, error)
+	EraseAccount(userID uint, req *EraseAccountRequest) (*models.ErasureRecord, error)
+	EraseUser(actorID, id uint, device *Device) (*models.ErasureRecord, error)
+	VerifyErasureReceipt(req *VerifyErasureReceiptRequest) (*models.ErasureRecord, error)
+}
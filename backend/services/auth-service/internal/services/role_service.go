@@ -0,0 +1,210 @@
+package services
+
+import (
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrRoleNotFound = errors.New("role not found")
+)
+
+type RoleService struct {
+	roleRepo *repositories.RoleRepository
+	userRepo *repositories.UserRepository
+}
+
+func NewRoleService(roleRepo *repositories.RoleRepository, userRepo *repositories.UserRepository) *RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=100"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (s *RoleService) ListRoles() ([]*models.Role, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("roles_list", time.Since(start))
+	}()
+
+	return s.roleRepo.ListRoles()
+}
+
+func (s *RoleService) ListPermissions() ([]*models.Permission, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("permissions_list", time.Since(start))
+	}()
+
+	return s.roleRepo.ListPermissions()
+}
+
+func (s *RoleService) CreateRole(req *CreateRoleRequest) (*models.Role, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("role_create", time.Since(start))
+	}()
+
+	if _, err := s.roleRepo.GetRoleByName(req.Name); err == nil {
+		return nil, errors.New("role with this name already exists")
+	}
+
+	permissions, err := s.lookupPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+
+	if err := s.roleRepo.CreateRole(role); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.GetRoleByID(role.ID)
+}
+
+func (s *RoleService) SetRolePermissions(roleID uint, req *SetRolePermissionsRequest) (*models.Role, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("role_update", time.Since(start))
+	}()
+
+	role, err := s.roleRepo.GetRoleByID(roleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+
+	permissions, err := s.lookupPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.SetRolePermissions(role, permissions); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.GetRoleByID(roleID)
+}
+
+func (s *RoleService) GetUserRoles(userID uint) ([]models.Role, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("user_roles_get", time.Since(start))
+	}()
+
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return s.roleRepo.GetUserRoles(userID)
+}
+
+func (s *RoleService) AssignRole(userID uint, req *AssignRoleRequest) ([]models.Role, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("user_role_assign", time.Since(start))
+	}()
+
+	user, role, err := s.lookupUserAndRole(userID, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.AssignRole(user, role); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.GetUserRoles(userID)
+}
+
+func (s *RoleService) RemoveRole(userID uint, roleName string) ([]models.Role, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("user_role_remove", time.Since(start))
+	}()
+
+	user, role, err := s.lookupUserAndRole(userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.RemoveRole(user, role); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.GetUserRoles(userID)
+}
+
+func (s *RoleService) lookupUserAndRole(userID uint, roleName string) (*models.User, *models.Role, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrUserNotFound
+		}
+		return nil, nil, err
+	}
+
+	role, err := s.roleRepo.GetRoleByName(roleName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrRoleNotFound
+		}
+		return nil, nil, err
+	}
+
+	return user, role, nil
+}
+
+func (s *RoleService) lookupPermissions(names []string) ([]models.Permission, error) {
+	if len(names) == 0 {
+		return []models.Permission{}, nil
+	}
+
+	permissions, err := s.roleRepo.GetPermissionsByName(names)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("unknown permission: %s", name)
+		}
+	}
+
+	return permissions, nil
+}
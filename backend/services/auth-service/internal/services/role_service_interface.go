@@ -0,0 +1,13 @@
+package services
+
+import "auth-service/internal/models"
+
+type IRoleService interface {
+	ListRoles() ([]*models.Role, error)
+	ListPermissions() ([]*models.Permission, error)
+	CreateRole(req *CreateRoleRequest) (*models.Role, error)
+	SetRolePermissions(roleID uint, req *SetRolePermissionsRequest) (*models.Role, error)
+	GetUserRoles(userID uint) ([]models.Role, error)
+	AssignRole(userID uint, req *AssignRoleRequest) ([]models.Role, error)
+	RemoveRole(userID uint, roleName string) ([]models.Role, error)
+}
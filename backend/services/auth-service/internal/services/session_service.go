@@ -0,0 +1,153 @@
+package services
+
+import (
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxUserAgentLength is the size of the user_agent columns.
+const maxUserAgentLength = 255
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Device describes where a login came from. Handlers fill it in from the
+// request.
+type Device struct {
+	IPAddress string
+	UserAgent string
+}
+
+// SessionService lists and revokes the login sessions of users. Sessions are
+// created by AuthService when tokens are issued for a new login.
+type SessionService struct {
+	tokenRepo *repositories.TokenRepository
+	userRepo  *repositories.UserRepository
+}
+
+func NewSessionService(tokenRepo *repositories.TokenRepository, userRepo *repositories.UserRepository) *SessionService {
+	return &SessionService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// SessionResponse is a session as shown to users. Current marks the session
+// of the access token the request was made with.
+type SessionResponse struct {
+	*models.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions returns the active sessions of a user. currentSessionID is the
+// sid of the caller's access token, empty for admins looking at other users.
+func (s *SessionService) ListSessions(userID uint, currentSessionID string) ([]*SessionResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("sessions_list", time.Since(start))
+	}()
+
+	if err := s.checkUser(userID); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.tokenRepo.ListActiveSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, &SessionResponse{
+			Session: session,
+			Current: currentSessionID != "" && session.FamilyID == currentSessionID,
+		})
+	}
+	return resp, nil
+}
+
+// RevokeSession signs the user out of one session: its refresh tokens and
+// outstanding access tokens are revoked.
+func (s *SessionService) RevokeSession(userID, id uint) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("session_revoke", time.Since(start))
+	}()
+
+	if err := s.checkUser(userID); err != nil {
+		return err
+	}
+
+	session, err := s.tokenRepo.GetSession(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	return s.tokenRepo.RevokeFamily(session.FamilyID)
+}
+
+// RevokeOtherSessions signs the user out everywhere except the session
+// currentSessionID; with an empty currentSessionID every session is revoked.
+func (s *SessionService) RevokeOtherSessions(userID uint, currentSessionID string) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("sessions_revoke_others", time.Since(start))
+	}()
+
+	if err := s.checkUser(userID); err != nil {
+		return err
+	}
+
+	if currentSessionID == "" {
+		return s.tokenRepo.RevokeAllForUser(userID)
+	}
+	return s.tokenRepo.RevokeOtherSessions(userID, currentSessionID)
+}
+
+func (s *SessionService) checkUser(userID uint) error {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// newSession builds the session of a new token family from its first
+// refresh token.
+func newSession(token *models.RefreshToken, device *Device) *models.Session {
+	session := &models.Session{
+		UserID:     token.UserID,
+		FamilyID:   token.FamilyID,
+		ClientID:   token.ClientID,
+		LastSeenAt: time.Now().UTC(),
+		ExpiresAt:  token.ExpiresAt,
+	}
+	if device != nil {
+		session.IPAddress = device.IPAddress
+		session.UserAgent = truncate(device.UserAgent, maxUserAgentLength)
+	}
+	return session
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,7 @@
+package services
+
+type ISessionService interface {
+	ListSessions(userID uint, currentSessionID string) ([]*SessionResponse, error)
+	RevokeSession(userID, id uint) error
+	RevokeOtherSessions(userID uint, currentSessionID string) error
+}
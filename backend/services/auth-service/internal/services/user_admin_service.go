@@ -0,0 +1,235 @@
+package services
+
+import (
+	"auth-service/internal/audit"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSelfModification is returned when an administrator tries to deactivate
+// or delete their own account.
+var ErrSelfModification = errors.New("administrators cannot deactivate or delete their own account")
+
+type UserAdminService struct {
+	userRepo  *repositories.UserRepository
+	tokenRepo *repositories.TokenRepository
+	auditor   *audit.Logger
+}
+
+func NewUserAdminService(userRepo *repositories.UserRepository, tokenRepo *repositories.TokenRepository, auditor *audit.Logger) *UserAdminService {
+	return &UserAdminService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		auditor:   auditor,
+	}
+}
+
+// AdminUser is the administrative view of a user. Unlike models.User it
+// exposes when the user was soft-deleted.
+type AdminUser struct {
+	*models.User
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type ListUsersRequest struct {
+	Email          string `form:"email"`
+	Name           string `form:"name"`
+	IsActive       *bool  `form:"is_active"`
+	IncludeDeleted bool   `form:"include_deleted"`
+	Offset         int    `form:"offset" binding:"min=0"`
+	Limit          int    `form:"limit" binding:"min=0,max=100"`
+}
+
+type ListUsersResponse struct {
+	Users  []*AdminUser `json:"users"`
+	Total  int64        `json:"total"`
+	Offset int          `json:"offset"`
+	Limit  int          `json:"limit"`
+}
+
+type SetUserStatusRequest struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
+
+func (s *UserAdminService) ListUsers(req *ListUsersRequest) (*ListUsersResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("admin_users_list", time.Since(start))
+	}()
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = 20
+	}
+
+	filter := repositories.UserFilter{
+		Email:          req.Email,
+		Name:           req.Name,
+		IsActive:       req.IsActive,
+		IncludeDeleted: req.IncludeDeleted,
+	}
+
+	users, total, err := s.userRepo.Search(filter, req.Offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*AdminUser, 0, len(users))
+	for _, user := range users {
+		views = append(views, adminUser(user))
+	}
+
+	return &ListUsersResponse{
+		Users:  views,
+		Total:  total,
+		Offset: req.Offset,
+		Limit:  limit,
+	}, nil
+}
+
+func (s *UserAdminService) GetUser(id uint) (*AdminUser, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("admin_user_get", time.Since(start))
+	}()
+
+	user, err := s.lookupUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return adminUser(user), nil
+}
+
+// SetUserActive activates or deactivates a user. Deactivating a user revokes
+// all of their refresh tokens and outstanding access tokens. device is where
+// the administrator's request came from, for the audit log.
+func (s *UserAdminService) SetUserActive(actorID, id uint, active bool, device *Device) (*AdminUser, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("admin_user_status", time.Since(start))
+	}()
+
+	user, err := s.setUserActive(actorID, id, active)
+	eventType := models.AuditEventUserDeactivate
+	if active {
+		eventType = models.AuditEventUserActivate
+	}
+	s.recordAdminEvent(eventType, actorID, id, device, err)
+	return user, err
+}
+
+func (s *UserAdminService) setUserActive(actorID, id uint, active bool) (*AdminUser, error) {
+	if !active && actorID == id {
+		return nil, ErrSelfModification
+	}
+
+	user, err := s.lookupUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.DeletedAt.Valid {
+		return nil, errors.New("user is deleted")
+	}
+
+	if err := s.userRepo.SetActive(id, active); err != nil {
+		return nil, err
+	}
+
+	if !active {
+		if err := s.tokenRepo.RevokeAllForUser(id); err != nil {
+			return nil, err
+		}
+	}
+
+	user.IsActive = active
+	return adminUser(user), nil
+}
+
+// DeleteUser soft-deletes a user and revokes all of their tokens.
+func (s *UserAdminService) DeleteUser(actorID, id uint, device *Device) error {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("admin_user_delete", time.Since(start))
+	}()
+
+	err := s.deleteUser(actorID, id)
+	s.recordAdminEvent(models.AuditEventUserDelete, actorID, id, device, err)
+	return err
+}
+
+func (s *UserAdminService) deleteUser(actorID, id uint) error {
+	if actorID == id {
+		return ErrSelfModification
+	}
+
+	if _, err := s.userRepo.GetByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if err := s.userRepo.Delete(id); err != nil {
+		return err
+	}
+
+	return s.tokenRepo.RevokeAllForUser(id)
+}
+
+func (s *UserAdminService) RestoreUser(id uint) (*AdminUser, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("admin_user_restore", time.Since(start))
+	}()
+
+	user, err := s.lookupUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.DeletedAt.Valid {
+		return nil, errors.New("user is not deleted")
+	}
+
+	if err := s.userRepo.Restore(id); err != nil {
+		return nil, err
+	}
+
+	user.DeletedAt = gorm.DeletedAt{}
+	return adminUser(user), nil
+}
+
+// recordAdminEvent records an action of an administrator on another user.
+func (s *UserAdminService) recordAdminEvent(eventType string, actorID, subjectID uint, device *Device, err error) {
+	event := newAuditEvent(eventType, nil, "", device, err)
+	event.ActorID = &actorID
+	event.SubjectID = &subjectID
+	s.auditor.Record(event)
+}
+
+func (s *UserAdminService) lookupUser(id uint) (*models.User, error) {
+	user, err := s.userRepo.GetByIDUnscoped(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func adminUser(user *models.User) *AdminUser {
+	view := &AdminUser{User: user}
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time
+		view.DeletedAt = &deletedAt
+	}
+	return view
+}
@@ -0,0 +1,9 @@
+package services
+
+type IUserAdminService interface {
+	ListUsers(req *ListUsersRequest) (*ListUsersResponse, error)
+	GetUser(id uint) (*AdminUser, error)
+	SetUserActive(actorID, id uint, active bool, device *Device) (*AdminUser, error)
+	DeleteUser(actorID, id uint, device *Device) error
+	RestoreUser(id uint) (*AdminUser, error)
+}
@@ -0,0 +1,13 @@
+USE auth_db;
+
+-- Asymmetric JWT signing keys. Retired keys are kept for verification until expires_at.
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    kid VARCHAR(64) NOT NULL UNIQUE,
+    algorithm VARCHAR(16) NOT NULL,
+    private_key TEXT NOT NULL,
+    retired_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_expires_at (expires_at)
+);
@@ -0,0 +1,18 @@
+USE auth_db;
+
+-- Email verification. Accounts that existed before verification was introduced are treated as verified.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP NULL AFTER is_active;
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- Single-use tokens sent by email (password reset, email verification). Only the SHA-256 hash is stored.
+CREATE TABLE IF NOT EXISTS one_time_tokens (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_id (user_id),
+    INDEX idx_expires_at (expires_at)
+);
@@ -0,0 +1,13 @@
+USE auth_db;
+
+-- Failed login counters per email ("email:<address>") and per client IP ("ip:<address>"),
+-- shared by all replicas when login_protection.store is "database"
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    attempt_key VARCHAR(320) NOT NULL UNIQUE,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NULL,
+    locked_until TIMESTAMP NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_updated_at (updated_at)
+);
@@ -0,0 +1,22 @@
+USE auth_db;
+
+-- OAuth2 clients for the client_credentials grant. Secrets are stored as SHA-256 hashes.
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    client_id VARCHAR(64) NOT NULL UNIQUE,
+    secret_hash VARCHAR(64) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255),
+    scopes TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+INSERT INTO permissions (name, description) VALUES
+('clients:manage', 'Manage OAuth2 clients')
+ON DUPLICATE KEY UPDATE name=name;
+
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+WHERE r.name = 'admin' AND p.name = 'clients:manage';
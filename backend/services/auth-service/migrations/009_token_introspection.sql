@@ -0,0 +1,10 @@
+USE auth_db;
+
+-- OAuth2 clients need this scope to call /oauth/introspect
+INSERT INTO permissions (name, description) VALUES
+('tokens:introspect', 'Introspect access and refresh tokens')
+ON DUPLICATE KEY UPDATE name=name;
+
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+WHERE r.name = 'admin' AND p.name = 'tokens:introspect';
@@ -0,0 +1,26 @@
+USE auth_db;
+
+-- Authorization code flow: public clients (no secret, PKCE only) and redirect URIs
+ALTER TABLE oauth_clients
+    MODIFY COLUMN secret_hash VARCHAR(64) NULL,
+    ADD COLUMN redirect_uris TEXT AFTER scopes,
+    ADD COLUMN is_public BOOLEAN DEFAULT FALSE AFTER redirect_uris;
+
+-- Single-use authorization codes, stored as SHA-256 hashes
+CREATE TABLE IF NOT EXISTS authorization_codes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    code_hash VARCHAR(64) NOT NULL UNIQUE,
+    client_id VARCHAR(64) NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    scope VARCHAR(255),
+    nonce VARCHAR(255),
+    code_challenge VARCHAR(128) NOT NULL,
+    auth_time TIMESTAMP NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_id (user_id),
+    INDEX idx_expires_at (expires_at)
+);
@@ -0,0 +1,23 @@
+USE auth_db;
+
+-- Logins of users on a device, one per refresh token family
+CREATE TABLE IF NOT EXISTS sessions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    family_id VARCHAR(64) NOT NULL UNIQUE,
+    user_agent VARCHAR(255),
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_id (user_id),
+    INDEX idx_expires_at (expires_at)
+);
+
+-- The device of the browser login, for the session created when the code is
+-- redeemed
+ALTER TABLE authorization_codes
+    ADD COLUMN ip_address VARCHAR(45) AFTER auth_time,
+    ADD COLUMN user_agent VARCHAR(255) AFTER ip_address;
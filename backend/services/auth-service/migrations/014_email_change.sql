@@ -0,0 +1,4 @@
+USE auth_db;
+
+-- What a one-time token confirms, e.g. the new address of an email change
+ALTER TABLE one_time_tokens ADD COLUMN data VARCHAR(255) NULL AFTER token_hash;
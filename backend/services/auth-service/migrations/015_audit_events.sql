@@ -0,0 +1,37 @@
+USE auth_db;
+
+-- Security audit trail of authentication events
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    actor_id BIGINT UNSIGNED NULL,
+    actor_email VARCHAR(255),
+    subject_id BIGINT UNSIGNED NULL,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    reason VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_type (type),
+    INDEX idx_actor_id (actor_id),
+    INDEX idx_actor_email (actor_email),
+    INDEX idx_subject_id (subject_id),
+    INDEX idx_created_at (created_at)
+);
+
+-- Entries are append-only. No foreign keys either, so the trail outlives
+-- deleted users.
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events is append-only';
+
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events is append-only';
+
+-- Administrators may read the audit trail
+INSERT INTO permissions (name, description) VALUES
+('audit:read', 'View and export the security audit log')
+ON DUPLICATE KEY UPDATE name=name;
+
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+WHERE r.name = 'admin' AND p.name = 'audit:read';
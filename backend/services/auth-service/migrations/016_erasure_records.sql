@@ -0,0 +1,28 @@
+USE auth_db;
+
+-- Records of account erasures. No foreign key, as the user row is deleted
+-- by the erasure the record documents.
+CREATE TABLE IF NOT EXISTS erasure_records (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    erasure_id VARCHAR(64) NOT NULL UNIQUE,
+    user_id BIGINT UNSIGNED NOT NULL,
+    requested_by BIGINT UNSIGNED NULL,
+    status VARCHAR(16) NOT NULL,
+    steps VARCHAR(255),
+    orders_pseudonymized BIGINT NOT NULL DEFAULT 0,
+    error VARCHAR(255),
+    receipt TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL,
+    INDEX idx_user_id (user_id)
+);
+
+-- auth-service pseudonymizes the orders of erased users with this
+-- permission; administrators may grant it to OAuth2 clients too
+INSERT INTO permissions (name, description) VALUES
+('orders:erase:any', 'Pseudonymize the orders of erased users')
+ON DUPLICATE KEY UPDATE name=name;
+
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+WHERE r.name = 'admin' AND p.name = 'orders:erase:any';
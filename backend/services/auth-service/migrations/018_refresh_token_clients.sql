@@ -0,0 +1,6 @@
+USE auth_db;
+
+-- The OAuth client a token family was issued to by the authorization code
+-- flow; empty for first-party logins. Only that client can redeem the tokens.
+ALTER TABLE refresh_tokens ADD COLUMN client_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN client_id VARCHAR(64) NOT NULL DEFAULT '';
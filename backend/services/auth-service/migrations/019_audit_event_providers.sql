@@ -0,0 +1,5 @@
+USE auth_db;
+
+-- The identity provider of federated sign-ins, account links and
+-- provisioned accounts
+ALTER TABLE audit_events ADD COLUMN provider VARCHAR(64) AFTER user_agent;
@@ -0,0 +1,22 @@
+USE auth_db;
+
+-- Audit events stay append-only, except that erasing a user clears the
+-- personal data of their events: the email address (or replaces it with an
+-- "erased:" hash), the client IP address and the user agent.
+DROP TRIGGER IF EXISTS audit_events_no_update;
+
+DELIMITER //
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+FOR EACH ROW
+BEGIN
+    IF NOT (NEW.id <=> OLD.id AND NEW.type <=> OLD.type AND NEW.outcome <=> OLD.outcome
+            AND NEW.actor_id <=> OLD.actor_id AND NEW.subject_id <=> OLD.subject_id
+            AND NEW.provider <=> OLD.provider AND NEW.reason <=> OLD.reason
+            AND NEW.created_at <=> OLD.created_at
+            AND (NEW.actor_email <=> OLD.actor_email OR NEW.actor_email = '' OR NEW.actor_email LIKE 'erased:%')
+            AND (NEW.ip_address <=> OLD.ip_address OR NEW.ip_address = '')
+            AND (NEW.user_agent <=> OLD.user_agent OR NEW.user_agent = '')) THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events is append-only';
+    END IF;
+END//
+DELIMITER ;
@@ -3,11 +3,14 @@ package main
 import (
 	"log"
 	"net/http"
+	"time"
 
+	"order-service/internal/auth"
 	"order-service/internal/config"
 	"order-service/internal/database"
 	"order-service/internal/handlers"
 	"order-service/internal/middleware"
+	"order-service/internal/ordernumber"
 	"order-service/internal/repositories"
 	"order-service/internal/services"
 
@@ -53,9 +56,55 @@ func main() {
 	}
 
 	orderRepo := repositories.NewOrderRepository(db.GetDB())
-	orderService := services.NewOrderService(orderRepo)
+	var orderNumbers ordernumber.Generator
+	switch cfg.OrderNumber.Generator {
+	case ordernumber.GeneratorSequence, "":
+		orderNumbers = ordernumber.NewSequenceGenerator(repositories.NewSequenceRepository(db.GetDB()), ordernumber.SequenceOptions{
+			Prefix:     cfg.OrderNumber.Prefix,
+			Daily:      cfg.OrderNumber.Daily,
+			CheckDigit: cfg.OrderNumber.CheckDigit,
+		})
+	case ordernumber.GeneratorULID:
+		orderNumbers = ordernumber.NewULIDGenerator(cfg.OrderNumber.Prefix)
+	default:
+		log.Fatalf("Unsupported order number generator: %s", cfg.OrderNumber.Generator)
+	}
+	orderService := services.NewOrderService(orderRepo, orderNumbers)
 	orderHandler := handlers.NewOrderHandler(orderService)
 
+	var idempotencyStore middleware.IdempotencyStore
+	switch cfg.Idempotency.Store {
+	case middleware.IdempotencyStoreDatabase:
+		idempotencyStore = repositories.NewIdempotencyRepository(db.GetDB())
+	case middleware.IdempotencyStoreMemory, "":
+		idempotencyStore = middleware.NewMemoryIdempotencyStore()
+	default:
+		log.Fatalf("Unsupported idempotency store: %s", cfg.Idempotency.Store)
+	}
+	idempotency := middleware.Idempotency(idempotencyStore, time.Duration(cfg.Idempotency.TTL)*time.Second)
+
+	var authMiddleware gin.HandlerFunc
+	if cfg.Auth.TrustedGateway {
+		log.Println("Trusting X-User-ID from the API gateway, bearer tokens are not verified")
+		authMiddleware = middleware.TrustedGatewayMiddleware()
+	} else {
+		var jwksClient *auth.JWKSClient
+		jwtSecret := cfg.Auth.JWTSecret
+		if cfg.Auth.JWKSURL != "" {
+			jwksClient = auth.NewJWKSClient(cfg.Auth.JWKSURL, time.Duration(cfg.Auth.JWKSCacheTTL)*time.Second)
+			if jwtSecret != "" {
+				log.Println("Ignoring auth.jwt_secret, HS256 tokens are refused when auth.jwks_url is set")
+				jwtSecret = ""
+			}
+		}
+		var apiKeyClient *auth.APIKeyClient
+		if cfg.Auth.ValidateURL != "" {
+			apiKeyClient = auth.NewAPIKeyClient(cfg.Auth.ValidateURL, time.Duration(cfg.Auth.APIKeyCacheTTL)*time.Second)
+		}
+		verifier := auth.NewTokenVerifier(jwtSecret, jwksClient, apiKeyClient, cfg.Auth.Issuer)
+		authMiddleware = middleware.AuthMiddleware(verifier)
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(gin.Logger())
@@ -67,12 +116,17 @@ func main() {
 	api := router.Group("/api/v1")
 	{
 		orders := api.Group("/orders")
-		orders.Use(middleware.AuthMiddleware())
+		orders.Use(authMiddleware)
 		{
-			orders.POST("", orderHandler.CreateOrder)
-			orders.GET("", orderHandler.GetOrders)
+			orders.POST("", middleware.RequireUser(), idempotency, orderHandler.CreateOrder)
+			orders.GET("", middleware.RequireUser(), orderHandler.GetOrders)
+			orders.GET("/org", middleware.RequireOrgAdmin(), orderHandler.GetOrgOrders)
+			orders.GET("/users/:user_id", middleware.RequirePermission(auth.PermissionOrdersReadAny), orderHandler.GetUserOrders)
+			orders.GET("/users/:user_id/export", middleware.RequirePermission(auth.PermissionOrdersReadAny), orderHandler.ExportUserOrders)
+			orders.POST("/users/:user_id/erase", middleware.RequirePermission(auth.PermissionOrdersEraseAny), orderHandler.EraseUserOrders)
 			orders.GET("/:id", orderHandler.GetOrder)
 			orders.PUT("/:id", orderHandler.UpdateOrder)
+			orders.GET("/:id/history", orderHandler.GetOrderHistory)
 			orders.DELETE("/:id", orderHandler.DeleteOrder)
 		}
 	}
@@ -87,6 +141,17 @@ func main() {
 	// Prometheus metrics endpoint
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	// Periodically drop expired idempotency keys
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := idempotencyStore.DeleteExpired(time.Now().UTC()); err != nil {
+				log.Printf("Failed to clean up idempotency keys: %v", err)
+			}
+		}
+	}()
+
 	log.Printf("Order service starting on %s", cfg.GetServerAddr())
 
 	// Start main server
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyPrefix marks the long-lived API keys users create in auth-service.
+// Unlike access tokens they can not be verified locally.
+const APIKeyPrefix = "ak_"
+
+// ErrInvalidAPIKey is returned when auth-service rejects an API key.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// IsAPIKey reports whether a bearer credential is an API key rather than an
+// access token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+type validateResponse struct {
+	User struct {
+		ID       uint   `json:"id"`
+		Email    string `json:"email"`
+		IsActive bool   `json:"is_active"`
+	} `json:"user"`
+	Scope    string `json:"scope"`
+	APIKeyID uint   `json:"api_key_id"`
+	OrgID    uint   `json:"org_id"`
+	OrgRole  string `json:"org_role"`
+}
+
+type cachedAPIKey struct {
+	claims    *Claims
+	expiresAt time.Time
+}
+
+// APIKeyClient validates API keys against the auth service's validate
+// endpoint and caches accepted keys for ttl, so a revoked key keeps working
+// for at most that long. Rejections are not cached.
+type APIKeyClient struct {
+	url        string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey
+}
+
+func NewAPIKeyClient(url string, ttl time.Duration) *APIKeyClient {
+	return &APIKeyClient{
+		url:        url,
+		ttl:        ttl,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		cache:      make(map[string]cachedAPIKey),
+	}
+}
+
+// Verify returns the claims of the user the key belongs to, with the scope
+// delegated to the key.
+func (c *APIKeyClient) Verify(key string) (*Claims, error) {
+	sum := sha256.Sum256([]byte(key))
+	cacheKey := hex.EncodeToString(sum[:])
+
+	c.mu.Lock()
+	entry, ok := c.cache[cacheKey]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.claims, nil
+	}
+
+	claims, err := c.validate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	for k, e := range c.cache {
+		if now.After(e.expiresAt) {
+			delete(c.cache, k)
+		}
+	}
+	c.cache[cacheKey] = cachedAPIKey{claims: claims, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+
+	return claims, nil
+}
+
+func (c *APIKeyClient) validate(key string) (*Claims, error) {
+	req, err := http.NewRequest(http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate API key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrInvalidAPIKey
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to validate API key: %s returned %d", c.url, resp.StatusCode)
+	}
+
+	var body validateResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode validate response: %w", err)
+	}
+	if body.User.ID == 0 || body.APIKeyID == 0 {
+		return nil, ErrInvalidAPIKey
+	}
+	if !body.User.IsActive {
+		return nil, errors.New("user account is deactivated")
+	}
+
+	return &Claims{
+		UserID:      body.User.ID,
+		Email:       body.User.Email,
+		IsActive:    body.User.IsActive,
+		Scope:       body.Scope,
+		SubjectType: SubjectTypeUser,
+		APIKeyID:    body.APIKeyID,
+		OrgID:       body.OrgID,
+		OrgRole:     body.OrgRole,
+	}, nil
+}
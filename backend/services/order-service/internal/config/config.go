@@ -8,8 +8,11 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Auth        AuthConfig        `mapstructure:"auth"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	OrderNumber OrderNumberConfig `mapstructure:"order_number"`
 	// Prometheus PrometheusConfig `mapstructure:"prometheus"`
 }
 
@@ -26,6 +29,39 @@ type DatabaseConfig struct {
 	DBName   string `mapstructure:"dbname"`
 }
 
+// AuthConfig controls how callers are authenticated. With TrustedGateway the
+// X-User-ID header is taken at face value; otherwise bearer tokens are
+// verified locally with the keys at JWKSURL or, only when JWKSURL is empty,
+// with JWTSecret (HS256), and API keys are checked at the auth service's
+// ValidateURL.
+type AuthConfig struct {
+	TrustedGateway bool   `mapstructure:"trusted_gateway"`
+	JWTSecret      string `mapstructure:"jwt_secret"`
+	JWKSURL        string `mapstructure:"jwks_url"`
+	JWKSCacheTTL   int    `mapstructure:"jwks_cache_ttl"`
+	ValidateURL    string `mapstructure:"validate_url"`
+	APIKeyCacheTTL int    `mapstructure:"api_key_cache_ttl"`
+	Issuer         string `mapstructure:"issuer"`
+}
+
+// IdempotencyConfig controls how long responses to requests with an
+// Idempotency-Key are kept (TTL, in seconds) and where: "memory" or
+// "database", which replicas need.
+type IdempotencyConfig struct {
+	Store string `mapstructure:"store"`
+	TTL   int    `mapstructure:"ttl"`
+}
+
+// OrderNumberConfig selects how order numbers are generated: "sequence"
+// (consecutive numbers from a database counter, optionally restarting daily
+// with the date in the number and ending in a check digit) or "ulid".
+type OrderNumberConfig struct {
+	Generator  string `mapstructure:"generator"`
+	Prefix     string `mapstructure:"prefix"`
+	Daily      bool   `mapstructure:"daily"`
+	CheckDigit bool   `mapstructure:"check_digit"`
+}
+
 // type PrometheusConfig struct {
 // 	Port int `mapstructure:"port"`
 // }
@@ -53,6 +53,9 @@ func (d *Database) AutoMigrate() error {
 	if err := d.DB.AutoMigrate(
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderStatusHistory{},
+		&models.IdempotencyKey{},
+		&models.OrderNumberSequence{},
 	); err != nil {
 		return fmt.Errorf("failed to auto-migrate database: %w", err)
 	}
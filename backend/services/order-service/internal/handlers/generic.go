@@ -19,3 +19,9 @@ type GenericSuccessResponse struct {
 type GetOrdersResponse struct {
 	Orders []*models.Order `json:"orders"`
 }
+
+// GetOrderHistoryResponse represents the status history of an order.
+// @name GetOrderHistoryResponse
+type GetOrderHistoryResponse struct {
+	History []*models.OrderStatusHistory `json:"history"`
+}
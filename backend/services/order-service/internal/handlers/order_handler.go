@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"order-service/internal/auth"
+	"order-service/internal/middleware"
 	"order-service/internal/services"
 	"strconv"
 
@@ -18,13 +21,16 @@ func NewOrderHandler(orderService services.IOrderService) *OrderHandler {
 
 // CreateOrder godoc
 // @Summary Create a new order
-// @Description Create a new order for the authenticated user
+// @Description Create a new order for the authenticated user in their active organization. Retries carrying the same Idempotency-Key get the original response instead of creating another order.
 // @Tags orders
 // @Accept  json
 // @Produce  json
+// @Param   Idempotency-Key header string false "Unique key making retries safe"
 // @Param   order body services.CreateOrderRequest true "Create Order"
 // @Success 201 {object} models.Order
 // @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 409 {object} handlers.GenericErrorResponse
+// @Failure 422 {object} handlers.GenericErrorResponse
 // @Security ApiKeyAuth
 // @Router /orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
@@ -41,7 +47,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.CreateOrder(userID, &req)
+	order, err := h.orderService.CreateOrder(middleware.TenantID(c), userID, &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -52,7 +58,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 // GetOrder godoc
 // @Summary Get an order by ID
-// @Description Get an order by ID
+// @Description Get an order by ID in the caller's active organization. Organization admins see all orders of their organization.
 // @Tags orders
 // @Produce  json
 // @Param   id path int true "Order ID"
@@ -74,13 +80,13 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.GetOrder(uint(orderID))
+	order, err := h.orderService.GetOrder(middleware.TenantID(c), uint(orderID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
 		return
 	}
 
-	if order.UserID != userID {
+	if !order.OwnedBy(userID) && !middleware.HasPermission(c, auth.PermissionOrdersReadAny) && !middleware.IsOrgAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized access to order"})
 		return
 	}
@@ -90,7 +96,7 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 
 // UpdateOrder godoc
 // @Summary Update an order
-// @Description Update an order's status
+// @Description Move an order to another status. Customers may only cancel their orders before they are shipped; confirming, shipping and delivering require the orders:update:any permission. Every change is recorded in the order's status history.
 // @Tags orders
 // @Accept  json
 // @Produce  json
@@ -98,6 +104,8 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 // @Param   order body services.UpdateOrderRequest true "Update Order"
 // @Success 200 {object} models.Order
 // @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Failure 409 {object} handlers.GenericErrorResponse
 // @Security ApiKeyAuth
 // @Router /orders/{id} [put]
 func (h *OrderHandler) UpdateOrder(c *gin.Context) {
@@ -115,13 +123,14 @@ func (h *OrderHandler) UpdateOrder(c *gin.Context) {
 	}
 
 	// Check ownership before updating
-	existingOrder, err := h.orderService.GetOrder(uint(orderID))
+	existingOrder, err := h.orderService.GetOrder(middleware.TenantID(c), uint(orderID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
 		return
 	}
 
-	if existingOrder.UserID != userID {
+	staff := middleware.HasPermission(c, auth.PermissionOrdersUpdateAny)
+	if !existingOrder.OwnedBy(userID) && !staff {
 		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized access to order"})
 		return
 	}
@@ -132,23 +141,71 @@ func (h *OrderHandler) UpdateOrder(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.UpdateOrder(uint(orderID), &req)
+	actor := &services.Actor{UserID: userID, ClientID: c.GetString("client_id"), Staff: staff}
+	order, err := h.orderService.UpdateOrder(middleware.TenantID(c), uint(orderID), actor, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, order)
 }
 
+// GetOrderHistory godoc
+// @Summary Get the status history of an order
+// @Description Every status change of an order, oldest first, with the actor, their role and the reason given. Visible to whoever can view the order.
+// @Tags orders
+// @Produce  json
+// @Param   id path int true "Order ID"
+// @Success 200 {object} handlers.GetOrderHistoryResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Failure 404 {object} handlers.GenericErrorResponse
+// @Security ApiKeyAuth
+// @Router /orders/{id}/history [get]
+func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
+	userIDStr := c.GetString("user_id")
+	userID, err := h.orderService.ValidateUserID(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
+		return
+	}
+
+	order, err := h.orderService.GetOrder(middleware.TenantID(c), uint(orderID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+
+	if !order.OwnedBy(userID) && !middleware.HasPermission(c, auth.PermissionOrdersReadAny) && !middleware.IsOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized access to order"})
+		return
+	}
+
+	history, err := h.orderService.GetOrderHistory(middleware.TenantID(c), uint(orderID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch order history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, GetOrderHistoryResponse{History: history})
+}
+
 // DeleteOrder godoc
 // @Summary Delete an order
-// @Description Delete an order by ID
+// @Description Delete an order by ID. Customers may only delete their pending orders; the deletion is recorded in the status history.
 // @Tags orders
 // @Produce  json
 // @Param   id path int true "Order ID"
 // @Success 200 {object} handlers.GenericSuccessResponse
 // @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Failure 409 {object} handlers.GenericErrorResponse
 // @Security ApiKeyAuth
 // @Router /orders/{id} [delete]
 func (h *OrderHandler) DeleteOrder(c *gin.Context) {
@@ -166,19 +223,21 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 	}
 
 	// Check ownership before deleting
-	existingOrder, err := h.orderService.GetOrder(uint(orderID))
+	existingOrder, err := h.orderService.GetOrder(middleware.TenantID(c), uint(orderID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
 		return
 	}
 
-	if existingOrder.UserID != userID {
+	staff := middleware.HasPermission(c, auth.PermissionOrdersDeleteAny)
+	if !existingOrder.OwnedBy(userID) && !staff {
 		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized access to order"})
 		return
 	}
 
-	if err := h.orderService.DeleteOrder(uint(orderID)); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	actor := &services.Actor{UserID: userID, ClientID: c.GetString("client_id"), Staff: staff}
+	if err := h.orderService.DeleteOrder(middleware.TenantID(c), uint(orderID), actor); err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -187,12 +246,22 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 
 // GetOrders godoc
 // @Summary Get all orders for the authenticated user
-// @Description Get all orders for the authenticated user
+// @Description Get the orders the authenticated user placed in their active organization, filtered, sorted (newest first by default) and paged with an opaque cursor
 // @Tags orders
 // @Produce  json
-// @Param   offset query int false "Offset"
-// @Param   limit query int false "Limit"
-// @Success 200 {object} handlers.GetOrdersResponse
+// @Param   status query string false "Status" Enums(pending, confirmed, shipped, delivered, cancelled)
+// @Param   from query string false "Created at or after (RFC 3339 or 2006-01-02)"
+// @Param   to query string false "Created before (RFC 3339), or on or before (2006-01-02)"
+// @Param   currency query string false "ISO 4217 currency"
+// @Param   min_total query string false "Minimum total in currency (default USD)"
+// @Param   max_total query string false "Maximum total in currency (default USD)"
+// @Param   product_id query string false "Contains the product"
+// @Param   sort query string false "Sort order" Enums(created_at, -created_at, total, -total)
+// @Param   cursor query string false "next_cursor of the previous page"
+// @Param   offset query int false "Offset, ignored with a cursor"
+// @Param   limit query int false "Limit (default 10, max 100)"
+// @Success 200 {object} services.ListOrdersResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
 // @Failure 500 {object} handlers.GenericErrorResponse
 // @Security ApiKeyAuth
 // @Router /orders [get]
@@ -204,14 +273,107 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 		return
 	}
 
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	var req services.ListOrdersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.orderService.GetOrdersByUserID(middleware.TenantID(c), userID, &req)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetUserOrders godoc
+// @Summary Get all orders of a user
+// @Description Get the orders of any user in the caller's active organization. Requires the orders:read:any permission. Takes the same filters, sort order and paging as GET /orders.
+// @Tags orders
+// @Produce  json
+// @Param   user_id path int true "User ID"
+// @Param   status query string false "Status"
+// @Param   sort query string false "Sort order" Enums(created_at, -created_at, total, -total)
+// @Param   cursor query string false "next_cursor of the previous page"
+// @Param   limit query int false "Limit (default 10, max 100)"
+// @Success 200 {object} services.ListOrdersResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Security ApiKeyAuth
+// @Router /orders/users/{user_id} [get]
+func (h *OrderHandler) GetUserOrders(c *gin.Context) {
+	userID, err := h.orderService.ValidateUserID(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var req services.ListOrdersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.orderService.GetOrdersByUserID(middleware.TenantID(c), userID, &req)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
 
-	if limit > 100 {
-		limit = 100
+// GetOrgOrders godoc
+// @Summary Get all orders of the organization
+// @Description Get the orders of all users of the caller's active organization. Requires the admin role in the organization. Takes the same filters, sort order and paging as GET /orders.
+// @Tags orders
+// @Produce  json
+// @Param   status query string false "Status"
+// @Param   sort query string false "Sort order" Enums(created_at, -created_at, total, -total)
+// @Param   cursor query string false "next_cursor of the previous page"
+// @Param   limit query int false "Limit (default 10, max 100)"
+// @Success 200 {object} services.ListOrdersResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Security ApiKeyAuth
+// @Router /orders/org [get]
+func (h *OrderHandler) GetOrgOrders(c *gin.Context) {
+	var req services.ListOrdersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.orderService.GetOrdersByOrgID(middleware.TenantID(c), &req)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExportUserOrders godoc
+// @Summary Export all orders of a user
+// @Description All orders of a user in all organizations, including deleted ones, for a data export. Requires the orders:read:any permission.
+// @Tags orders
+// @Produce  json
+// @Param   user_id path int true "User ID"
+// @Success 200 {object} handlers.GetOrdersResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Security ApiKeyAuth
+// @Router /orders/users/{user_id}/export [get]
+func (h *OrderHandler) ExportUserOrders(c *gin.Context) {
+	userID, err := h.orderService.ValidateUserID(c.Param("user_id"))
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
 	}
 
-	orders, err := h.orderService.GetOrdersByUserID(userID, offset, limit)
+	orders, err := h.orderService.ExportUserOrders(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch orders"})
 		return
@@ -219,3 +381,51 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"orders": orders})
 }
+
+// EraseUserOrders godoc
+// @Summary Pseudonymize the orders of an erased user
+// @Description Detach all orders of a user, in all organizations, from their account, which is being erased. The orders are kept for accounting under a random customer_ref. Requires the orders:erase:any permission.
+// @Tags orders
+// @Produce  json
+// @Param   user_id path int true "User ID"
+// @Success 200 {object} services.EraseUserOrdersResponse
+// @Failure 400 {object} handlers.GenericErrorResponse
+// @Failure 403 {object} handlers.GenericErrorResponse
+// @Security ApiKeyAuth
+// @Router /orders/users/{user_id}/erase [post]
+func (h *OrderHandler) EraseUserOrders(c *gin.Context) {
+	userID, err := h.orderService.ValidateUserID(c.Param("user_id"))
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	result, err := h.orderService.EraseUserOrders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to erase orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func orderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrTransitionForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidTransition):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func respondListError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidListQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch orders"})
+}
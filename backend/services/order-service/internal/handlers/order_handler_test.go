@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"order-service/internal/auth"
 	"order-service/internal/models"
 	"order-service/internal/services"
 	"testing"
@@ -20,43 +22,75 @@ type MockOrderService struct {
 	mock.Mock
 }
 
-func (m *MockOrderService) CreateOrder(userID uint, req *services.CreateOrderRequest) (*models.Order, error) {
-	args := m.Called(userID, req)
+func (m *MockOrderService) CreateOrder(orgID, userID uint, req *services.CreateOrderRequest) (*models.Order, error) {
+	args := m.Called(orgID, userID, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Order), args.Error(1)
 }
 
-func (m *MockOrderService) GetOrder(orderID uint) (*models.Order, error) {
-	args := m.Called(orderID)
+func (m *MockOrderService) GetOrder(orgID, orderID uint) (*models.Order, error) {
+	args := m.Called(orgID, orderID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Order), args.Error(1)
 }
 
-func (m *MockOrderService) UpdateOrder(orderID uint, req *services.UpdateOrderRequest) (*models.Order, error) {
-	args := m.Called(orderID, req)
+func (m *MockOrderService) UpdateOrder(orgID, orderID uint, actor *services.Actor, req *services.UpdateOrderRequest) (*models.Order, error) {
+	args := m.Called(orgID, orderID, actor, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Order), args.Error(1)
 }
 
-func (m *MockOrderService) DeleteOrder(orderID uint) error {
-	args := m.Called(orderID)
+func (m *MockOrderService) GetOrderHistory(orgID, orderID uint) ([]*models.OrderStatusHistory, error) {
+	args := m.Called(orgID, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.OrderStatusHistory), args.Error(1)
+}
+
+func (m *MockOrderService) DeleteOrder(orgID, orderID uint, actor *services.Actor) error {
+	args := m.Called(orgID, orderID, actor)
 	return args.Error(0)
 }
 
-func (m *MockOrderService) GetOrdersByUserID(userID uint, offset, limit int) ([]*models.Order, error) {
-	args := m.Called(userID, offset, limit)
+func (m *MockOrderService) GetOrdersByUserID(orgID, userID uint, req *services.ListOrdersRequest) (*services.ListOrdersResponse, error) {
+	args := m.Called(orgID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ListOrdersResponse), args.Error(1)
+}
+
+func (m *MockOrderService) GetOrdersByOrgID(orgID uint, req *services.ListOrdersRequest) (*services.ListOrdersResponse, error) {
+	args := m.Called(orgID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ListOrdersResponse), args.Error(1)
+}
+
+func (m *MockOrderService) ExportUserOrders(userID uint) ([]*models.Order, error) {
+	args := m.Called(userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.Order), args.Error(1)
 }
 
+func (m *MockOrderService) EraseUserOrders(userID uint) (*services.EraseUserOrdersResponse, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.EraseUserOrdersResponse), args.Error(1)
+}
+
 func (m *MockOrderService) ValidateUserID(userIDStr string) (uint, error) {
 	args := m.Called(userIDStr)
 	return uint(args.Int(0)), args.Error(1)
@@ -79,7 +113,7 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 					ProductID:   "test-product-id",
 					ProductName: "Test Product",
 					Quantity:    1,
-					UnitPrice:   10.0,
+					UnitPrice:   "10.00",
 				},
 			},
 		}
@@ -92,7 +126,7 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			UserID:      1,
 			OrderNumber: "ORD-123",
 			Status:      "pending",
-			TotalAmount: 10.0,
+			TotalAmount: 1000,
 			Currency:    "USD",
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
@@ -103,13 +137,13 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 					ProductID:   "test-product-id",
 					ProductName: "Test Product",
 					Quantity:    1,
-					UnitPrice:   10.0,
-					TotalPrice:  10.0,
+					UnitPrice:   1000,
+					TotalPrice:  1000,
 				},
 			},
 		}
 		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
-		mockOrderService.On("CreateOrder", uint(1), reqBody).Return(mockOrder, nil)
+		mockOrderService.On("CreateOrder", uint(0), uint(1), reqBody).Return(mockOrder, nil)
 
 		orderHandler.CreateOrder(c)
 
@@ -124,6 +158,9 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 		assert.Equal(t, mockOrder.TotalAmount, resp.TotalAmount)
 		assert.Equal(t, mockOrder.Currency, resp.Currency)
 		assert.Equal(t, len(mockOrder.OrderItems), len(resp.OrderItems))
+		assert.Equal(t, mockOrder.OrderItems[0].UnitPrice, resp.OrderItems[0].UnitPrice)
+		assert.Contains(t, w.Body.String(), `"total_amount":"10.00"`)
+		assert.Contains(t, w.Body.String(), `"unit_price":"10.00"`)
 		mockOrderService.AssertExpectations(t)
 	})
 
@@ -153,18 +190,20 @@ func TestOrderHandler_GetOrder(t *testing.T) {
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "1")
 
 		mockOrder := &models.Order{
 			ID:          1,
 			UserID:      1,
 			OrderNumber: "ORD-123",
 			Status:      "pending",
-			TotalAmount: 10.0,
+			TotalAmount: 1000,
 			Currency:    "USD",
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		}
-		mockOrderService.On("GetOrder", uint(1)).Return(mockOrder, nil)
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(mockOrder, nil)
 
 		orderHandler.GetOrder(c)
 
@@ -188,8 +227,10 @@ func TestOrderHandler_GetOrder(t *testing.T) {
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "1")
 
-		mockOrderService.On("GetOrder", uint(1)).Return(nil, errors.New("order not found"))
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(nil, errors.New("order not found"))
 
 		orderHandler.GetOrder(c)
 
@@ -197,6 +238,138 @@ func TestOrderHandler_GetOrder(t *testing.T) {
 	})
 }
 
+func TestOrderHandler_GetOrder_OtherUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("forbidden without permission", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "2")
+		c.Set("claims", &auth.Claims{UserID: 2})
+
+		mockOrderService.On("ValidateUserID", "2").Return(2, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 1}, nil)
+
+		orderHandler.GetOrder(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("allowed with orders:read:any", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "2")
+		c.Set("claims", &auth.Claims{UserID: 2, Roles: []string{"support"}, Scope: auth.PermissionOrdersReadAny})
+
+		mockOrderService.On("ValidateUserID", "2").Return(2, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 1}, nil)
+
+		orderHandler.GetOrder(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockOrderService.AssertExpectations(t)
+	})
+
+	t.Run("allowed for organization admin", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "2")
+		c.Set("org_id", "3")
+		c.Set("org_role", auth.OrgRoleAdmin)
+		c.Set("claims", &auth.Claims{UserID: 2, OrgID: 3, OrgRole: auth.OrgRoleAdmin})
+
+		mockOrderService.On("ValidateUserID", "2").Return(2, nil)
+		mockOrderService.On("GetOrder", uint(3), uint(1)).Return(&models.Order{ID: 1, UserID: 1, OrgID: 3}, nil)
+
+		orderHandler.GetOrder(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockOrderService.AssertExpectations(t)
+	})
+
+	t.Run("forbidden for organization member", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "2")
+		c.Set("org_id", "3")
+		c.Set("org_role", "member")
+		c.Set("claims", &auth.Claims{UserID: 2, OrgID: 3, OrgRole: "member"})
+
+		mockOrderService.On("ValidateUserID", "2").Return(2, nil)
+		mockOrderService.On("GetOrder", uint(3), uint(1)).Return(&models.Order{ID: 1, UserID: 1, OrgID: 3}, nil)
+
+		orderHandler.GetOrder(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestOrderHandler_GetOrgOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockOrderService := new(MockOrderService)
+	orderHandler := NewOrderHandler(mockOrderService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/orders/org?limit=500", nil)
+	c.Set("user_id", "2")
+	c.Set("org_id", "3")
+	c.Set("org_role", auth.OrgRoleAdmin)
+
+	mockOrderService.On("GetOrdersByOrgID", uint(3), &services.ListOrdersRequest{Limit: 500}).Return(&services.ListOrdersResponse{
+		Orders:     []*models.Order{{ID: 1, UserID: 1, OrgID: 3}, {ID: 2, UserID: 5, OrgID: 3}},
+		TotalCount: 2,
+	}, nil)
+
+	orderHandler.GetOrgOrders(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp GetOrdersResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Orders, 2)
+	mockOrderService.AssertExpectations(t)
+}
+
+func TestOrderHandler_GetOrder_Erased(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("client does not own erased orders", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "0")
+		c.Set("client_id", "billing")
+		c.Set("claims", &auth.Claims{SubjectType: auth.SubjectTypeClient, ClientID: "billing"})
+
+		mockOrderService.On("ValidateUserID", "0").Return(0, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 0, CustomerRef: "cust_1"}, nil)
+
+		orderHandler.GetOrder(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
 func TestOrderHandler_UpdateOrder(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -207,9 +380,10 @@ func TestOrderHandler_UpdateOrder(t *testing.T) {
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "1")
 
 		reqBody := &services.UpdateOrderRequest{
-			Status: "shipped",
+			Status: "cancelled",
 		}
 		reqBytes, _ := json.Marshal(reqBody)
 		c.Request, _ = http.NewRequest(http.MethodPut, "/orders/1", bytes.NewBuffer(reqBytes))
@@ -219,13 +393,16 @@ func TestOrderHandler_UpdateOrder(t *testing.T) {
 			ID:          1,
 			UserID:      1,
 			OrderNumber: "ORD-123",
-			Status:      "shipped",
-			TotalAmount: 10.0,
+			Status:      "cancelled",
+			TotalAmount: 1000,
 			Currency:    "USD",
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		}
-		mockOrderService.On("UpdateOrder", uint(1), reqBody).Return(mockOrder, nil)
+		actor := &services.Actor{UserID: 1}
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 1, Status: "pending"}, nil)
+		mockOrderService.On("UpdateOrder", uint(0), uint(1), actor, reqBody).Return(mockOrder, nil)
 
 		orderHandler.UpdateOrder(c)
 
@@ -236,6 +413,108 @@ func TestOrderHandler_UpdateOrder(t *testing.T) {
 		assert.Equal(t, mockOrder.Status, resp.Status)
 		mockOrderService.AssertExpectations(t)
 	})
+
+	t.Run("transition not permitted", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "1")
+
+		reqBody := &services.UpdateOrderRequest{
+			Status: "shipped",
+		}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/orders/1", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		actor := &services.Actor{UserID: 1}
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 1, Status: "confirmed"}, nil)
+		mockOrderService.On("UpdateOrder", uint(0), uint(1), actor, reqBody).Return(nil, services.ErrTransitionForbidden)
+
+		orderHandler.UpdateOrder(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invalid transition", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "1")
+
+		reqBody := &services.UpdateOrderRequest{
+			Status: "pending",
+		}
+		reqBytes, _ := json.Marshal(reqBody)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/orders/1", bytes.NewBuffer(reqBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		actor := &services.Actor{UserID: 1}
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 1, Status: "delivered"}, nil)
+		mockOrderService.On("UpdateOrder", uint(0), uint(1), actor, reqBody).Return(nil, services.ErrInvalidTransition)
+
+		orderHandler.UpdateOrder(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestOrderHandler_GetOrderHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "1")
+
+		history := []*models.OrderStatusHistory{
+			{ID: 1, OrderID: 1, ToStatus: "pending", ActorID: 1, ActorRole: "customer"},
+			{ID: 2, OrderID: 1, FromStatus: "pending", ToStatus: "cancelled", ActorID: 1, ActorRole: "customer"},
+		}
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 1, Status: "cancelled"}, nil)
+		mockOrderService.On("GetOrderHistory", uint(0), uint(1)).Return(history, nil)
+
+		orderHandler.GetOrderHistory(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp GetOrderHistoryResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Len(t, resp.History, 2)
+		assert.Equal(t, "cancelled", resp.History[1].ToStatus)
+		mockOrderService.AssertExpectations(t)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "2")
+
+		mockOrderService.On("ValidateUserID", "2").Return(2, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 1, Status: "pending"}, nil)
+
+		orderHandler.GetOrderHistory(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockOrderService.AssertNotCalled(t, "GetOrderHistory", uint(0), uint(1))
+	})
 }
 
 func TestOrderHandler_DeleteOrder(t *testing.T) {
@@ -248,13 +527,34 @@ func TestOrderHandler_DeleteOrder(t *testing.T) {
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "1")
 
-		mockOrderService.On("DeleteOrder", uint(1)).Return(nil)
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 1, Status: "pending"}, nil)
+		mockOrderService.On("DeleteOrder", uint(0), uint(1), &services.Actor{UserID: 1}).Return(nil)
 
 		orderHandler.DeleteOrder(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
+
+	t.Run("confirmed order of a customer", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Set("user_id", "1")
+
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrder", uint(0), uint(1)).Return(&models.Order{ID: 1, UserID: 1, Status: "confirmed"}, nil)
+		mockOrderService.On("DeleteOrder", uint(0), uint(1), &services.Actor{UserID: 1}).Return(services.ErrTransitionForbidden)
+
+		orderHandler.DeleteOrder(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
 }
 
 func TestOrderHandler_GetOrders(t *testing.T) {
@@ -275,22 +575,165 @@ func TestOrderHandler_GetOrders(t *testing.T) {
 				UserID:      1,
 				OrderNumber: "ORD-123",
 				Status:      "pending",
-				TotalAmount: 10.0,
+				TotalAmount: 1000,
 				Currency:    "USD",
 				CreatedAt:   time.Now(),
 				UpdatedAt:   time.Now(),
 			},
 		}
 		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
-		mockOrderService.On("GetOrdersByUserID", uint(1), 0, 10).Return(mockOrders, nil)
+		mockOrderService.On("GetOrdersByUserID", uint(0), uint(1), &services.ListOrdersRequest{Limit: 10}).
+			Return(&services.ListOrdersResponse{Orders: mockOrders, TotalCount: 11, NextCursor: "next"}, nil)
 
 		orderHandler.GetOrders(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var resp gin.H
+		var resp services.ListOrdersResponse
 		err := json.Unmarshal(w.Body.Bytes(), &resp)
 		assert.NoError(t, err)
-		assert.NotNil(t, resp["orders"])
+		assert.Len(t, resp.Orders, 1)
+		assert.Equal(t, int64(11), resp.TotalCount)
+		assert.Equal(t, "next", resp.NextCursor)
+		mockOrderService.AssertExpectations(t)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", "1")
+		c.Request, _ = http.NewRequest(http.MethodGet,
+			"/orders?status=shipped&from=2026-01-01&to=2026-01-31&min_total=10.50&product_id=p-1&sort=-total&cursor=abc&limit=20", nil)
+
+		req := &services.ListOrdersRequest{
+			Status:    "shipped",
+			From:      "2026-01-01",
+			To:        "2026-01-31",
+			MinTotal:  "10.50",
+			ProductID: "p-1",
+			Sort:      "-total",
+			Cursor:    "abc",
+			Limit:     20,
+		}
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrdersByUserID", uint(0), uint(1), req).Return(&services.ListOrdersResponse{Orders: []*models.Order{}}, nil)
+
+		orderHandler.GetOrders(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockOrderService.AssertExpectations(t)
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", "1")
+		c.Request, _ = http.NewRequest(http.MethodGet, "/orders?sort=product_name", nil)
+
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+
+		orderHandler.GetOrders(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockOrderService.AssertNotCalled(t, "GetOrdersByUserID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", "1")
+		c.Request, _ = http.NewRequest(http.MethodGet, "/orders?cursor=bogus", nil)
+
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("GetOrdersByUserID", uint(0), uint(1), &services.ListOrdersRequest{Cursor: "bogus"}).
+			Return(nil, fmt.Errorf("%w: invalid cursor", services.ErrInvalidListQuery))
+
+		orderHandler.GetOrders(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+func TestOrderHandler_ExportUserOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "user_id", Value: "1"}}
+
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("ExportUserOrders", uint(1)).Return([]*models.Order{{ID: 1, UserID: 1}, {ID: 2, UserID: 1}}, nil)
+
+		orderHandler.ExportUserOrders(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp GetOrdersResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Orders, 2)
 		mockOrderService.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+
+	t.Run("user 0", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "user_id", Value: "0"}}
+
+		mockOrderService.On("ValidateUserID", "0").Return(0, nil)
+
+		orderHandler.ExportUserOrders(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockOrderService.AssertNotCalled(t, "ExportUserOrders", mock.Anything)
+	})
+}
+
+func TestOrderHandler_EraseUserOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "user_id", Value: "1"}}
+
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("EraseUserOrders", uint(1)).Return(&services.EraseUserOrdersResponse{OrdersPseudonymized: 3}, nil)
+
+		orderHandler.EraseUserOrders(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"orders_pseudonymized": 3}`, w.Body.String())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		mockOrderService := new(MockOrderService)
+		orderHandler := NewOrderHandler(mockOrderService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "user_id", Value: "1"}}
+
+		mockOrderService.On("ValidateUserID", "1").Return(1, nil)
+		mockOrderService.On("EraseUserOrders", uint(1)).Return(nil, errors.New("database is down"))
+
+		orderHandler.EraseUserOrders(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -2,40 +2,147 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+
+	"order-service/internal/auth"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// ClientUserID is the "user_id" of machine callers. No user has ID 0, so
+// clients own no orders and reach them only through *:any permissions.
+const ClientUserID = "0"
+
+// AuthMiddleware verifies the bearer token on every request and exposes the
+// caller as "user_id" (and the full claims as "claims") in the gin context.
+// OAuth2 client tokens additionally set "client_id"; users acting in an
+// organization set "org_id" and "org_role".
+func AuthMiddleware(verifier *auth.TokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// authHeader := c.GetHeader("Authorization")
-		// if authHeader == "" {
-		// 	c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
-		// 	c.Abort()
-		// 	return
-		// }
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+			c.Abort()
+			return
+		}
 
-		// if strings.HasPrefix(authHeader, "Bearer ") {
-		// 	// tokenString = authHeader[7:]
-		// }
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		userID := c.GetHeader("X-User-ID")
-		// userEmail := c.GetHeader("X-User-Email")
-		// userActive := c.GetHeader("X-User-Active")
+		claims, err := verifier.Verify(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			c.Abort()
+			return
+		}
+
+		if claims.IsClient() {
+			c.Set("user_id", ClientUserID)
+			c.Set("client_id", claims.ClientID)
+		} else {
+			c.Set("user_id", strconv.FormatUint(uint64(claims.UserID), 10))
+		}
+		if claims.OrgID != 0 {
+			c.Set("org_id", strconv.FormatUint(uint64(claims.OrgID), 10))
+			c.Set("org_role", claims.OrgRole)
+		}
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
 
-		// if userID == "" {
-		// 	c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user context headers"})
-		// 	c.Abort()
-		// 	return
-		// }
+// TrustedGatewayMiddleware takes the caller from the X-User-ID (or, for
+// OAuth2 clients, X-Client-ID) header and their organization from X-Org-ID
+// and X-Org-Role, all set by the API gateway after it validated the token.
+// Only use it when the service is unreachable except through that gateway.
+func TrustedGatewayMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetHeader("X-User-ID")
+		clientID := c.GetHeader("X-Client-ID")
 
-		if userID == "" {
+		switch {
+		case userID != "":
+			c.Set("user_id", userID)
+		case clientID != "":
+			c.Set("user_id", ClientUserID)
+			c.Set("client_id", clientID)
+		default:
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user context headers"})
 			c.Abort()
 			return
 		}
 
-		c.Set("user_id", userID)
+		if orgID := c.GetHeader("X-Org-ID"); orgID != "" {
+			c.Set("org_id", orgID)
+			c.Set("org_role", c.GetHeader("X-Org-Role"))
+		}
+
 		c.Next()
 	}
 }
+
+// RequireUser aborts with 403 for machine callers, on routes that act on
+// behalf of the calling user.
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("client_id") != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "endpoint requires a user token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission aborts with 403 unless the verified token grants the
+// permission. Requests authenticated by TrustedGatewayMiddleware carry no
+// permissions.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasPermission reports whether the caller's token grants the permission.
+func HasPermission(c *gin.Context, permission string) bool {
+	claims, ok := c.Get("claims")
+	if !ok {
+		return false
+	}
+	return claims.(*auth.Claims).HasScope(permission)
+}
+
+// RequireOrgAdmin aborts with 403 unless the caller administers their active
+// organization.
+func RequireOrgAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsOrgAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "endpoint requires an organization admin"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// TenantID returns the caller's active organization, which scopes every
+// order they can reach. Callers outside any organization, including OAuth2
+// clients, get 0.
+func TenantID(c *gin.Context) uint {
+	orgID, err := strconv.ParseUint(c.GetString("org_id"), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint(orgID)
+}
+
+// IsOrgAdmin reports whether the caller administers their active
+// organization.
+func IsOrgAdmin(c *gin.Context) bool {
+	return TenantID(c) != 0 && c.GetString("org_role") == auth.OrgRoleAdmin
+}
@@ -0,0 +1,341 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"order-service/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+func newTestClaims(isActive bool, expiresIn time.Duration) *auth.Claims {
+	return &auth.Claims{
+		UserID:   42,
+		Email:    "test@example.com",
+		IsActive: isActive,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "auth-service",
+		},
+	}
+}
+
+func signHS256(t *testing.T, claims jwt.Claims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["typ"] = auth.AccessTokenType
+	signed, err := token.SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return signed
+}
+
+func runAuthMiddleware(handler gin.HandlerFunc, header, value string) (*httptest.ResponseRecorder, string) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/orders", nil)
+	if value != "" {
+		c.Request.Header.Set(header, value)
+	}
+
+	handler(c)
+	return w, c.GetString("user_id")
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier := auth.NewTokenVerifier(testSecret, nil, nil, "auth-service")
+
+	t.Run("valid token", func(t *testing.T) {
+		token := signHS256(t, newTestClaims(true, time.Hour))
+
+		w, userID := runAuthMiddleware(AuthMiddleware(verifier), "Authorization", "Bearer "+token)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "42", userID)
+	})
+
+	t.Run("organization", func(t *testing.T) {
+		claims := newTestClaims(true, time.Hour)
+		claims.OrgID = 3
+		claims.OrgRole = auth.OrgRoleAdmin
+		token := signHS256(t, claims)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/orders", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+
+		AuthMiddleware(verifier)(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(3), TenantID(c))
+		assert.True(t, IsOrgAdmin(c))
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		w, _ := runAuthMiddleware(AuthMiddleware(verifier), "Authorization", "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("user id header is ignored", func(t *testing.T) {
+		w, userID := runAuthMiddleware(AuthMiddleware(verifier), "X-User-ID", "1")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, userID)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token := signHS256(t, newTestClaims(true, -time.Minute))
+
+		w, _ := runAuthMiddleware(AuthMiddleware(verifier), "Authorization", "Bearer "+token)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		claims := newTestClaims(true, time.Hour)
+		claims.Issuer = "someone-else"
+		token := signHS256(t, claims)
+
+		w, _ := runAuthMiddleware(AuthMiddleware(verifier), "Authorization", "Bearer "+token)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("not an access token", func(t *testing.T) {
+		// ID tokens are signed with the same key but carry no typ header
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, newTestClaims(true, time.Hour)).SignedString([]byte(testSecret))
+		require.NoError(t, err)
+
+		w, _ := runAuthMiddleware(AuthMiddleware(verifier), "Authorization", "Bearer "+token)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("inactive user", func(t *testing.T) {
+		token := signHS256(t, newTestClaims(false, time.Hour))
+
+		w, _ := runAuthMiddleware(AuthMiddleware(verifier), "Authorization", "Bearer "+token)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("client token", func(t *testing.T) {
+		claims := newTestClaims(true, time.Hour)
+		claims.UserID = 0
+		claims.SubjectType = auth.SubjectTypeClient
+		claims.ClientID = "reporting"
+		token := signHS256(t, claims)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/orders/1", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+
+		AuthMiddleware(verifier)(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, ClientUserID, c.GetString("user_id"))
+		assert.Equal(t, "reporting", c.GetString("client_id"))
+	})
+
+	t.Run("token signed via JWKS", func(t *testing.T) {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"keys": []map[string]string{{
+					"kty": "OKP",
+					"kid": "key-1",
+					"alg": "EdDSA",
+					"crv": "Ed25519",
+					"x":   base64.RawURLEncoding.EncodeToString(pub),
+				}},
+			})
+		}))
+		defer server.Close()
+
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, newTestClaims(true, time.Hour))
+		token.Header["kid"] = "key-1"
+		token.Header["typ"] = "application/at+jwt"
+		signed, err := token.SignedString(priv)
+		require.NoError(t, err)
+
+		jwksVerifier := auth.NewTokenVerifier("", auth.NewJWKSClient(server.URL, time.Minute), nil, "auth-service")
+		w, userID := runAuthMiddleware(AuthMiddleware(jwksVerifier), "Authorization", "Bearer "+signed)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "42", userID)
+
+		// HS256 tokens are refused when no secret is configured
+		w, _ = runAuthMiddleware(AuthMiddleware(jwksVerifier), "Authorization", "Bearer "+signHS256(t, newTestClaims(true, time.Hour)))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		// and also when one is, as long as a JWKS is used
+		bothVerifier := auth.NewTokenVerifier(testSecret, auth.NewJWKSClient(server.URL, time.Minute), nil, "auth-service")
+		w, _ = runAuthMiddleware(AuthMiddleware(bothVerifier), "Authorization", "Bearer "+signHS256(t, newTestClaims(true, time.Hour)))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer ak_valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid or expired API key"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"user":       map[string]interface{}{"id": 42, "email": "test@example.com", "is_active": true},
+			"scope":      auth.PermissionOrdersReadAny,
+			"api_key_id": 5,
+		})
+	}))
+	defer server.Close()
+
+	verifier := auth.NewTokenVerifier(testSecret, nil, auth.NewAPIKeyClient(server.URL, time.Minute), "auth-service")
+
+	t.Run("valid key", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/orders", nil)
+		c.Request.Header.Set("Authorization", "Bearer ak_valid")
+
+		AuthMiddleware(verifier)(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "42", c.GetString("user_id"))
+		claims := c.MustGet("claims").(*auth.Claims)
+		assert.True(t, claims.HasScope(auth.PermissionOrdersReadAny))
+
+		// Accepted keys are served from the cache
+		w, _ = runAuthMiddleware(AuthMiddleware(verifier), "Authorization", "Bearer ak_valid")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("rejected key", func(t *testing.T) {
+		w, _ := runAuthMiddleware(AuthMiddleware(verifier), "Authorization", "Bearer ak_revoked")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("keys not accepted", func(t *testing.T) {
+		w, _ := runAuthMiddleware(AuthMiddleware(auth.NewTokenVerifier(testSecret, nil, nil, "auth-service")), "Authorization", "Bearer ak_valid")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestTrustedGatewayMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w, userID := runAuthMiddleware(TrustedGatewayMiddleware(), "X-User-ID", "7")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "7", userID)
+
+	w, _ = runAuthMiddleware(TrustedGatewayMiddleware(), "X-User-ID", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w, userID = runAuthMiddleware(TrustedGatewayMiddleware(), "X-Client-ID", "reporting")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ClientUserID, userID)
+}
+
+func TestRequireUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(clientID string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/orders", nil)
+		if clientID != "" {
+			c.Set("client_id", clientID)
+		}
+		return w, c
+	}
+
+	w, c := newContext("")
+	RequireUser()(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, c.IsAborted())
+
+	w, c = newContext("reporting")
+	RequireUser()(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(claims *auth.Claims) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/orders/users/1", nil)
+		if claims != nil {
+			c.Set("claims", claims)
+		}
+		return w, c
+	}
+
+	w, c := newContext(&auth.Claims{Scope: "orders:read:any orders:update:any"})
+	RequirePermission(auth.PermissionOrdersReadAny)(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, c.IsAborted())
+
+	w, c = newContext(&auth.Claims{Scope: "orders:update:any"})
+	RequirePermission(auth.PermissionOrdersReadAny)(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, c = newContext(nil)
+	RequirePermission(auth.PermissionOrdersReadAny)(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequireOrgAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(orgID, orgRole string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/orders/org", nil)
+		c.Request.Header.Set("X-User-ID", "7")
+		c.Request.Header.Set("X-Org-ID", orgID)
+		c.Request.Header.Set("X-Org-Role", orgRole)
+		TrustedGatewayMiddleware()(c)
+		return w, c
+	}
+
+	w, c := newContext("3", auth.OrgRoleAdmin)
+	RequireOrgAdmin()(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, c.IsAborted())
+	assert.Equal(t, uint(3), TenantID(c))
+
+	w, c = newContext("3", "member")
+	RequireOrgAdmin()(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, c = newContext("", auth.OrgRoleAdmin)
+	RequireOrgAdmin()(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, uint(0), TenantID(c))
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"order-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyStoreMemory   = "memory"
+	IdempotencyStoreDatabase = "database"
+
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	defaultIdempotencyTTL    = 24 * time.Hour
+)
+
+// IdempotencyStore persists the responses of requests made with an
+// Idempotency-Key. Reserve must be atomic, so that of concurrent requests
+// with the same key, on any replica, only one is executed.
+type IdempotencyStore interface {
+	// Reserve stores record for a request about to be handled. If its key is
+	// already taken by a record that has not expired, it returns that record
+	// and false instead.
+	Reserve(record *models.IdempotencyKey) (*models.IdempotencyKey, bool, error)
+	// Complete stores the response to the request that reserved key.
+	Complete(key string, statusCode int, contentType string, body []byte) error
+	// Release drops the reservation of a request that failed, so it can be
+	// retried with the same key.
+	Release(key string) error
+	DeleteExpired(before time.Time) error
+}
+
+// Idempotency makes a mutating endpoint safe to retry. A request carrying an
+// Idempotency-Key header is handled once; repeating it with the same key
+// within ttl returns the stored response with Idempotent-Replayed set,
+// reusing the key for a different request fails with 422, and repeating it
+// while the first is still being handled fails with 409. Keys are scoped to
+// the caller and the route, so it has to run after the auth middleware.
+// Responses with a 5xx status and panics are not stored. Requests without the header
+// are passed through unchanged.
+func Idempotency(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now().UTC()
+		record := &models.IdempotencyKey{
+			Key:         scopedIdempotencyKey(c, idempotencyKey),
+			RequestHash: requestHash(c, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		existing, reserved, err := store.Reserve(record)
+		if err != nil {
+			log.Printf("Failed to reserve idempotency key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process Idempotency-Key"})
+			c.Abort()
+			return
+		}
+		if !reserved {
+			replay(c, existing, record.RequestHash)
+			return
+		}
+
+		// A handler that panics has not finished the request either, so its
+		// key is released for a retry before the panic reaches the recovery
+		// middleware
+		defer func() {
+			if r := recover(); r != nil {
+				if err := store.Release(record.Key); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+				panic(r)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			err = store.Release(record.Key)
+		} else {
+			err = store.Complete(record.Key, status, writer.Header().Get("Content-Type"), writer.body.Bytes())
+		}
+		if err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// replay answers a request whose key was already used.
+func replay(c *gin.Context, existing *models.IdempotencyKey, requestHash string) {
+	switch {
+	case existing.RequestHash != requestHash:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	case !existing.Completed():
+		c.JSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still being processed"})
+	default:
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+	}
+	c.Abort()
+}
+
+// scopedIdempotencyKey keeps callers, organizations and routes from seeing
+// each other's responses even when they choose the same key.
+func scopedIdempotencyKey(c *gin.Context, idempotencyKey string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		c.GetString("user_id"),
+		c.GetString("client_id"),
+		c.GetString("org_id"),
+		c.Request.Method,
+		c.FullPath(),
+		idempotencyKey,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestHash identifies a request by its target and body.
+func requestHash(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\x00"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// MemoryIdempotencyStore keeps responses in process. It is only suitable for
+// a single instance; replicas need a shared store.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyKey
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]models.IdempotencyKey)}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(record *models.IdempotencyKey) (*models.IdempotencyKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[record.Key]; ok && existing.ExpiresAt.After(time.Now()) {
+		return &existing, false, nil
+	}
+	s.records[record.Key] = *record
+	return record, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(key string, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return nil
+	}
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.ResponseBody = append([]byte(nil), body...)
+	s.records[key] = record
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) DeleteExpired(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, record := range s.records {
+		if record.ExpiresAt.Before(before) {
+			delete(s.records, key)
+		}
+	}
+	return nil
+}
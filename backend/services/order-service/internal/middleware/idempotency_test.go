@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newIdempotentRouter(store IdempotencyStore, status int) (*gin.Engine, *int) {
+	calls := 0
+	router := gin.New()
+	router.POST("/orders", func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Next()
+	}, Idempotency(store, time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(status, gin.H{"call": calls})
+	})
+	return router, &calls
+}
+
+func postIdempotent(router *gin.Engine, user, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("X-Test-User", user)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("replays the stored response", func(t *testing.T) {
+		router, calls := newIdempotentRouter(NewMemoryIdempotencyStore(), http.StatusCreated)
+
+		first := postIdempotent(router, "1", "key-1", `{"a":1}`)
+		second := postIdempotent(router, "1", "key-1", `{"a":1}`)
+
+		assert.Equal(t, http.StatusCreated, first.Code)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+		assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("different payload", func(t *testing.T) {
+		router, calls := newIdempotentRouter(NewMemoryIdempotencyStore(), http.StatusCreated)
+
+		postIdempotent(router, "1", "key-1", `{"a":1}`)
+		w := postIdempotent(router, "1", "key-1", `{"a":2}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("keys are scoped to the caller", func(t *testing.T) {
+		router, calls := newIdempotentRouter(NewMemoryIdempotencyStore(), http.StatusCreated)
+
+		postIdempotent(router, "1", "key-1", `{"a":1}`)
+		w := postIdempotent(router, "2", "key-1", `{"a":1}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("without key", func(t *testing.T) {
+		router, calls := newIdempotentRouter(NewMemoryIdempotencyStore(), http.StatusCreated)
+
+		postIdempotent(router, "1", "", `{"a":1}`)
+		postIdempotent(router, "1", "", `{"a":1}`)
+
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("server errors are not stored", func(t *testing.T) {
+		router, calls := newIdempotentRouter(NewMemoryIdempotencyStore(), http.StatusInternalServerError)
+
+		postIdempotent(router, "1", "key-1", `{"a":1}`)
+		w := postIdempotent(router, "1", "key-1", `{"a":1}`)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("panicking handler", func(t *testing.T) {
+		calls := 0
+		router := gin.New()
+		router.Use(gin.CustomRecoveryWithWriter(io.Discard, gin.RecoveryFunc(func(c *gin.Context, err any) {
+			c.AbortWithStatus(http.StatusInternalServerError)
+		})))
+		router.POST("/orders", func(c *gin.Context) {
+			c.Set("user_id", "1")
+		}, Idempotency(NewMemoryIdempotencyStore(), time.Hour), func(c *gin.Context) {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+			c.JSON(http.StatusCreated, gin.H{})
+		})
+
+		first := postIdempotent(router, "1", "key-1", `{"a":1}`)
+		retry := postIdempotent(router, "1", "key-1", `{"a":1}`)
+
+		assert.Equal(t, http.StatusInternalServerError, first.Code)
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("request in progress", func(t *testing.T) {
+		var retry *httptest.ResponseRecorder
+		router := gin.New()
+		router.POST("/orders", func(c *gin.Context) {
+			c.Set("user_id", "1")
+		}, Idempotency(NewMemoryIdempotencyStore(), time.Hour), func(c *gin.Context) {
+			if retry == nil {
+				// The client retries while the first request is still being handled
+				retry = postIdempotent(router, "1", "key-1", `{"a":1}`)
+			}
+			c.JSON(http.StatusCreated, gin.H{})
+		})
+
+		w := postIdempotent(router, "1", "key-1", `{"a":1}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, http.StatusConflict, retry.Code)
+	})
+
+	t.Run("expired key", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore()
+		router, calls := newIdempotentRouter(store, http.StatusCreated)
+
+		postIdempotent(router, "1", "key-1", `{"a":1}`)
+		for key, record := range store.records {
+			record.ExpiresAt = time.Now().Add(-time.Minute)
+			store.records[key] = record
+		}
+		w := postIdempotent(router, "1", "key-1", `{"a":2}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 2, *calls)
+	})
+}
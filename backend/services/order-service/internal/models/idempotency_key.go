@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the response to a request made with an
+// Idempotency-Key header, so a retry of the request gets the same response
+// instead of being executed again. Key is a hash of the caller, the route and
+// the header value; RequestHash a hash of the request itself. StatusCode is 0
+// while the first request is still being handled.
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Key          string    `json:"key" gorm:"column:idempotency_key;uniqueIndex;size:64;not null"`
+	RequestHash  string    `json:"request_hash" gorm:"size:64;not null"`
+	StatusCode   int       `json:"status_code" gorm:"not null;default:0"`
+	ContentType  string    `json:"content_type" gorm:"size:255"`
+	ResponseBody []byte    `json:"-" gorm:"type:mediumblob"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+}
+
+// Completed reports whether the response to the first request is stored.
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
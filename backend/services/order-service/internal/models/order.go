@@ -1,34 +1,149 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
+	"order-service/internal/money"
+
 	"gorm.io/gorm"
 )
 
+// Order belongs to the organization (tenant) OrgID it was placed in; orders
+// placed outside any organization have OrgID 0.
+//
+// Amounts are kept in minor units of Currency and appear in JSON as decimal
+// strings with the currency's number of decimal places, e.g. "24.25" for USD
+// and "2425" for JPY.
+//
+// Order is kept for accounting even after the customer's account is erased.
+// Erasure sets UserID to 0 and CustomerRef to a random reference shared by
+// all orders of the former customer, so they can still be told apart but no
+// longer traced back to the account.
 type Order struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	UserID      uint           `json:"user_id" gorm:"not null;index"`
+	OrgID       uint           `json:"org_id" gorm:"not null;default:0;index"`
 	OrderNumber string         `json:"order_number" gorm:"uniqueIndex;size:255;not null"`
 	Status      string         `json:"status" gorm:"default:'pending'"`
-	TotalAmount float64        `json:"total_amount" gorm:"type:decimal(10,2);not null"`
-	Currency    string         `json:"currency" gorm:"default:'USD'"`
+	TotalAmount money.Amount   `json:"total_amount" gorm:"column:total_minor;not null;default:0"`
+	Currency    string         `json:"currency" gorm:"size:3;not null;default:'USD'"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	CustomerRef string         `json:"customer_ref,omitempty" gorm:"size:64;index"`
+	ErasedAt    *time.Time     `json:"erased_at,omitempty"`
 	
 	OrderItems  []OrderItem    `json:"order_items" gorm:"foreignKey:OrderID"`
 }
 
+// OwnedBy reports whether the order belongs to the user. Orders of erased
+// accounts have UserID 0 and belong to nobody, in particular not to machine
+// callers, which act as user 0.
+func (o *Order) OwnedBy(userID uint) bool {
+	return userID != 0 && o.UserID == userID
+}
+
 type OrderItem struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	OrderID     uint           `json:"order_id" gorm:"not null;index"`
 	ProductID   string         `json:"product_id" gorm:"size:255;not null"`
 	ProductName string         `json:"product_name" gorm:"not null"`
 	Quantity    int            `json:"quantity" gorm:"not null"`
-	UnitPrice   float64        `json:"unit_price" gorm:"type:decimal(10,2);not null"`
-	TotalPrice  float64        `json:"total_price" gorm:"type:decimal(10,2);not null"`
+	UnitPrice   money.Amount   `json:"unit_price" gorm:"column:unit_price_minor;not null;default:0"`
+	TotalPrice  money.Amount   `json:"total_price" gorm:"column:total_price_minor;not null;default:0"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
+
+// currency returns the currency of the order's amounts. Orders are only
+// created in known currencies; anything else is shown with two decimals.
+func (o *Order) currency() money.Currency {
+	code := o.Currency
+	if code == "" {
+		code = money.DefaultCurrency
+	}
+	cur, err := money.LookupCurrency(code)
+	if err != nil {
+		return money.Currency{Code: code, Digits: 2}
+	}
+	return cur
+}
+
+// orderFields and orderItemFields have the fields but not the JSON methods
+// of Order and OrderItem; orderJSON and orderItemJSON shadow their amounts
+// with decimal strings.
+type (
+	orderFields     Order
+	orderItemFields OrderItem
+)
+
+type orderJSON struct {
+	orderFields
+	TotalAmount string          `json:"total_amount"`
+	OrderItems  []orderItemJSON `json:"order_items"`
+}
+
+type orderItemJSON struct {
+	orderItemFields
+	UnitPrice  string `json:"unit_price"`
+	TotalPrice string `json:"total_price"`
+}
+
+func (o Order) MarshalJSON() ([]byte, error) {
+	cur := o.currency()
+	out := orderJSON{
+		orderFields: orderFields(o),
+		TotalAmount: o.TotalAmount.Format(cur),
+	}
+	if o.OrderItems != nil {
+		out.OrderItems = make([]orderItemJSON, len(o.OrderItems))
+		for i, item := range o.OrderItems {
+			out.OrderItems[i] = orderItemJSON{
+				orderItemFields: orderItemFields(item),
+				UnitPrice:       item.UnitPrice.Format(cur),
+				TotalPrice:      item.TotalPrice.Format(cur),
+			}
+		}
+	}
+	return json.Marshal(out)
+}
+
+func (o *Order) UnmarshalJSON(data []byte) error {
+	var in orderJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	order := Order(in.orderFields)
+	cur := order.currency()
+	var err error
+	if order.TotalAmount, err = parseAmount(in.TotalAmount, cur); err != nil {
+		return err
+	}
+	order.OrderItems = nil
+	if in.OrderItems != nil {
+		order.OrderItems = make([]OrderItem, len(in.OrderItems))
+		for i, itemIn := range in.OrderItems {
+			item := OrderItem(itemIn.orderItemFields)
+			if item.UnitPrice, err = parseAmount(itemIn.UnitPrice, cur); err != nil {
+				return err
+			}
+			if item.TotalPrice, err = parseAmount(itemIn.TotalPrice, cur); err != nil {
+				return err
+			}
+			order.OrderItems[i] = item
+		}
+	}
+
+	*o = order
+	return nil
+}
+
+func parseAmount(s string, cur money.Currency) (money.Amount, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return money.Parse(s, cur)
+}
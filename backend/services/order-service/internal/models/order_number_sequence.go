@@ -0,0 +1,9 @@
+package models
+
+// OrderNumberSequence is a named counter order numbers are drawn from, e.g.
+// "orders" or, with daily numbering, "orders-20260116". Value is the last
+// number handed out.
+type OrderNumberSequence struct {
+	Name  string `json:"name" gorm:"primaryKey;size:64"`
+	Value uint64 `json:"value" gorm:"not null;default:0"`
+}
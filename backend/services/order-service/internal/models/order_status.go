@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Order statuses. An order starts out pending; delivered and cancelled are
+// final.
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+	// OrderStatusDeleted only appears as ToStatus in the status history,
+	// recording the deletion of an order
+	OrderStatusDeleted = "deleted"
+)
+
+// Roles in which an actor changes the status of an order.
+const (
+	ActorRoleCustomer = "customer"
+	ActorRoleStaff    = "staff"
+)
+
+// OrderStatusHistory records one status change of an order. The first entry
+// of every order has an empty FromStatus and records its placement. ActorID
+// is 0 for OAuth2 clients, which are identified by ActorClientID instead, and
+// for customers whose account has been erased.
+type OrderStatusHistory struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	OrderID       uint      `json:"order_id" gorm:"not null;index"`
+	FromStatus    string    `json:"from_status" gorm:"size:32;not null;default:''"`
+	ToStatus      string    `json:"to_status" gorm:"size:32;not null"`
+	ActorID       uint      `json:"actor_id" gorm:"not null;default:0;index"`
+	ActorClientID string    `json:"actor_client_id,omitempty" gorm:"size:255"`
+	ActorRole     string    `json:"actor_role" gorm:"size:16;not null"`
+	Reason        string    `json:"reason,omitempty" gorm:"size:255"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
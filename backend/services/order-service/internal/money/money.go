@@ -0,0 +1,151 @@
+// Package money represents amounts exactly, as integers in the minor unit of
+// their currency (cents for USD), instead of as floats, which can not hold
+// most decimal fractions and drift when summed.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency = errors.New("unknown ISO 4217 currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+	// ErrTooPrecise is returned for amounts with more decimal places than
+	// the minor unit of their currency. Amounts are never rounded implicitly.
+	ErrTooPrecise = errors.New("amount has more decimal places than the currency allows")
+	ErrOverflow   = errors.New("amount out of range")
+)
+
+// Amount is a number of minor units of some currency. Which currency is kept
+// alongside, e.g. in Order.Currency.
+type Amount int64
+
+// Parse reads a decimal such as "10.5" or "-3.25" as an amount of cur.
+// Exponents are not accepted, and neither are non-zero digits beyond the
+// minor unit: "10.505" is not a USD amount, "10.500" is.
+func Parse(s string, cur Currency) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	if len(fraction) > cur.Digits {
+		if strings.Trim(fraction[cur.Digits:], "0") != "" {
+			return 0, fmt.Errorf("%w: %s has %d decimal places", ErrTooPrecise, cur.Code, cur.Digits)
+		}
+		fraction = fraction[:cur.Digits]
+	}
+	fraction += strings.Repeat("0", cur.Digits-len(fraction))
+
+	digits := whole + fraction
+	if digits == "" {
+		// e.g. ".000" in a currency without minor unit
+		digits = "0"
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Amount(minor), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Format writes the amount as a decimal with exactly the number of decimal
+// places of cur: "24.25" for USD, "1000" for JPY, "1.500" for KWD.
+func (a Amount) Format(cur Currency) string {
+	minor := int64(a)
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUint(minor), 10)
+	if cur.Digits == 0 {
+		return sign + digits
+	}
+	if len(digits) <= cur.Digits {
+		digits = strings.Repeat("0", cur.Digits-len(digits)+1) + digits
+	}
+	split := len(digits) - cur.Digits
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+func absUint(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+// Add returns a + b, or ErrOverflow.
+func (a Amount) Add(b Amount) (Amount, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, ErrOverflow
+	}
+	return sum, nil
+}
+
+// Mul returns the amount times quantity, or ErrOverflow.
+func (a Amount) Mul(quantity int64) (Amount, error) {
+	if a == 0 || quantity == 0 {
+		return 0, nil
+	}
+	product := int64(a) * quantity
+	if product/quantity != int64(a) || (int64(a) == math.MinInt64 && quantity == -1) {
+		return 0, ErrOverflow
+	}
+	return Amount(product), nil
+}
+
+// Decimal is an amount as it appears in a request, before its currency is
+// known. It accepts both JSON numbers and strings and keeps their text, so
+// 10.10 is read as exactly 10.10 and never passes through a float.
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*d = Decimal(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+	}
+	*d = Decimal(n)
+	return nil
+}
+
+// Amount parses the decimal as an amount of cur.
+func (d Decimal) Amount(cur Currency) (Amount, error) {
+	return Parse(string(d), cur)
+}
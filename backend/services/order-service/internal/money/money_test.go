@@ -0,0 +1,114 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustCurrency(t *testing.T, code string) Currency {
+	cur, err := LookupCurrency(code)
+	require.NoError(t, err)
+	return cur
+}
+
+func TestLookupCurrency(t *testing.T) {
+	cur, err := LookupCurrency("eur")
+	require.NoError(t, err)
+	assert.Equal(t, Currency{Code: "EUR", Digits: 2}, cur)
+
+	assert.Equal(t, 0, mustCurrency(t, "JPY").Digits)
+	assert.Equal(t, 3, mustCurrency(t, "KWD").Digits)
+
+	_, err = LookupCurrency("ABC")
+	assert.True(t, errors.Is(err, ErrUnknownCurrency))
+	_, err = LookupCurrency("XAU")
+	assert.True(t, errors.Is(err, ErrUnknownCurrency))
+}
+
+func TestParse(t *testing.T) {
+	usd := mustCurrency(t, "USD")
+	jpy := mustCurrency(t, "JPY")
+	kwd := mustCurrency(t, "KWD")
+
+	tests := []struct {
+		in   string
+		cur  Currency
+		want Amount
+	}{
+		{"10.10", usd, 1010},
+		{"10.1", usd, 1010},
+		{"10", usd, 1000},
+		{".5", usd, 50},
+		{"0.07", usd, 7},
+		{"-3.25", usd, -325},
+		{"10.500", usd, 1050},
+		{"1500", jpy, 1500},
+		{"1500.00", jpy, 1500},
+		{"1.5", kwd, 1500},
+		{"0.001", kwd, 1},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.cur)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	_, err := Parse("10.505", usd)
+	assert.True(t, errors.Is(err, ErrTooPrecise))
+	_, err = Parse("1500.5", jpy)
+	assert.True(t, errors.Is(err, ErrTooPrecise))
+
+	for _, in := range []string{"", ".", "-", "1e3", "1,50", "abc", "1.2.3"} {
+		_, err := Parse(in, usd)
+		assert.True(t, errors.Is(err, ErrInvalidAmount), in)
+	}
+
+	_, err = Parse("100000000000000000000", usd)
+	assert.True(t, errors.Is(err, ErrOverflow))
+}
+
+func TestFormat(t *testing.T) {
+	usd := mustCurrency(t, "USD")
+
+	assert.Equal(t, "24.25", Amount(2425).Format(usd))
+	assert.Equal(t, "0.07", Amount(7).Format(usd))
+	assert.Equal(t, "0.00", Amount(0).Format(usd))
+	assert.Equal(t, "-0.05", Amount(-5).Format(usd))
+	assert.Equal(t, "1500", Amount(1500).Format(mustCurrency(t, "JPY")))
+	assert.Equal(t, "1.500", Amount(1500).Format(mustCurrency(t, "KWD")))
+	assert.Equal(t, "-92233720368547758.08", Amount(math.MinInt64).Format(usd))
+}
+
+func TestArithmetic(t *testing.T) {
+	// 0.1 + 0.2 is exactly 0.3, unlike with floats
+	sum, err := Amount(10).Add(20)
+	require.NoError(t, err)
+	assert.Equal(t, Amount(30), sum)
+
+	product, err := Amount(1010).Mul(3)
+	require.NoError(t, err)
+	assert.Equal(t, Amount(3030), product)
+
+	_, err = Amount(math.MaxInt64).Add(1)
+	assert.True(t, errors.Is(err, ErrOverflow))
+	_, err = Amount(math.MaxInt64 / 2).Mul(3)
+	assert.True(t, errors.Is(err, ErrOverflow))
+}
+
+func TestDecimal_UnmarshalJSON(t *testing.T) {
+	var in struct {
+		Number Decimal `json:"number"`
+		String Decimal `json:"string"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"number": 10.10, "string": "3.25"}`), &in))
+
+	assert.Equal(t, Decimal("10.10"), in.Number)
+	assert.Equal(t, Decimal("3.25"), in.String)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"number": true}`), &in))
+}
@@ -0,0 +1,169 @@
+// Package ordernumber generates the human-readable numbers orders are
+// referred to by. Numbers have to be unique across all replicas of the
+// service, so generators either draw from a shared sequence or are random
+// enough never to collide.
+package ordernumber
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	GeneratorSequence = "sequence"
+	GeneratorULID     = "ulid"
+
+	DefaultPrefix = "ORD"
+
+	// sequenceDigits is the minimum width of the sequence part of a number
+	sequenceDigits = 8
+)
+
+// Generator hands out order numbers. Next must never return the same number
+// twice, also not when called concurrently from several replicas.
+type Generator interface {
+	Next() (string, error)
+}
+
+// SequenceStore hands out consecutive values of named counters, starting at
+// 1. NextValue must be atomic across replicas.
+type SequenceStore interface {
+	NextValue(name string) (uint64, error)
+}
+
+// SequenceOptions shape the numbers of a SequenceGenerator.
+type SequenceOptions struct {
+	// Prefix starts every number, e.g. "ORD"
+	Prefix string
+	// Daily puts the date (UTC) after the prefix and restarts the sequence
+	// every day: ORD-20260116-00000042 instead of ORD-00000042.
+	Daily bool
+	// CheckDigit appends a Luhn check digit to the sequence part, so typos in
+	// a number read out or typed in can be detected with Valid.
+	CheckDigit bool
+}
+
+// SequenceGenerator numbers orders consecutively from a shared counter.
+type SequenceGenerator struct {
+	store   SequenceStore
+	options SequenceOptions
+	now     func() time.Time
+}
+
+func NewSequenceGenerator(store SequenceStore, options SequenceOptions) *SequenceGenerator {
+	if options.Prefix == "" {
+		options.Prefix = DefaultPrefix
+	}
+	return &SequenceGenerator{store: store, options: options, now: time.Now}
+}
+
+func (g *SequenceGenerator) Next() (string, error) {
+	name := "orders"
+	parts := []string{g.options.Prefix}
+	if g.options.Daily {
+		day := g.now().UTC().Format("20060102")
+		name += "-" + day
+		parts = append(parts, day)
+	}
+
+	value, err := g.store.NextValue(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next order number: %w", err)
+	}
+
+	digits := fmt.Sprintf("%0*d", sequenceDigits, value)
+	if g.options.CheckDigit {
+		digits += strconv.Itoa(luhnCheckDigit(digits))
+	}
+	return strings.Join(append(parts, digits), "-"), nil
+}
+
+// Valid reports whether the last group of digits of a number generated with
+// CheckDigit ends in the right check digit.
+func Valid(number string) bool {
+	digits := number[strings.LastIndex(number, "-")+1:]
+	if len(digits) < 2 {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	last := len(digits) - 1
+	return luhnCheckDigit(digits[:last]) == int(digits[last]-'0')
+}
+
+// luhnCheckDigit computes the Luhn (mod 10) check digit of a string of
+// decimal digits. It catches every single-digit error and most swaps of
+// adjacent digits.
+func luhnCheckDigit(digits string) int {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+// ULIDGenerator numbers orders with ULIDs: a millisecond timestamp followed
+// by 80 random bits, so numbers sort by creation time and need no shared
+// state.
+type ULIDGenerator struct {
+	prefix string
+	now    func() time.Time
+}
+
+func NewULIDGenerator(prefix string) *ULIDGenerator {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &ULIDGenerator{prefix: prefix, now: time.Now}
+}
+
+func (g *ULIDGenerator) Next() (string, error) {
+	id, err := newULID(g.now())
+	if err != nil {
+		return "", fmt.Errorf("failed to generate order number: %w", err)
+	}
+	return g.prefix + "-" + id, nil
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID encodes 48 bits of Unix milliseconds and 80 random bits as 26
+// Crockford base32 characters.
+func newULID(t time.Time) (string, error) {
+	var raw [16]byte
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(raw[:6], ms[2:])
+	if _, err := rand.Read(raw[6:]); err != nil {
+		return "", err
+	}
+
+	// 128 bits in 26 characters of 5 bits: the first character carries only
+	// the top 3 bits
+	var out [26]byte
+	hi := binary.BigEndian.Uint64(raw[:8])
+	lo := binary.BigEndian.Uint64(raw[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:]), nil
+}
@@ -0,0 +1,126 @@
+package ordernumber
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySequenceStore stands in for the database counters.
+type memorySequenceStore struct {
+	mu     sync.Mutex
+	values map[string]uint64
+	err    error
+}
+
+func newMemorySequenceStore() *memorySequenceStore {
+	return &memorySequenceStore{values: make(map[string]uint64)}
+}
+
+func (s *memorySequenceStore) NextValue(name string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.values[name]++
+	return s.values[name], nil
+}
+
+func TestSequenceGenerator(t *testing.T) {
+	day := time.Date(2026, 1, 16, 23, 59, 0, 0, time.UTC)
+
+	t.Run("plain", func(t *testing.T) {
+		g := NewSequenceGenerator(newMemorySequenceStore(), SequenceOptions{})
+
+		first, err := g.Next()
+		require.NoError(t, err)
+		second, err := g.Next()
+		require.NoError(t, err)
+
+		assert.Equal(t, "ORD-00000001", first)
+		assert.Equal(t, "ORD-00000002", second)
+	})
+
+	t.Run("daily with check digit", func(t *testing.T) {
+		g := NewSequenceGenerator(newMemorySequenceStore(), SequenceOptions{Prefix: "SO", Daily: true, CheckDigit: true})
+		g.now = func() time.Time { return day }
+
+		number, err := g.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "SO-20260116-000000018", number)
+		assert.True(t, Valid(number))
+
+		// The sequence restarts the next day
+		g.now = func() time.Time { return day.Add(time.Minute) }
+		number, err = g.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "SO-20260117-000000018", number)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		store := newMemorySequenceStore()
+		store.err = errors.New("database down")
+		g := NewSequenceGenerator(store, SequenceOptions{})
+
+		_, err := g.Next()
+		assert.Error(t, err)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		g := NewSequenceGenerator(newMemorySequenceStore(), SequenceOptions{Daily: true, CheckDigit: true})
+
+		var mu sync.Mutex
+		seen := make(map[string]bool)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				number, err := g.Next()
+				assert.NoError(t, err)
+				mu.Lock()
+				seen[number] = true
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, seen, 50)
+	})
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("ORD-20260116-000000422"))
+	assert.False(t, Valid("ORD-20260116-000000423"))
+	// Swapped adjacent digits
+	assert.False(t, Valid("ORD-20260116-000004022"))
+	assert.False(t, Valid("ORD-01KF0X"))
+	assert.False(t, Valid("ORD-"))
+}
+
+func TestULIDGenerator(t *testing.T) {
+	g := NewULIDGenerator("")
+	g.now = func() time.Time { return time.UnixMilli(1768608000000) }
+
+	first, err := g.Next()
+	require.NoError(t, err)
+	second, err := g.Next()
+	require.NoError(t, err)
+
+	assert.Regexp(t, regexp.MustCompile(`^ORD-[0-9A-HJKMNP-TV-Z]{26}$`), first)
+	assert.NotEqual(t, first, second)
+	// Both share the encoded timestamp, the first 10 characters of the ULID
+	assert.Equal(t, first[:14], second[:14])
+
+	g.now = func() time.Time { return time.UnixMilli(1768608000001) }
+	later, err := g.Next()
+	require.NoError(t, err)
+	assert.Less(t, first[:14], later[:14])
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"order-service/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository is the shared middleware.IdempotencyStore used when
+// several replicas have to agree on which request owns a key.
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve relies on the unique index on the key: of concurrent inserts only
+// one succeeds, the others get the row that won. An expired row for the key
+// is replaced.
+func (r *IdempotencyRepository) Reserve(record *models.IdempotencyKey) (*models.IdempotencyKey, bool, error) {
+	var existing models.IdempotencyKey
+	reserved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("idempotency_key = ? AND expires_at <= ?", record.Key, time.Now().UTC()).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			reserved = true
+			return nil
+		}
+
+		return tx.Where("idempotency_key = ?", record.Key).First(&existing).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if reserved {
+		return record, true, nil
+	}
+	return &existing, false, nil
+}
+
+func (r *IdempotencyRepository) Complete(key string, statusCode int, contentType string, body []byte) error {
+	return r.db.Model(&models.IdempotencyKey{}).Where("idempotency_key = ?", key).Updates(map[string]interface{}{
+		"status_code":   statusCode,
+		"content_type":  contentType,
+		"response_body": body,
+	}).Error
+}
+
+func (r *IdempotencyRepository) Release(key string) error {
+	return r.db.Where("idempotency_key = ?", key).Delete(&models.IdempotencyKey{}).Error
+}
+
+func (r *IdempotencyRepository) DeleteExpired(before time.Time) error {
+	return r.db.Where("expires_at < ?", before).Delete(&models.IdempotencyKey{}).Error
+}
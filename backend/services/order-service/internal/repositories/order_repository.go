@@ -1,13 +1,23 @@
 package repositories
 
 import (
+	"errors"
 	"fmt"
 	"order-service/internal/models"
+	"order-service/internal/money"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrStatusChanged is returned by UpdateStatus and Delete when the order's
+// status was changed by someone else in the meantime.
+var ErrStatusChanged = errors.New("order status was changed concurrently")
+
+// OrderRepository stores orders. Queries are scoped to one organization
+// (tenant), except the ones serving account erasure, which span all
+// organizations of the user.
 type OrderRepository struct {
 	db *gorm.DB
 }
@@ -16,81 +26,230 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-func (r *OrderRepository) Create(order *models.Order) error {
-	fmt.Printf("=== Create called with order items: %d ===\n", len(order.OrderItems))
-	for i, item := range order.OrderItems {
-		fmt.Printf("Item %d: %+v\n", i, item)
+// tenant restricts a query to the orders of the organization orgID.
+func tenant(orgID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("orders.org_id = ?", orgID)
 	}
-	
+}
+
+// Create stores an order with its items and records its placement as the
+// first entry of the status history. The IDs of order and items are set on
+// success.
+func (r *OrderRepository) Create(order *models.Order) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		fmt.Printf("=== Transaction started ===\n")
-		// Extract order items
-		orderItems := order.OrderItems
-		
-		// Create order without OrderItems using raw SQL to avoid GORM relationship handling
-		orderResult := tx.Exec(`
-			INSERT INTO orders (user_id, order_number, status, total_amount, currency, created_at, updated_at, deleted_at) 
-			VALUES (?, ?, ?, ?, ?, NOW(), NOW(), NULL)
-		`, order.UserID, order.OrderNumber, order.Status, order.TotalAmount, order.Currency)
-		
-		if orderResult.Error != nil {
-			return orderResult.Error
+		// Items are inserted separately, once the order ID is known
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+			return err
+		}
+
+		for i := range order.OrderItems {
+			order.OrderItems[i].OrderID = order.ID
 		}
-		
-		// Get the new order ID
-		var newOrderID uint
-		tx.Raw("SELECT LAST_INSERT_ID()").Scan(&newOrderID)
-		order.ID = newOrderID
-
-		// Now create order items using raw SQL
-		fmt.Printf("=== Creating %d order items ===\n", len(orderItems))
-		for i, item := range orderItems {
-			fmt.Printf("=== Executing INSERT for item %d: %+v ===\n", i, item)
-			itemResult := tx.Exec(`
-				INSERT INTO order_items (order_id, product_id, product_name, quantity, unit_price, total_price, created_at, updated_at, deleted_at) 
-				VALUES (?, ?, ?, ?, ?, ?, NOW(), NOW(), NULL)
-			`, order.ID, item.ProductID, item.ProductName, item.Quantity, item.UnitPrice, item.TotalPrice)
-			
-			fmt.Printf("=== Item result: %d rows, error: %v ===\n", itemResult.RowsAffected, itemResult.Error)
-			if itemResult.Error != nil {
-				return itemResult.Error
+		if len(order.OrderItems) > 0 {
+			if err := tx.Create(&order.OrderItems).Error; err != nil {
+				return err
 			}
 		}
 
-		return nil
+		return tx.Create(&models.OrderStatusHistory{
+			OrderID:   order.ID,
+			ToStatus:  order.Status,
+			ActorID:   order.UserID,
+			ActorRole: models.ActorRoleCustomer,
+		}).Error
 	})
 }
 
-func (r *OrderRepository) GetByID(id uint) (*models.Order, error) {
+func (r *OrderRepository) GetByID(orgID, id uint) (*models.Order, error) {
 	var order models.Order
-	err := r.db.Preload("OrderItems").First(&order, id).Error
+	err := r.db.Scopes(tenant(orgID)).Preload("OrderItems").First(&order, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &order, nil
 }
 
-func (r *OrderRepository) GetByUserID(userID uint, offset, limit int) ([]*models.Order, error) {
+// OrderFilter narrows down the orders listed by List. Zero fields do not
+// filter.
+type OrderFilter struct {
+	UserID *uint
+	Status string
+	// CreatedFrom is inclusive, CreatedBefore exclusive
+	CreatedFrom   *time.Time
+	CreatedBefore *time.Time
+	// MinTotal and MaxTotal are inclusive and in Currency
+	Currency  string
+	MinTotal  *money.Amount
+	MaxTotal  *money.Amount
+	ProductID string
+}
+
+func (f *OrderFilter) scope(db *gorm.DB) *gorm.DB {
+	if f.UserID != nil {
+		db = db.Where("orders.user_id = ?", *f.UserID)
+	}
+	if f.Status != "" {
+		db = db.Where("orders.status = ?", f.Status)
+	}
+	if f.CreatedFrom != nil {
+		db = db.Where("orders.created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedBefore != nil {
+		db = db.Where("orders.created_at < ?", *f.CreatedBefore)
+	}
+	if f.Currency != "" {
+		db = db.Where("orders.currency = ?", f.Currency)
+	}
+	if f.MinTotal != nil {
+		db = db.Where("orders.total_minor >= ?", *f.MinTotal)
+	}
+	if f.MaxTotal != nil {
+		db = db.Where("orders.total_minor <= ?", *f.MaxTotal)
+	}
+	if f.ProductID != "" {
+		db = db.Where(`EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id
+			AND order_items.product_id = ? AND order_items.deleted_at IS NULL)`, f.ProductID)
+	}
+	return db
+}
+
+// Columns orders can be sorted by. Ties are broken by ID in the same
+// direction, so the order is total and pages never overlap.
+const (
+	OrderSortCreatedAt = "created_at"
+	OrderSortTotal     = "total_minor"
+)
+
+// OrderPage selects one page of a sorted list of orders. With After the page
+// starts behind that position (keyset pagination) and Offset is ignored.
+type OrderPage struct {
+	SortColumn string
+	Descending bool
+	After      *OrderKeyset
+	Offset     int
+	Limit      int
+}
+
+// OrderKeyset is the position of an order in a list sorted by a column: its
+// value in that column and its ID.
+type OrderKeyset struct {
+	Value interface{}
+	ID    uint
+}
+
+func (p *OrderPage) scope(db *gorm.DB) *gorm.DB {
+	column := "orders." + p.SortColumn
+	direction, compare := "ASC", ">"
+	if p.Descending {
+		direction, compare = "DESC", "<"
+	}
+
+	if p.After != nil {
+		db = db.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND orders.id %[2]s ?))", column, compare),
+			p.After.Value, p.After.Value, p.After.ID)
+	} else if p.Offset > 0 {
+		db = db.Offset(p.Offset)
+	}
+	return db.Order(column + " " + direction).Order("orders.id " + direction).Limit(p.Limit)
+}
+
+// List returns one page of the orders of the organization orgID matching
+// filter, and the number of matching orders on all pages.
+func (r *OrderRepository) List(orgID uint, filter *OrderFilter, page *OrderPage) ([]*models.Order, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Order{}).Scopes(tenant(orgID), filter.scope).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var orders []*models.Order
-	err := r.db.Preload("OrderItems").Where("user_id = ?", userID).
-		Offset(offset).Limit(limit).Find(&orders).Error
+	err := r.db.Scopes(tenant(orgID), filter.scope, page.scope).Preload("OrderItems").Find(&orders).Error
+	return orders, total, err
+}
+
+// GetAllByUserID returns every order of a user with its items, including
+// deleted ones, oldest first, in all organizations.
+func (r *OrderRepository) GetAllByUserID(userID uint) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := r.db.Unscoped().
+		Preload("OrderItems", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", userID).Order("id").Find(&orders).Error
 	return orders, err
 }
 
+// Pseudonymize detaches every order of a user in all organizations,
+// including deleted ones, from the account and marks them with customerRef
+// instead. The user is also removed as actor from the status history of all
+// orders. It returns the number of orders changed.
+func (r *OrderRepository) Pseudonymize(userID uint, customerRef string) (int64, error) {
+	var count int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.Order{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"user_id":      0,
+			"customer_ref": customerRef,
+			"erased_at":    time.Now().UTC(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		count = result.RowsAffected
+
+		return tx.Model(&models.OrderStatusHistory{}).Where("actor_id = ?", userID).Update("actor_id", 0).Error
+	})
+	return count, err
+}
+
+// Update saves an order loaded with GetByID, which keeps it in its tenant.
 func (r *OrderRepository) Update(order *models.Order) error {
 	return r.db.Save(order).Error
 }
 
-func (r *OrderRepository) Delete(id uint) error {
+// UpdateStatus moves an order loaded with GetByID from its current status to
+// entry.ToStatus and records entry in the status history. It fails with
+// ErrStatusChanged if the order is no longer in entry.FromStatus.
+func (r *OrderRepository) UpdateStatus(order *models.Order, entry *models.OrderStatusHistory) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("order_id = ?", id).Delete(&models.OrderItem{}).Error; err != nil {
-			return err
+		result := tx.Model(&models.Order{}).Where("id = ? AND status = ?", order.ID, entry.FromStatus).
+			Update("status", entry.ToStatus)
+		if result.Error != nil {
+			return result.Error
 		}
-		return tx.Delete(&models.Order{}, id).Error
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+
+		entry.OrderID = order.ID
+		return tx.Create(entry).Error
 	})
 }
 
-func (r *OrderRepository) GenerateOrderNumber() string {
-	timestamp := time.Now().Format("20060102150405")
-	return "ORD-" + timestamp
+// GetStatusHistory returns the status changes of an order of the
+// organization orgID, oldest first.
+func (r *OrderRepository) GetStatusHistory(orgID, orderID uint) ([]*models.OrderStatusHistory, error) {
+	var history []*models.OrderStatusHistory
+	err := r.db.Joins("JOIN orders ON orders.id = order_status_history.order_id").Scopes(tenant(orgID)).
+		Where("order_status_history.order_id = ?", orderID).
+		Order("order_status_history.id").Find(&history).Error
+	return history, err
+}
+
+// Delete deletes an order loaded with GetByID and its items, and records
+// entry in the status history. It fails with ErrStatusChanged if the order
+// is no longer in entry.FromStatus or was deleted in the meantime.
+func (r *OrderRepository) Delete(order *models.Order, entry *models.OrderStatusHistory) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("status = ?", entry.FromStatus).Delete(&models.Order{}, order.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+		if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderItem{}).Error; err != nil {
+			return err
+		}
+
+		entry.OrderID = order.ID
+		return tx.Create(entry).Error
+	})
 }
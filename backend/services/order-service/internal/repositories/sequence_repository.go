@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+)
+
+// SequenceRepository is the ordernumber.SequenceStore backed by the
+// order_number_sequences table. Incrementing a counter locks its row, so
+// every replica gets distinct values.
+type SequenceRepository struct {
+	db *gorm.DB
+}
+
+func NewSequenceRepository(db *gorm.DB) *SequenceRepository {
+	return &SequenceRepository{db: db}
+}
+
+// NextValue creates the counter at 1 or increments it, and reads the new
+// value back with LAST_INSERT_ID(), which is kept per connection; the
+// transaction keeps both statements on the same one.
+func (r *SequenceRepository) NextValue(name string) (uint64, error) {
+	var value uint64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO order_number_sequences (name, value) VALUES (?, LAST_INSERT_ID(1))
+			ON DUPLICATE KEY UPDATE value = LAST_INSERT_ID(value + 1)
+		`, name).Error; err != nil {
+			return err
+		}
+		return tx.Raw("SELECT LAST_INSERT_ID()").Scan(&value).Error
+	})
+	return value, err
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"order-service/internal/models"
+	"order-service/internal/money"
+	"order-service/internal/repositories"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListLimit = 10
+	maxListLimit     = 100
+)
+
+// ErrInvalidListQuery is returned for filters, sort orders or cursors a list
+// of orders can not be made from.
+var ErrInvalidListQuery = errors.New("invalid query")
+
+// ListOrdersRequest filters, sorts and pages a list of orders. Dates are
+// RFC 3339 timestamps or plain dates (2006-01-02); a plain date in To
+// includes the whole day. MinTotal and MaxTotal are in Currency, which
+// defaults to USD when either is given, and only orders in that currency
+// match. Sort is created_at or total, descending with a leading "-";
+// newest first by default. Sorting by total needs a currency, since totals
+// in different currencies can not be compared. Cursor continues from the
+// NextCursor of the previous page, with the same filters and sort order;
+// Offset is ignored with a cursor.
+type ListOrdersRequest struct {
+	Status    string        `form:"status" binding:"omitempty,oneof=pending confirmed shipped delivered cancelled"`
+	From      string        `form:"from"`
+	To        string        `form:"to"`
+	Currency  string        `form:"currency" binding:"omitempty,len=3"`
+	MinTotal  money.Decimal `form:"min_total"`
+	MaxTotal  money.Decimal `form:"max_total"`
+	ProductID string        `form:"product_id"`
+	Sort      string        `form:"sort" binding:"omitempty,oneof=created_at -created_at total -total"`
+	Cursor    string        `form:"cursor"`
+	Offset    int           `form:"offset" binding:"min=0"`
+	Limit     int           `form:"limit" binding:"min=0"`
+}
+
+// ListOrdersResponse is one page of orders. TotalCount counts the matching
+// orders on all pages; NextCursor is empty on the last page.
+type ListOrdersResponse struct {
+	Orders     []*models.Order `json:"orders"`
+	TotalCount int64           `json:"total_count"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// listCursor is the content of an opaque cursor: the sort order and a hash
+// of the filters it was made for, and the position of the last order of the
+// page.
+type listCursor struct {
+	Sort   string `json:"s"`
+	Filter string `json:"f"`
+	Value  string `json:"v"`
+	ID     uint   `json:"id"`
+}
+
+// listOrders runs req against the orders of the organization orgID,
+// restricted further by filter.
+func (s *OrderService) listOrders(orgID uint, filter *repositories.OrderFilter, req *ListOrdersRequest) (*ListOrdersResponse, error) {
+	if err := parseListFilter(req, filter); err != nil {
+		return nil, err
+	}
+
+	sort := req.Sort
+	if sort == "" {
+		sort = "-created_at"
+	}
+	page := &repositories.OrderPage{
+		SortColumn: repositories.OrderSortCreatedAt,
+		Descending: strings.HasPrefix(sort, "-"),
+		Offset:     req.Offset,
+		Limit:      req.Limit,
+	}
+	if strings.TrimPrefix(sort, "-") == "total" {
+		page.SortColumn = repositories.OrderSortTotal
+	}
+	if page.Limit <= 0 {
+		page.Limit = defaultListLimit
+	}
+	if page.Limit > maxListLimit {
+		page.Limit = maxListLimit
+	}
+	limit := page.Limit
+	filterKey := filterHash(filter)
+
+	if req.Cursor != "" {
+		after, err := decodeCursor(req.Cursor, sort, filterKey)
+		if err != nil {
+			return nil, err
+		}
+		page.After = after
+	}
+
+	// One more than asked for tells whether there is a next page
+	page.Limit++
+	orders, total, err := s.orderRepo.List(orgID, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ListOrdersResponse{Orders: orders, TotalCount: total}
+	if len(orders) > limit {
+		resp.Orders = orders[:limit]
+		resp.NextCursor = encodeCursor(sort, filterKey, resp.Orders[limit-1])
+	}
+	if resp.Orders == nil {
+		resp.Orders = []*models.Order{}
+	}
+	return resp, nil
+}
+
+func parseListFilter(req *ListOrdersRequest, filter *repositories.OrderFilter) error {
+	filter.Status = req.Status
+	filter.ProductID = req.ProductID
+
+	var err error
+	if filter.CreatedFrom, err = parseDateBound(req.From, false); err != nil {
+		return fmt.Errorf("%w: from: %v", ErrInvalidListQuery, err)
+	}
+	if filter.CreatedBefore, err = parseDateBound(req.To, true); err != nil {
+		return fmt.Errorf("%w: to: %v", ErrInvalidListQuery, err)
+	}
+
+	currencyCode := req.Currency
+	if currencyCode == "" && (req.MinTotal != "" || req.MaxTotal != "") {
+		currencyCode = money.DefaultCurrency
+	}
+	if currencyCode == "" {
+		if strings.TrimPrefix(req.Sort, "-") == "total" {
+			return fmt.Errorf("%w: sort=total requires a currency", ErrInvalidListQuery)
+		}
+		return nil
+	}
+	currency, err := money.LookupCurrency(currencyCode)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidListQuery, err)
+	}
+	filter.Currency = currency.Code
+
+	if filter.MinTotal, err = parseAmountBound(req.MinTotal, currency); err != nil {
+		return fmt.Errorf("%w: min_total: %v", ErrInvalidListQuery, err)
+	}
+	if filter.MaxTotal, err = parseAmountBound(req.MaxTotal, currency); err != nil {
+		return fmt.Errorf("%w: max_total: %v", ErrInvalidListQuery, err)
+	}
+	return nil
+}
+
+// parseDateBound reads an RFC 3339 timestamp or a plain date. A plain date
+// that ends a range is moved to the start of the next day, since ranges
+// exclude their end.
+func parseDateBound(s string, end bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, errors.New("expected an RFC 3339 timestamp or a date like 2006-01-02")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+func parseAmountBound(d money.Decimal, currency money.Currency) (*money.Amount, error) {
+	if d == "" {
+		return nil, nil
+	}
+	amount, err := d.Amount(currency)
+	if err != nil {
+		return nil, err
+	}
+	return &amount, nil
+}
+
+// filterHash identifies the filters of a list, including the restrictions of
+// the caller, so that a cursor can not continue a different list.
+func filterHash(filter *repositories.OrderFilter) string {
+	data, _ := json.Marshal(filter)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodeCursor(sort, filter string, last *models.Order) string {
+	cursor := listCursor{Sort: sort, Filter: filter, ID: last.ID}
+	if strings.TrimPrefix(sort, "-") == "total" {
+		cursor.Value = strconv.FormatInt(int64(last.TotalAmount), 10)
+	} else {
+		cursor.Value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s, sort, filter string) (*repositories.OrderKeyset, error) {
+	invalid := fmt.Errorf("%w: invalid cursor", ErrInvalidListQuery)
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, invalid
+	}
+	if cursor.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was made for sort=%s", ErrInvalidListQuery, cursor.Sort)
+	}
+	if cursor.Filter != filter {
+		return nil, fmt.Errorf("%w: cursor was made for other filters", ErrInvalidListQuery)
+	}
+
+	keyset := &repositories.OrderKeyset{ID: cursor.ID}
+	if strings.TrimPrefix(sort, "-") == "total" {
+		total, err := strconv.ParseInt(cursor.Value, 10, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		keyset.Value = total
+	} else {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, invalid
+		}
+		keyset.Value = createdAt
+	}
+	return keyset, nil
+}
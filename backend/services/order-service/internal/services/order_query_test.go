@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"order-service/internal/models"
+	"order-service/internal/money"
+	"order-service/internal/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListFilter(t *testing.T) {
+	t.Run("dates and amounts", func(t *testing.T) {
+		var filter repositories.OrderFilter
+		err := parseListFilter(&ListOrdersRequest{
+			Status:    "shipped",
+			From:      "2026-01-01",
+			To:        "2026-01-31",
+			MinTotal:  "10.5",
+			MaxTotal:  "100",
+			ProductID: "p-1",
+		}, &filter)
+		require.NoError(t, err)
+
+		assert.Equal(t, "shipped", filter.Status)
+		assert.Equal(t, "p-1", filter.ProductID)
+		assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *filter.CreatedFrom)
+		// A plain end date includes the whole day
+		assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), *filter.CreatedBefore)
+		assert.Equal(t, "USD", filter.Currency)
+		assert.Equal(t, money.Amount(1050), *filter.MinTotal)
+		assert.Equal(t, money.Amount(10000), *filter.MaxTotal)
+	})
+
+	t.Run("total sort in a currency", func(t *testing.T) {
+		var filter repositories.OrderFilter
+		require.NoError(t, parseListFilter(&ListOrdersRequest{Sort: "total", MinTotal: "10"}, &filter))
+		assert.Equal(t, "USD", filter.Currency)
+	})
+
+	t.Run("timestamps and currency", func(t *testing.T) {
+		var filter repositories.OrderFilter
+		err := parseListFilter(&ListOrdersRequest{
+			From:     "2026-01-01T10:00:00+02:00",
+			Currency: "jpy",
+			MinTotal: "1500",
+		}, &filter)
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC), *filter.CreatedFrom)
+		assert.Nil(t, filter.CreatedBefore)
+		assert.Equal(t, "JPY", filter.Currency)
+		assert.Equal(t, money.Amount(1500), *filter.MinTotal)
+		assert.Nil(t, filter.MaxTotal)
+	})
+
+	t.Run("no filters", func(t *testing.T) {
+		var filter repositories.OrderFilter
+		require.NoError(t, parseListFilter(&ListOrdersRequest{}, &filter))
+		assert.Equal(t, repositories.OrderFilter{}, filter)
+	})
+
+	for name, req := range map[string]*ListOrdersRequest{
+		"bad date":         {From: "yesterday"},
+		"unknown currency": {Currency: "ABC"},
+		"bad amount":       {MinTotal: "ten"},
+		"too precise":      {MaxTotal: "10.505"},
+		"total sort":       {Sort: "-total"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := parseListFilter(req, &repositories.OrderFilter{})
+			assert.True(t, errors.Is(err, ErrInvalidListQuery))
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	order := &models.Order{
+		ID:          42,
+		TotalAmount: 2425,
+		CreatedAt:   time.Date(2026, 1, 16, 9, 30, 0, 123000000, time.UTC),
+	}
+
+	t.Run("created_at", func(t *testing.T) {
+		keyset, err := decodeCursor(encodeCursor("-created_at", "f", order), "-created_at", "f")
+		require.NoError(t, err)
+		assert.Equal(t, uint(42), keyset.ID)
+		assert.Equal(t, order.CreatedAt, keyset.Value)
+	})
+
+	t.Run("total", func(t *testing.T) {
+		keyset, err := decodeCursor(encodeCursor("total", "f", order), "total", "f")
+		require.NoError(t, err)
+		assert.Equal(t, uint(42), keyset.ID)
+		assert.Equal(t, int64(2425), keyset.Value)
+	})
+
+	t.Run("other sort order", func(t *testing.T) {
+		_, err := decodeCursor(encodeCursor("total", "f", order), "-total", "f")
+		assert.True(t, errors.Is(err, ErrInvalidListQuery))
+	})
+
+	t.Run("other filters", func(t *testing.T) {
+		userID := uint(7)
+		shipped := filterHash(&repositories.OrderFilter{UserID: &userID, Status: "shipped"})
+		delivered := filterHash(&repositories.OrderFilter{UserID: &userID, Status: "delivered"})
+		assert.NotEqual(t, shipped, delivered)
+
+		_, err := decodeCursor(encodeCursor("total", shipped, order), "total", delivered)
+		assert.True(t, errors.Is(err, ErrInvalidListQuery))
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		for _, cursor := range []string{"bogus!", "e30", "eyJzIjoidG90YWwiLCJ2IjoieCIsImlkIjoxfQ"} {
+			_, err := decodeCursor(cursor, "total", "f")
+			assert.True(t, errors.Is(err, ErrInvalidListQuery), cursor)
+		}
+	})
+}
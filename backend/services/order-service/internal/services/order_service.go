@@ -1,10 +1,15 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"order-service/internal/middleware"
 	"order-service/internal/models"
+	"order-service/internal/money"
+	"order-service/internal/ordernumber"
 	"order-service/internal/repositories"
 	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -12,73 +17,118 @@ import (
 )
 
 type OrderService struct {
-	orderRepo *repositories.OrderRepository
+	orderRepo    *repositories.OrderRepository
+	orderNumbers ordernumber.Generator
 }
 
-func NewOrderService(orderRepo *repositories.OrderRepository) *OrderService {
+func NewOrderService(orderRepo *repositories.OrderRepository, orderNumbers ordernumber.Generator) *OrderService {
 	return &OrderService{
-		orderRepo: orderRepo,
+		orderRepo:    orderRepo,
+		orderNumbers: orderNumbers,
 	}
 }
 
 type CreateOrderRequest struct {
+	// Currency is an ISO 4217 code; orders without one are in USD
+	Currency   string                   `json:"currency" binding:"omitempty,len=3"`
 	OrderItems []CreateOrderItemRequest `json:"order_items" binding:"required,min=1"`
 }
 
 type CreateOrderItemRequest struct {
-	ProductID   string  `json:"product_id" binding:"required"`
-	ProductName string  `json:"product_name" binding:"required"`
-	Quantity    int     `json:"quantity" binding:"required,min=1"`
-	UnitPrice   float64 `json:"unit_price" binding:"required,min=0"`
+	ProductID   string `json:"product_id" binding:"required"`
+	ProductName string `json:"product_name" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required,min=1"`
+	// UnitPrice is a decimal number or string in the order's currency, with
+	// at most as many decimal places as its minor unit
+	UnitPrice money.Decimal `json:"unit_price" binding:"required" swaggertype:"string" example:"10.50"`
 }
 
 type UpdateOrderRequest struct {
 	Status string `json:"status" binding:"required,oneof=pending confirmed shipped delivered cancelled"`
+	// Reason is recorded in the status history; staff must give one when
+	// cancelling a customer's order
+	Reason string `json:"reason" binding:"max=255"`
 }
 
-func (s *OrderService) CreateOrder(userID uint, req *CreateOrderRequest) (*models.Order, error) {
+type EraseUserOrdersResponse struct {
+	OrdersPseudonymized int64 `json:"orders_pseudonymized"`
+}
+
+// CreateOrder places an order for the user in the organization orgID.
+func (s *OrderService) CreateOrder(orgID, userID uint, req *CreateOrderRequest) (*models.Order, error) {
 	start := time.Now()
 	defer func() {
 		middleware.RecordDatabaseQuery("order_create", time.Since(start))
 	}()
 
-	order := &models.Order{
-		UserID:      userID,
-		OrderNumber: s.orderRepo.GenerateOrderNumber(),
-		Status:      "pending",
-		Currency:    "USD",
+	currencyCode := req.Currency
+	if currencyCode == "" {
+		currencyCode = money.DefaultCurrency
+	}
+	currency, err := money.LookupCurrency(currencyCode)
+	if err != nil {
+		return nil, err
 	}
 
 	itemMap := make(map[string]*models.OrderItem)
-	
+	var productIDs []string
+
 	for _, item := range req.OrderItems {
-		totalPrice := float64(item.Quantity) * item.UnitPrice
-		
+		unitPrice, err := item.UnitPrice.Amount(currency)
+		if err != nil {
+			return nil, fmt.Errorf("unit price of product %s: %w", item.ProductID, err)
+		}
+		if unitPrice < 0 {
+			return nil, fmt.Errorf("unit price of product %s must not be negative", item.ProductID)
+		}
+		totalPrice, err := unitPrice.Mul(int64(item.Quantity))
+		if err != nil {
+			return nil, fmt.Errorf("total price of product %s: %w", item.ProductID, err)
+		}
+
 		if existingItem, exists := itemMap[item.ProductID]; exists {
 			existingItem.Quantity += item.Quantity
-			existingItem.TotalPrice += totalPrice
+			if existingItem.TotalPrice, err = existingItem.TotalPrice.Add(totalPrice); err != nil {
+				return nil, fmt.Errorf("total price of product %s: %w", item.ProductID, err)
+			}
 		} else {
 			orderItem := &models.OrderItem{
 				ProductID:   item.ProductID,
 				ProductName: item.ProductName,
 				Quantity:    item.Quantity,
-				UnitPrice:   item.UnitPrice,
+				UnitPrice:   unitPrice,
 				TotalPrice:  totalPrice,
 			}
 			itemMap[item.ProductID] = orderItem
+			productIDs = append(productIDs, item.ProductID)
 		}
 	}
 
 	var orderItems []models.OrderItem
-	var totalAmount float64
+	var totalAmount money.Amount
 
-	for _, item := range itemMap {
+	for _, productID := range productIDs {
+		item := itemMap[productID]
 		orderItems = append(orderItems, *item)
-		totalAmount += item.TotalPrice
+		if totalAmount, err = totalAmount.Add(item.TotalPrice); err != nil {
+			return nil, fmt.Errorf("order total: %w", err)
+		}
+	}
+
+	orderNumber, err := s.orderNumbers.Next()
+	if err != nil {
+		return nil, err
 	}
 
-	order.TotalAmount = totalAmount
-	order.OrderItems = orderItems
+	order := &models.Order{
+		UserID:      userID,
+		OrgID:       orgID,
+		OrderNumber: orderNumber,
+		Status:      models.OrderStatusPending,
+		Currency:    currency.Code,
+		TotalAmount: totalAmount,
+		OrderItems:  orderItems,
+	}
 
 	if err := s.orderRepo.Create(order); err != nil {
 		return nil, err
@@ -86,69 +136,166 @@ func (s *OrderService) CreateOrder(userID uint, req *CreateOrderRequest) (*model
 
 	middleware.RecordOrderCreated()
 
-	return s.orderRepo.GetByID(order.ID)
+	return s.orderRepo.GetByID(orgID, order.ID)
 }
 
-func (s *OrderService) GetOrder(orderID uint) (*models.Order, error) {
+func (s *OrderService) GetOrder(orgID, orderID uint) (*models.Order, error) {
 	start := time.Now()
 	defer func() {
 		middleware.RecordDatabaseQuery("order_get", time.Since(start))
 	}()
 
-	return s.orderRepo.GetByID(orderID)
+	return s.orderRepo.GetByID(orgID, orderID)
 }
 
-func (s *OrderService) GetOrdersByUserID(userID uint, offset, limit int) ([]*models.Order, error) {
+// GetOrdersByUserID lists the orders of a user in the organization orgID.
+func (s *OrderService) GetOrdersByUserID(orgID, userID uint, req *ListOrdersRequest) (*ListOrdersResponse, error) {
 	start := time.Now()
 	defer func() {
 		middleware.RecordDatabaseQuery("orders_list", time.Since(start))
 	}()
 
-	return s.orderRepo.GetByUserID(userID, offset, limit)
+	return s.listOrders(orgID, &repositories.OrderFilter{UserID: &userID}, req)
 }
 
-func (s *OrderService) UpdateOrder(orderID uint, req *UpdateOrderRequest) (*models.Order, error) {
+// GetOrdersByOrgID lists the orders of all users of an organization.
+func (s *OrderService) GetOrdersByOrgID(orgID uint, req *ListOrdersRequest) (*ListOrdersResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("orders_list_org", time.Since(start))
+	}()
+
+	if orgID == 0 {
+		return nil, errors.New("invalid organization ID")
+	}
+
+	return s.listOrders(orgID, &repositories.OrderFilter{}, req)
+}
+
+// ExportUserOrders returns every order of a user in all organizations,
+// including deleted ones, for a data export.
+func (s *OrderService) ExportUserOrders(userID uint) ([]*models.Order, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("orders_export", time.Since(start))
+	}()
+
+	if userID == 0 {
+		return nil, errors.New("invalid user ID")
+	}
+
+	return s.orderRepo.GetAllByUserID(userID)
+}
+
+// EraseUserOrders pseudonymizes the orders of a user whose account is being
+// erased. The orders are kept for accounting under a new random customer
+// reference that is not stored anywhere else. Erasing a user without orders,
+// or again, changes nothing.
+func (s *OrderService) EraseUserOrders(userID uint) (*EraseUserOrdersResponse, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("orders_erase", time.Since(start))
+	}()
+
+	if userID == 0 {
+		return nil, errors.New("invalid user ID")
+	}
+
+	ref := make([]byte, 16)
+	if _, err := rand.Read(ref); err != nil {
+		return nil, err
+	}
+
+	count, err := s.orderRepo.Pseudonymize(userID, "cust_"+hex.EncodeToString(ref))
+	if err != nil {
+		return nil, err
+	}
+
+	return &EraseUserOrdersResponse{OrdersPseudonymized: count}, nil
+}
+
+// UpdateOrder moves an order to req.Status if the order lifecycle allows the
+// actor to, and records the change in the status history.
+func (s *OrderService) UpdateOrder(orgID, orderID uint, actor *Actor, req *UpdateOrderRequest) (*models.Order, error) {
 	start := time.Now()
 	defer func() {
 		middleware.RecordDatabaseQuery("order_update", time.Since(start))
 	}()
 
-	order, err := s.orderRepo.GetByID(orderID)
+	order, err := s.orderRepo.GetByID(orgID, orderID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("order not found")
+			return nil, ErrOrderNotFound
 		}
 		return nil, err
 	}
 
-	order.Status = req.Status
+	if err := checkTransition(order, req.Status, actor, req.Reason); err != nil {
+		return nil, err
+	}
 
-	if err := s.orderRepo.Update(order); err != nil {
+	entry := &models.OrderStatusHistory{
+		FromStatus:    order.Status,
+		ToStatus:      req.Status,
+		ActorID:       actor.UserID,
+		ActorClientID: actor.ClientID,
+		ActorRole:     actor.Role(),
+		Reason:        req.Reason,
+	}
+	if err := s.orderRepo.UpdateStatus(order, entry); err != nil {
+		if errors.Is(err, repositories.ErrStatusChanged) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTransition, err)
+		}
 		return nil, err
 	}
 
-	return s.orderRepo.GetByID(orderID)
+	return s.orderRepo.GetByID(orgID, orderID)
+}
+
+// GetOrderHistory returns the status changes of an order, oldest first.
+func (s *OrderService) GetOrderHistory(orgID, orderID uint) ([]*models.OrderStatusHistory, error) {
+	start := time.Now()
+	defer func() {
+		middleware.RecordDatabaseQuery("order_history", time.Since(start))
+	}()
+
+	return s.orderRepo.GetStatusHistory(orgID, orderID)
 }
 
-func (s *OrderService) DeleteOrder(orderID uint) error {
+// DeleteOrder soft-deletes an order if the actor may, and records the
+// deletion in the status history.
+func (s *OrderService) DeleteOrder(orgID, orderID uint, actor *Actor) error {
 	start := time.Now()
 	defer func() {
 		middleware.RecordDatabaseQuery("order_delete", time.Since(start))
 	}()
 
-	order, err := s.orderRepo.GetByID(orderID)
+	order, err := s.orderRepo.GetByID(orgID, orderID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("order not found")
+			return ErrOrderNotFound
 		}
 		return err
 	}
 
-	if order.Status == "shipped" || order.Status == "delivered" {
-		return errors.New("cannot delete order that is shipped or delivered")
+	if err := checkDeletion(order, actor); err != nil {
+		return err
 	}
 
-	return s.orderRepo.Delete(orderID)
+	entry := &models.OrderStatusHistory{
+		FromStatus:    order.Status,
+		ToStatus:      models.OrderStatusDeleted,
+		ActorID:       actor.UserID,
+		ActorClientID: actor.ClientID,
+		ActorRole:     actor.Role(),
+	}
+	if err := s.orderRepo.Delete(order, entry); err != nil {
+		if errors.Is(err, repositories.ErrStatusChanged) {
+			return fmt.Errorf("%w: %v", ErrInvalidTransition, err)
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *OrderService) ValidateUserID(userIDStr string) (uint, error) {
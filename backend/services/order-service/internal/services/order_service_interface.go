@@ -3,10 +3,14 @@ package services
 import "order-service/internal/models"
 
 type IOrderService interface {
-	CreateOrder(userID uint, req *CreateOrderRequest) (*models.Order, error)
-	GetOrder(orderID uint) (*models.Order, error)
-	UpdateOrder(orderID uint, req *UpdateOrderRequest) (*models.Order, error)
-	DeleteOrder(orderID uint) error
-	GetOrdersByUserID(userID uint, offset, limit int) ([]*models.Order, error)
+	CreateOrder(orgID, userID uint, req *CreateOrderRequest) (*models.Order, error)
+	GetOrder(orgID, orderID uint) (*models.Order, error)
+	UpdateOrder(orgID, orderID uint, actor *Actor, req *UpdateOrderRequest) (*models.Order, error)
+	GetOrderHistory(orgID, orderID uint) ([]*models.OrderStatusHistory, error)
+	DeleteOrder(orgID, orderID uint, actor *Actor) error
+	GetOrdersByUserID(orgID, userID uint, req *ListOrdersRequest) (*ListOrdersResponse, error)
+	GetOrdersByOrgID(orgID uint, req *ListOrdersRequest) (*ListOrdersResponse, error)
+	ExportUserOrders(userID uint) ([]*models.Order, error)
+	EraseUserOrders(userID uint) (*EraseUserOrdersResponse, error)
 	ValidateUserID(userIDStr string) (uint, error)
 }
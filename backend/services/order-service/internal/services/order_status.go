@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"order-service/internal/models"
+)
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrInvalidTransition is returned for a status change the order
+	// lifecycle does not allow, or whose guard fails
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrTransitionForbidden is returned when the actor may not make a status
+	// change the lifecycle allows, e.g. a customer shipping their own order
+	ErrTransitionForbidden = errors.New("status change not permitted")
+)
+
+// Actor is the caller changing the status of an order. Staff are callers
+// allowed to update any order; everybody else acts as the customer.
+type Actor struct {
+	UserID   uint
+	ClientID string
+	Staff    bool
+}
+
+// Role is the actor role recorded in the status history.
+func (a *Actor) Role() string {
+	if a.Staff {
+		return models.ActorRoleStaff
+	}
+	return models.ActorRoleCustomer
+}
+
+// transition is an allowed status change. Customers may only make the ones
+// not marked staffOnly. The guard, if any, checks the order and the reason
+// given before the change is made.
+type transition struct {
+	staffOnly bool
+	guard     func(order *models.Order, actor *Actor, reason string) error
+}
+
+// orderTransitions is the order lifecycle:
+//
+//	pending -> confirmed -> shipped -> delivered
+//	   |           |
+//	   +-----------+-> cancelled
+//
+// Customers may only cancel; confirming, shipping and delivering are up to
+// staff. Orders can not be cancelled once shipped.
+var orderTransitions = map[string]map[string]transition{
+	models.OrderStatusPending: {
+		models.OrderStatusConfirmed: {staffOnly: true, guard: requireItems},
+		models.OrderStatusCancelled: {guard: requireStaffReason},
+	},
+	models.OrderStatusConfirmed: {
+		models.OrderStatusShipped:   {staffOnly: true, guard: requireItems},
+		models.OrderStatusCancelled: {guard: requireStaffReason},
+	},
+	models.OrderStatusShipped: {
+		models.OrderStatusDelivered: {staffOnly: true},
+	},
+}
+
+// checkTransition reports whether the actor may move the order to status.
+func checkTransition(order *models.Order, status string, actor *Actor, reason string) error {
+	t, ok := orderTransitions[order.Status][status]
+	if !ok {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, order.Status, status)
+	}
+
+	if t.staffOnly && !actor.Staff {
+		return fmt.Errorf("%w: only staff can move an order to %s", ErrTransitionForbidden, status)
+	}
+
+	if t.guard != nil {
+		return t.guard(order, actor, reason)
+	}
+	return nil
+}
+
+// checkDeletion reports whether the actor may delete the order. Customers may
+// only delete orders that are still pending; anything later is up to staff,
+// and nobody deletes an order once it has shipped.
+func checkDeletion(order *models.Order, actor *Actor) error {
+	switch order.Status {
+	case models.OrderStatusShipped, models.OrderStatusDelivered:
+		return fmt.Errorf("%w: cannot delete an order that is %s", ErrInvalidTransition, order.Status)
+	case models.OrderStatusPending:
+		return nil
+	}
+
+	if !actor.Staff {
+		return fmt.Errorf("%w: only staff can delete a %s order", ErrTransitionForbidden, order.Status)
+	}
+	return nil
+}
+
+// requireItems keeps orders without items from being confirmed or shipped.
+func requireItems(order *models.Order, actor *Actor, reason string) error {
+	if len(order.OrderItems) == 0 {
+		return fmt.Errorf("%w: order has no items", ErrInvalidTransition)
+	}
+	return nil
+}
+
+// requireStaffReason makes staff give the customer a reason when they cancel
+// an order that is not their own.
+func requireStaffReason(order *models.Order, actor *Actor, reason string) error {
+	if actor.Staff && !order.OwnedBy(actor.UserID) && reason == "" {
+		return fmt.Errorf("%w: a reason is required to cancel a customer's order", ErrInvalidTransition)
+	}
+	return nil
+}
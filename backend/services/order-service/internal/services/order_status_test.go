@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"order-service/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDeletion(t *testing.T) {
+	customer := &Actor{UserID: 1}
+	staff := &Actor{UserID: 2, Staff: true}
+
+	tests := []struct {
+		status string
+		actor  *Actor
+		want   error
+	}{
+		{models.OrderStatusPending, customer, nil},
+		{models.OrderStatusPending, staff, nil},
+		{models.OrderStatusConfirmed, customer, ErrTransitionForbidden},
+		{models.OrderStatusConfirmed, staff, nil},
+		{models.OrderStatusCancelled, customer, ErrTransitionForbidden},
+		{models.OrderStatusCancelled, staff, nil},
+		{models.OrderStatusShipped, staff, ErrInvalidTransition},
+		{models.OrderStatusDelivered, staff, ErrInvalidTransition},
+	}
+	for _, tt := range tests {
+		err := checkDeletion(&models.Order{UserID: 1, Status: tt.status}, tt.actor)
+		if tt.want == nil {
+			assert.NoError(t, err, tt.status)
+		} else {
+			assert.True(t, errors.Is(err, tt.want), "%s by %s: %v", tt.status, tt.actor.Role(), err)
+		}
+	}
+}
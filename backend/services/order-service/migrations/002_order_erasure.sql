@@ -0,0 +1,8 @@
+USE order_db;
+
+-- Orders of erased accounts are kept for accounting but detached from the
+-- user: user_id becomes 0 and customer_ref a random per-customer reference
+ALTER TABLE orders
+    ADD COLUMN customer_ref VARCHAR(64) NULL,
+    ADD COLUMN erased_at TIMESTAMP NULL,
+    ADD INDEX idx_customer_ref (customer_ref);
@@ -0,0 +1,7 @@
+USE order_db;
+
+-- Orders belong to the organization (tenant) they were placed in. Existing
+-- orders and orders placed outside any organization have org_id 0.
+ALTER TABLE orders
+    ADD COLUMN org_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    ADD INDEX idx_org_user (org_id, user_id);
@@ -0,0 +1,25 @@
+USE order_db;
+
+-- Every status change of an order, including its placement (from_status '').
+-- actor_id is 0 for OAuth2 clients, identified by actor_client_id instead,
+-- and for customers whose account has been erased.
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    order_id BIGINT UNSIGNED NOT NULL,
+    from_status VARCHAR(32) NOT NULL DEFAULT '',
+    to_status VARCHAR(32) NOT NULL,
+    actor_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    actor_client_id VARCHAR(255) NULL,
+    actor_role VARCHAR(16) NOT NULL,
+    reason VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_order_id (order_id),
+    INDEX idx_actor_id (actor_id),
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+-- Existing orders get their placement as first entry.
+INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, created_at)
+SELECT o.id, '', 'pending', o.user_id, 'customer', o.created_at
+FROM orders o
+WHERE NOT EXISTS (SELECT 1 FROM order_status_history h WHERE h.order_id = o.id);
@@ -0,0 +1,17 @@
+USE order_db;
+
+-- Responses to requests made with an Idempotency-Key header. idempotency_key
+-- is a hash of the caller, the route and the header value; status_code is 0
+-- while the first request is still being handled.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    idempotency_key VARCHAR(64) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    content_type VARCHAR(255) NULL,
+    response_body MEDIUMBLOB NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    UNIQUE INDEX idx_idempotency_key (idempotency_key),
+    INDEX idx_expires_at (expires_at)
+);
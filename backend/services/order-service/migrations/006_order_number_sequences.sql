@@ -0,0 +1,8 @@
+USE order_db;
+
+-- Counters order numbers are drawn from, e.g. "orders" or, with daily
+-- numbering, "orders-20260116". value is the last number handed out.
+CREATE TABLE IF NOT EXISTS order_number_sequences (
+    name VARCHAR(64) NOT NULL PRIMARY KEY,
+    value BIGINT UNSIGNED NOT NULL DEFAULT 0
+);
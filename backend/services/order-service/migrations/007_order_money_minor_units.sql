@@ -0,0 +1,20 @@
+USE order_db;
+
+-- Amounts are stored as integers in the minor unit of the order's currency
+-- (cents for USD) instead of DECIMAL(10,2), which can not hold currencies
+-- with three decimal places. All existing orders are in USD.
+ALTER TABLE orders
+    ADD COLUMN total_minor BIGINT NOT NULL DEFAULT 0 AFTER total_amount;
+UPDATE orders SET total_minor = ROUND(total_amount * 100);
+UPDATE orders SET currency = 'USD' WHERE currency IS NULL OR currency = '';
+ALTER TABLE orders
+    DROP COLUMN total_amount,
+    MODIFY currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE order_items
+    ADD COLUMN unit_price_minor BIGINT NOT NULL DEFAULT 0 AFTER unit_price,
+    ADD COLUMN total_price_minor BIGINT NOT NULL DEFAULT 0 AFTER total_price;
+UPDATE order_items SET unit_price_minor = ROUND(unit_price * 100), total_price_minor = ROUND(total_price * 100);
+ALTER TABLE order_items
+    DROP COLUMN unit_price,
+    DROP COLUMN total_price;
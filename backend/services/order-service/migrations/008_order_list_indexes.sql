@@ -0,0 +1,8 @@
+USE order_db;
+
+-- Order lists are sorted by created_at or total_minor, with id breaking
+-- ties, within an organization and usually one user.
+ALTER TABLE orders
+    ADD INDEX idx_org_user_created (org_id, user_id, created_at, id),
+    ADD INDEX idx_org_created (org_id, created_at, id),
+    ADD INDEX idx_org_total (org_id, total_minor, id);